@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 
 	// "net/http"
 	"os"
@@ -18,26 +19,54 @@ import (
 	"github.com/jekyulll/url_shortener/database"
 	"github.com/jekyulll/url_shortener/internal/api"
 	"github.com/jekyulll/url_shortener/internal/cache"
+	"github.com/jekyulll/url_shortener/internal/emails"
+	"github.com/jekyulll/url_shortener/internal/middleware"
 	"github.com/jekyulll/url_shortener/internal/repository"
 	"github.com/jekyulll/url_shortener/internal/service"
+	"github.com/jekyulll/url_shortener/internal/web"
 	"github.com/jekyulll/url_shortener/pkg/email"
 	"github.com/jekyulll/url_shortener/pkg/filter"
 	"github.com/jekyulll/url_shortener/pkg/hasher"
 	"github.com/jekyulll/url_shortener/pkg/jwt"
+	"github.com/jekyulll/url_shortener/pkg/qrcode"
 	"github.com/jekyulll/url_shortener/pkg/randnum"
+	"github.com/jekyulll/url_shortener/pkg/ratelimit"
 	"github.com/jekyulll/url_shortener/pkg/shortcode"
 	"gorm.io/gorm"
 )
 
+// 批量创建的行数额度在 rate_limit.groups 中的名称
+const bulkRowsGroup = "bulk_rows"
+
 type Application struct {
-	r           *gin.Engine
-	db          *gorm.DB
-	redisCache  *cache.RedisCache
-	jwt         *jwt.JWT
-	cfg         *config.Config
-	urlService  *service.URLService
-	urlHandler  *api.URLHandler
-	userHandler *api.UserHandler
+	r                *gin.Engine
+	db               *gorm.DB
+	redisCache       *cache.RedisCache
+	jwt              *jwt.JWT
+	cfg              *config.Config
+	urlService       *service.URLService
+	userService      *service.UserService
+	apiKeyService    *service.APIKeyService
+	urlHandler       *api.URLHandler
+	userHandler      *api.UserHandler
+	domainHandler    *api.DomainHandler
+	pageHandler      *api.PageHandler
+	apiKeyHandler    *api.APIKeyHandler
+	oidcHandler      *api.OIDCHandler
+	totpHandler      *api.TOTPHandler
+	adminHandler     *api.AdminHandler
+	workspaceHandler *api.WorkspaceHandler
+	emailHandler     *api.EmailHandler
+	emailOutbox      *service.EmailOutbox
+	reminderHandler  *api.ReminderHandler
+	reminderService  *service.ReminderService
+	webhookHandler   *api.WebhookHandler
+	webhookService   *service.WebhookService
+	bulkHandler      *api.BulkHandler
+	bulkService      *service.BulkService
+	tagHandler       *api.TagHandler
+	folderHandler    *api.FolderHandler
+	rateLimits       map[string]gin.HandlerFunc
 }
 
 func New() *Application {
@@ -69,32 +98,90 @@ func (a *Application) Init(configPath string) error {
 	log.Println("init: redis connected")
 
 	// pkg
-	emailSender, err := email.NewEmailSend(cfg.Email)
+	// 邮件写入 outbox 后由后台任务投递，启动时不连接邮件服务器
+	transport, err := email.NewTransport(cfg.Email)
 	if err != nil {
 		return err
 	}
+	emailTemplates, err := emails.Load(cfg.Email.TemplateDir, cfg.Email.DefaultLocale)
+	if err != nil {
+		return fmt.Errorf("failed to load email templates: %w", err)
+	}
+	a.emailOutbox = service.NewEmailOutbox(repository.NewEmailOutboxRepo(a.db), transport, emailTemplates, cfg.Email)
+	emailSender := a.emailOutbox
 	log.Println("init: email initialized")
 
 	passwordHash := hasher.NewPassworkHash()
 
-	a.jwt = jwt.NewJWT(cfg.JWT)
+	a.jwt, err = jwt.NewJWT(cfg.JWT)
+	if err != nil {
+		return fmt.Errorf("failed to init jwt: %w", err)
+	}
 
 	randNum := randnum.NewRandNum(cfg.RandNum)
 
-	generator := shortcode.NewShortCodeGeneratorImpl(cfg.ShortCode.Length)
+	generator, err := shortcode.NewGenerator(cfg.ShortCode)
+	if err != nil {
+		return err
+	}
 
 	// cuntomValidator := validator.NewCustomValidator()
 
 	filter := filter.New(a.cfg.Filter.Capacity, a.cfg.Filter.ErrorRate)
 
-	urlRepo := repository.NewURLRepo(a.db)
-	userRepo := repository.NewUserRepo(a.db)
+	qrGenerator, err := qrcode.NewGenerator(cfg.QRCode)
+	if err != nil {
+		return err
+	}
 
-	a.urlService = service.NewURLService(urlRepo, filter, generator, redisCache, cfg.App)
-	userService := service.NewUserService(userRepo, passwordHash, a.jwt, redisCache, emailSender, randNum)
+	// 限流：Redis 不可用时退回内存计数
+	var bulkQuota service.BulkQuota
+	if cfg.RateLimit.Enabled {
+		limiter := ratelimit.NewFallback(redisCache, ratelimit.NewMemory())
+		a.rateLimits = make(map[string]gin.HandlerFunc)
+		for group, rule := range cfg.RateLimit.Groups {
+			// 批量创建按行数计入额度，由 BulkService 扣除
+			if group == bulkRowsGroup {
+				bulkQuota.Limiter = limiter
+				bulkQuota.Rule, err = middleware.RateLimitRule(group, rule)
+			} else {
+				a.rateLimits[group], err = middleware.RateLimit(limiter, group, rule)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
 
-	a.urlHandler = api.NewURLHandler(a.urlService)
-	a.userHandler = api.NewUserHandler(userService)
+	urlRepo := repository.NewURLRepo(a.db)
+	userRepo := repository.NewUserRepo(a.db)
+	domainRepo := repository.NewDomainRepo(a.db)
+	workspaceRepo := repository.NewWorkspaceRepo(a.db)
+
+	a.webhookService = service.NewWebhookService(repository.NewWebhookRepo(a.db), net.DefaultResolver, cfg.Webhook)
+	a.urlService = service.NewURLService(urlRepo, domainRepo, workspaceRepo, filter, generator, redisCache, qrGenerator, a.webhookService, cfg.App)
+	a.userService = service.NewUserService(userRepo, passwordHash, a.jwt, redisCache, emailSender, randNum, redisCache, redisCache, cfg.JWT, cfg.Lockout)
+
+	pageService := service.NewLandingPageService(repository.NewLandingPageRepo(a.db), domainRepo, cfg.App)
+
+	a.urlHandler = api.NewURLHandler(a.urlService, pageService)
+	a.userHandler = api.NewUserHandler(a.userService)
+	a.domainHandler = api.NewDomainHandler(service.NewDomainService(domainRepo, net.DefaultResolver, cfg.App))
+	a.pageHandler = api.NewPageHandler(pageService)
+	a.apiKeyService = service.NewAPIKeyService(repository.NewAPIKeyRepo(a.db), userRepo)
+	a.apiKeyHandler = api.NewAPIKeyHandler(a.apiKeyService)
+	a.adminHandler = api.NewAdminHandler(service.NewAdminService(userRepo, a.urlService, a.userService))
+	a.totpHandler = api.NewTOTPHandler(service.NewTOTPService(userRepo, repository.NewRecoveryCodeRepo(a.db), redisCache, a.userService, qrGenerator, cfg.App))
+	a.workspaceHandler = api.NewWorkspaceHandler(service.NewWorkspaceService(workspaceRepo, userRepo, emailSender, cfg.App))
+	a.emailHandler = api.NewEmailHandler(a.emailOutbox)
+	a.reminderService = service.NewReminderService(a.urlService, userRepo, emailSender, redisCache, cfg.Reminder, cfg.App)
+	a.reminderHandler = api.NewReminderHandler(a.reminderService)
+	a.webhookHandler = api.NewWebhookHandler(a.webhookService)
+	a.bulkService = service.NewBulkService(a.urlService, repository.NewBulkJobRepo(a.db), bulkQuota)
+	a.bulkHandler = api.NewBulkHandler(a.bulkService)
+	a.tagHandler = api.NewTagHandler(service.NewTagService(a.urlService, repository.NewTagRepo(a.db)))
+	a.folderHandler = api.NewFolderHandler(service.NewFolderService(a.urlService, repository.NewFolderRepo(a.db)))
+	a.oidcHandler = api.NewOIDCHandler(service.NewOIDCService(cfg.OIDC, userRepo, repository.NewIdentityRepo(a.db), redisCache, a.userService))
 
 	// TODO
 	// TimeOut未设置
@@ -110,15 +197,25 @@ func (a *Application) Init(configPath string) error {
 	// a.r = r
 
 	a.r = gin.Default()
+	// 限流、登录锁定依赖 ClientIP，只采信配置的代理转发的地址
+	if err := a.r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted_proxies: %w", err)
+	}
 	// 允许所有跨域请求
 	a.r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"}, // 前端地址
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-API-Key"},
+		ExposeHeaders:    []string{"Content-Length", "RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
+	// HTML 模板（预览页、落地页等）
+	tmpl, err := web.LoadTemplates(cfg.App.TemplateDir)
+	if err != nil {
+		return fmt.Errorf("failed to load templates: %w", err)
+	}
+	a.r.SetHTMLTemplate(tmpl)
 	a.initRouter()
 
 	return nil
@@ -128,6 +225,15 @@ func (a *Application) Run() {
 	go a.start()
 	go a.tickSyncViewsToDB()
 	go a.tickCleanUp()
+	go a.tickDeliverEmails()
+	go a.tickDeliverWebhooks()
+	go a.tickBulkJobs()
+	if a.cfg.Reminder.Enabled {
+		go a.tickReminders()
+	}
+	if a.jwt.Asymmetric() {
+		go a.tickRotateKeys()
+	}
 
 	a.shutDown()
 }
@@ -141,6 +247,9 @@ func (a *Application) start() {
 		if err := a.urlService.DeleteAllExpired(context.Background()); err != nil {
 			log.Println(err)
 		}
+		if err := a.urlService.PurgeTrash(context.Background()); err != nil {
+			log.Println(err)
+		}
 	}()
 	// 用 gracehttp 代替 gin 的 Run, 自动优雅退出
 	// ! gracehttp 只能在 Linux 上用
@@ -176,6 +285,19 @@ func (a *Application) tickCleanUp() {
 			if err := a.urlService.DeleteAllExpired(ctx); err != nil {
 				log.Println(err)
 			}
+			// 彻底删除回收站中超过保留期的短链接
+			if err := a.urlService.PurgeTrash(ctx); err != nil {
+				log.Println(err)
+			}
+			if err := a.emailOutbox.PurgeSent(ctx); err != nil {
+				log.Println(err)
+			}
+			if err := a.webhookService.PurgeDeliveries(ctx); err != nil {
+				log.Println(err)
+			}
+			if err := a.bulkService.PurgeJobs(ctx); err != nil {
+				log.Println(err)
+			}
 		}()
 	}
 }
@@ -206,6 +328,62 @@ func (a *Application) tickSyncViewsToDB() {
 	}
 }
 
+// 投递 outbox 中的邮件：定期轮询，本实例有新邮件入队时立即投递，多实例时由拿到锁的实例负责
+func (a *Application) tickDeliverEmails() {
+	ticker := time.NewTicker(a.emailOutbox.PollInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-a.emailOutbox.Wake():
+		}
+		func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+
+			// 其他实例正在投递时跳过，剩下的邮件下次轮询时处理
+			lockKey := "lock:email_outbox"
+			lockValue, ok, err := a.redisCache.AcquireLock(ctx, lockKey, 5*time.Minute)
+			if err != nil || !ok {
+				return
+			}
+			defer a.redisCache.ReleaseLock(ctx, lockKey, lockValue)
+
+			if err := a.emailOutbox.Deliver(ctx); err != nil {
+				log.Printf("failed to deliver emails: %v", err)
+			}
+		}()
+	}
+}
+
+// 各实例定期重新加载签名密钥，拿到锁的实例负责按周期轮换
+func (a *Application) tickRotateKeys() {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+
+			lockKey := "lock:jwt_rotate"
+			lockValue, ok, err := a.redisCache.AcquireLock(ctx, lockKey, time.Minute)
+			if err != nil || !ok {
+				if err := a.jwt.Reload(); err != nil {
+					log.Printf("failed to reload jwt keys: %v", err)
+				}
+				return
+			}
+			defer a.redisCache.ReleaseLock(ctx, lockKey, lockValue)
+
+			if _, err := a.jwt.Rotate(); err != nil {
+				log.Printf("failed to rotate jwt keys: %v", err)
+			}
+		}()
+	}
+}
+
 // func (a *Application) tickRebuildFilter() {
 // 	ticker := time.NewTicker(24 * time.Hour)
 // 	defer ticker.Stop()
@@ -244,3 +422,83 @@ func (a *Application) shutDown() {
 	_, cancle := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancle()
 }
+
+// 推送 webhook：定期轮询，本实例有新事件时立即推送，多实例时由拿到锁的实例负责
+func (a *Application) tickDeliverWebhooks() {
+	ticker := time.NewTicker(a.webhookService.PollInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-a.webhookService.Wake():
+		}
+		func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+
+			lockKey := "lock:webhook_deliveries"
+			lockValue, ok, err := a.redisCache.AcquireLock(ctx, lockKey, 5*time.Minute)
+			if err != nil || !ok {
+				return
+			}
+			defer a.redisCache.ReleaseLock(ctx, lockKey, lockValue)
+
+			if err := a.webhookService.Deliver(ctx); err != nil {
+				log.Printf("failed to deliver webhooks: %v", err)
+			}
+		}()
+	}
+}
+
+// 处理批量创建任务：有新任务时立即处理，未处理完的任务下次继续，多实例时由拿到锁的实例负责
+func (a *Application) tickBulkJobs() {
+	ticker := time.NewTicker(a.bulkService.PollInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-a.bulkService.Wake():
+		}
+		func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+
+			lockKey := "lock:bulk_jobs"
+			lockValue, ok, err := a.redisCache.AcquireLock(ctx, lockKey, 5*time.Minute)
+			if err != nil || !ok {
+				return
+			}
+			defer a.redisCache.ReleaseLock(ctx, lockKey, lockValue)
+
+			if err := a.bulkService.ProcessJobs(ctx); err != nil {
+				log.Printf("failed to process bulk jobs: %v", err)
+			}
+		}()
+	}
+}
+
+// 提醒即将过期的短链接，多实例时由拿到锁的实例负责
+func (a *Application) tickReminders() {
+	ticker := time.NewTicker(a.reminderService.Interval())
+	defer ticker.Stop()
+
+	for range ticker.C {
+		func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+
+			lockKey := "lock:expiry_reminders"
+			lockValue, ok, err := a.redisCache.AcquireLock(ctx, lockKey, 5*time.Minute)
+			if err != nil || !ok {
+				return
+			}
+			defer a.redisCache.ReleaseLock(ctx, lockKey, lockValue)
+
+			if err := a.reminderService.SendReminders(ctx); err != nil {
+				log.Printf("failed to send expiry reminders: %v", err)
+			}
+		}()
+	}
+}
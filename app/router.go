@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/jekyulll/url_shortener/internal/middleware"
+	"github.com/jekyulll/url_shortener/internal/model"
 )
 
 func (a *Application) initRouter() {
@@ -15,22 +16,137 @@ func (a *Application) initRouter() {
 		})
 	})
 
+	// 供其他服务验证本服务签发的 token，HS256 下为空
+	a.r.GET("/.well-known/jwks.json", func(ctx *gin.Context) {
+		ctx.Header("Cache-Control", "public, max-age=300")
+		ctx.JSON(http.StatusOK, a.jwt.JWKS())
+	})
+
 	// 用户认证路由组
 	u := a.r.Group("/api/auth")
 
 	// 用户认证相关端点
-	u.POST("/login", a.userHandler.Login)                  // 用户登录
-	u.POST("/register", a.userHandler.Register)            // 用户注册
-	u.POST("/forget", a.userHandler.ForgetPassword)        // 忘记密码
-	u.GET("/register/:email", a.userHandler.SendEmailCode) // 发送注册验证码
+	u.POST("/login", a.rateLimit("login"), a.userHandler.Login)                       // 用户登录
+	u.POST("/register", a.userHandler.Register)                                       // 用户注册
+	u.POST("/forget", a.userHandler.ForgetPassword)                                   // 忘记密码
+	u.GET("/register/:email", a.rateLimit("email_code"), a.userHandler.SendEmailCode) // 发送注册验证码（真实发信）
+	u.POST("/refresh", a.userHandler.Refresh)                                         // 轮换 refresh token，换取新的 access token
+
+	// 企业身份提供方登录（OIDC）
+	u.GET("/oidc/providers", a.oidcHandler.GetProviders)      // 可用的身份提供方
+	u.GET("/oidc/:provider/login", a.oidcHandler.Login)       // 跳转到 IdP
+	u.GET("/oidc/:provider/callback", a.oidcHandler.Callback) // IdP 回调，返回 token
+
+	auth := middleware.JWTAuther(a.jwt, a.userService)
+	u.POST("/logout", auth, a.userHandler.Logout) // 登出，吊销 token
+
+	// 两步验证（TOTP）
+	u.POST("/login/2fa", a.rateLimit("login"), a.totpHandler.LoginTwoFactor)   // 登录第二步，提交验证码或恢复码
+	u.POST("/2fa/enroll", auth, a.totpHandler.Enroll)                          // 生成密钥和二维码
+	u.POST("/2fa/activate", auth, a.totpHandler.Activate)                      // 验证后开启，返回恢复码
+	u.POST("/2fa/disable", auth, a.totpHandler.Disable)                        // 关闭两步验证
+	u.POST("/2fa/recovery-codes", auth, a.totpHandler.RegenerateRecoveryCodes) // 重新生成恢复码
 
 	// URL缩短服务相关路由
-	a.r.GET("/:code", a.urlHandler.RedirectURL) // 短链接重定向
-
-	// 需要JWT认证的URL管理API
-	url := a.r.Group("/api", middleware.JWTAuther(a.jwt))
-	url.POST("/url", a.urlHandler.CreateURL)                // 创建短链接
-	url.GET("/urls", a.urlHandler.GetURLs)                  // 获取用户的所有短链接
-	url.DELETE("/url/:code", a.urlHandler.DeleteURL)        // 删除短链接
-	url.PATCH("/url/:code", a.urlHandler.UpdateURLDuration) // 更新短链接的有效期
+	a.r.GET("/:code", a.rateLimit("redirect"), a.urlHandler.RedirectURL)          // 短链接重定向（按 Host 区分域名），/:code+ 为预览页
+	a.r.GET("/api/url/:code/qr", a.rateLimit("redirect"), a.urlHandler.GetQRCode) // 短链接二维码（海报等场景，无需登录）
+
+	// 过期提醒邮件中的一键延期，凭令牌操作，无需登录
+	a.r.GET("/api/urls/extend", a.reminderHandler.ExtendPage) // 延期确认页
+	a.r.POST("/api/urls/extend", a.reminderHandler.Extend)    // 确认延期
+
+	// URL管理API，需要JWT认证或个人 API Key（供 CI 等程序化调用）
+	url := a.r.Group("/api", middleware.APIKeyAuther(a.apiKeyService, auth))
+	url.POST("/url", a.rateLimit("create_url"), a.urlHandler.CreateURL)         // 创建短链接
+	url.GET("/urls", a.urlHandler.GetURLs)                                      // 获取所在工作区的短链接，可按工作区过滤
+	url.GET("/urls/export", a.urlHandler.ExportURLs)                            // 流式导出全部短链接（csv、jsonl、xlsx）
+	url.GET("/urls/trash", a.urlHandler.GetTrash)                               // 回收站
+	url.POST("/urls/bulk", a.rateLimit("create_url"), a.bulkHandler.CreateURLs) // 批量创建（JSON 数组或 CSV），async=true 时创建后台任务
+	url.GET("/urls/bulk/:id", a.bulkHandler.GetJob)                             // 批量创建任务的进度和结果
+	url.DELETE("/url/:code", a.urlHandler.DeleteURL)                            // 删除短链接（移入回收站）
+	url.POST("/url/:code/restore", a.urlHandler.RestoreURL)                     // 从回收站恢复
+	url.PATCH("/url/:code", a.urlHandler.UpdateURLDuration)                     // 更新短链接的有效期
+	url.POST("/url/:code/pause", a.urlHandler.PauseURL)                         // 暂停短链接
+	url.POST("/url/:code/resume", a.urlHandler.ResumeURL)                       // 恢复短链接
+	url.PATCH("/url/:code/details", a.urlHandler.UpdateURLDetails)              // 修改标题、描述和备注
+
+	// 整理短链接：标签和文件夹，按工作区划分
+	url.GET("/tags", a.tagHandler.GetTags)                   // 获取所在工作区的标签，可按工作区过滤
+	url.POST("/tags", a.tagHandler.CreateTag)                // 创建标签
+	url.PATCH("/tags/:id", a.tagHandler.UpdateTag)           // 重命名、修改颜色
+	url.DELETE("/tags/:id", a.tagHandler.DeleteTag)          // 删除标签
+	url.POST("/urls/tags", a.tagHandler.TagURLs)             // 批量添加、移除标签
+	url.GET("/folders", a.folderHandler.GetFolders)          // 获取所在工作区的文件夹
+	url.POST("/folders", a.folderHandler.CreateFolder)       // 创建文件夹，可嵌套
+	url.PATCH("/folders/:id", a.folderHandler.UpdateFolder)  // 重命名或移动
+	url.DELETE("/folders/:id", a.folderHandler.DeleteFolder) // 删除空文件夹
+	url.POST("/urls/move", a.folderHandler.MoveURLs)         // 批量移入文件夹
+
+	// 账户设置类API，仅接受JWT
+	account := a.r.Group("/api", auth)
+
+	account.PUT("/me/locale", a.userHandler.SetLocale)             // 设置邮件使用的语言
+	account.GET("/me/reminders", a.reminderHandler.GetSettings)    // 过期提醒设置
+	account.PUT("/me/reminders", a.reminderHandler.UpdateSettings) // 开关过期提醒，设置 webhook 地址
+
+	// 自定义域名
+	account.POST("/domains", a.domainHandler.AddDomain)               // 添加域名，返回需配置的 TXT 记录
+	account.GET("/domains", a.domainHandler.GetDomains)               // 获取用户的所有域名
+	account.POST("/domains/:id/verify", a.domainHandler.VerifyDomain) // 验证域名所有权
+	account.DELETE("/domains/:id", a.domainHandler.DeleteDomain)      // 删除域名
+
+	// 自定义落地页（not_found、expired、disabled）
+	account.PUT("/pages/:kind", a.pageHandler.SetPage)       // 设置落地页模板，可指定域名
+	account.GET("/pages", a.pageHandler.GetPages)            // 获取用户的所有落地页模板
+	account.DELETE("/pages/:kind", a.pageHandler.DeletePage) // 删除落地页模板，恢复默认页面
+
+	// 短链接事件的 webhook 订阅
+	account.POST("/webhooks", a.webhookHandler.CreateWebhook)                                     // 创建订阅，签名密钥仅返回一次
+	account.GET("/webhooks", a.webhookHandler.GetWebhooks)                                        // 获取用户的所有订阅
+	account.PATCH("/webhooks/:id", a.webhookHandler.UpdateWebhook)                                // 修改地址、事件或停用
+	account.DELETE("/webhooks/:id", a.webhookHandler.DeleteWebhook)                               // 删除订阅
+	account.GET("/webhooks/:id/deliveries", a.webhookHandler.GetDeliveries)                       // 投递记录
+	account.POST("/webhooks/:id/deliveries/:delivery_id/replay", a.webhookHandler.ReplayDelivery) // 重放一次投递
+
+	// 个人 API Key
+	account.POST("/keys", a.apiKeyHandler.CreateAPIKey)       // 创建 API Key，完整 key 仅返回一次
+	account.GET("/keys", a.apiKeyHandler.GetAPIKeys)          // 获取用户的所有 API Key
+	account.PATCH("/keys/:id", a.apiKeyHandler.UpdateAPIKey)  // 修改 API Key 名称
+	account.DELETE("/keys/:id", a.apiKeyHandler.RevokeAPIKey) // 吊销 API Key
+
+	// 工作区：成员共享管理短链接
+	account.POST("/workspaces", a.workspaceHandler.CreateWorkspace)                     // 创建团队工作区
+	account.GET("/workspaces", a.workspaceHandler.GetWorkspaces)                        // 获取所在的工作区
+	account.POST("/workspaces/invites/accept", a.workspaceHandler.AcceptInvite)         // 接受邀请
+	account.GET("/workspaces/:id", a.workspaceHandler.GetWorkspace)                     // 工作区详情和成员
+	account.PATCH("/workspaces/:id", a.workspaceHandler.UpdateWorkspace)                // 重命名
+	account.DELETE("/workspaces/:id", a.workspaceHandler.DeleteWorkspace)               // 删除空的团队工作区
+	account.POST("/workspaces/:id/invites", a.workspaceHandler.InviteMember)            // 邮件邀请成员
+	account.PUT("/workspaces/:id/members/:user_id", a.workspaceHandler.UpdateMember)    // 修改成员角色
+	account.DELETE("/workspaces/:id/members/:user_id", a.workspaceHandler.RemoveMember) // 移除成员或退出
+
+	// 管理接口：审核员可查看和强制过期，管理员另可停用账号、修改角色和转移短链接
+	admin := a.r.Group("/api/admin", auth, middleware.RequireRole(model.RoleModerator))
+	admin.GET("/users", a.adminHandler.SearchUsers)                                                         // 查找用户
+	admin.GET("/urls", a.adminHandler.SearchURLs)                                                           // 查找短链接
+	admin.POST("/urls/:code/expire", a.adminHandler.ForceExpireURL)                                         // 强制过期
+	admin.PUT("/users/:id/role", middleware.RequireRole(model.RoleAdmin), a.adminHandler.SetUserRole)       // 修改角色
+	admin.POST("/users/:id/disable", middleware.RequireRole(model.RoleAdmin), a.adminHandler.DisableUser)   // 停用账号
+	admin.POST("/users/:id/enable", middleware.RequireRole(model.RoleAdmin), a.adminHandler.EnableUser)     // 启用账号
+	admin.POST("/urls/:code/transfer", middleware.RequireRole(model.RoleAdmin), a.adminHandler.TransferURL) // 转移短链接
+	admin.GET("/emails", middleware.RequireRole(model.RoleAdmin), a.emailHandler.GetEmails)                 // 邮件投递状态
+	admin.POST("/emails/:id/retry", middleware.RequireRole(model.RoleAdmin), a.emailHandler.RetryEmail)     // 重新投递已放弃的邮件
+
+	// 其余路径统一展示 404 页
+	a.r.NoRoute(a.urlHandler.NotFound)
+}
+
+// rateLimit 返回路由组的限流中间件，未开启或未配置该路由组时不限流
+func (a *Application) rateLimit(group string) gin.HandlerFunc {
+	if limit, ok := a.rateLimits[group]; ok {
+		return limit
+	}
+	return func(c *gin.Context) {
+		c.Next()
+	}
 }
@@ -20,6 +20,12 @@ type Config struct {
 	Email     EmailConfig     `mapstructure:"email"`
 	JWT       JWTConfig       `mapstructure:"jwt"`
 	RandNum   RandNumConfig   `mapstructure:"rand_num"`
+	QRCode    QRCodeConfig    `mapstructure:"qrcode"`
+	OIDC      []OIDCConfig    `mapstructure:"oidc"`
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	Lockout   LockoutConfig   `mapstructure:"lockout"`
+	Reminder  ReminderConfig  `mapstructure:"reminder"`
+	Webhook   WebhookConfig   `mapstructure:"webhook"`
 }
 
 // var Cfg *Config
@@ -91,6 +97,9 @@ type ServerConfig struct {
 	Addr         string        `mapstructure:"addr"`
 	WriteTimeout time.Duration `mapstructure:"write_timeout"`
 	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
+	// 反向代理的地址或网段，只有来自这些地址的 X-Forwarded-For 才被采信。
+	// 为空时直接使用连接的对端地址，限流和登录锁定按真实 IP 计算
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 type AppConfig struct {
@@ -98,10 +107,15 @@ type AppConfig struct {
 	DefaultDuration  time.Duration `mapstructure:"default_duration"`
 	CleanupInterval  time.Duration `mapstructure:"cleanup_interval"`
 	SyncViewDuration time.Duration `mapstructure:"sync_view_interval"`
+	TemplateDir      string        `mapstructure:"template_dir"` // 覆盖内置 HTML 模板的目录，可为空
+	TrashRetention   time.Duration `mapstructure:"trash_retention"`
 }
 
 type ShortCodeConfig struct {
-	Length int `mapstructure:"length"`
+	Length        int      `mapstructure:"length"`
+	Alphabet      string   `mapstructure:"alphabet"`       // base62（默认）、unambiguous（去掉 0/O/o、1/l/I），或直接给出字符集
+	ReservedWords []string `mapstructure:"reserved_words"` // 追加到内置保留词，完全匹配时不可用
+	BlockedWords  []string `mapstructure:"blocked_words"`  // 追加到内置屏蔽词，包含即不可用
 }
 
 type FilterConfig struct {
@@ -118,15 +132,99 @@ type RandNumConfig struct {
 }
 
 type JWTConfig struct {
-	Secret   string        `mapstructure:"secret"`
-	Duration time.Duration `mapstructure:"duration"`
+	Secret          string        `mapstructure:"secret"`
+	Duration        time.Duration `mapstructure:"duration"`         // access token 有效期
+	RefreshDuration time.Duration `mapstructure:"refresh_duration"` // refresh token 有效期，每次刷新都会轮换
+	Issuer          string        `mapstructure:"issuer"`           // 设置后签发和验证时都会检查 iss
+
+	// 非对称签名：algorithm 为 RS256 或 EdDSA 时，私钥保存在 key_dir 中并定期轮换，
+	// 公钥通过 /.well-known/jwks.json 发布，secret 不再使用
+	Algorithm        string        `mapstructure:"algorithm"` // HS256（默认）、RS256、EdDSA
+	KeyDir           string        `mapstructure:"key_dir"`
+	RotationInterval time.Duration `mapstructure:"rotation_interval"`
+	KeyRetention     time.Duration `mapstructure:"key_retention"` // 旧密钥被替换后仍可用于验证的时间
 }
 
 type EmailConfig struct {
+	Transport   string `mapstructure:"transport"` // smtp（默认）、file（写入 dir 下的 maildir）、memory
 	Password    string `mapstructure:"password"`
 	Username    string `mapstructure:"username"`
 	HostAddress string `mapstructure:"host_address"`
 	HostPort    string `mapstructure:"host_port"`
-	Subject     string `mapstructure:"subject"`
-	TestMail    string `mapstructure:"test_mail"`
+	From        string `mapstructure:"from"` // 为空时使用 username
+	// 连接 SMTP 服务器并发送一封邮件的超时时间，默认 30s
+	Timeout time.Duration `mapstructure:"timeout"`
+	Dir     string        `mapstructure:"dir"`
+
+	// 邮件模板：template_dir/<语言>/<名字>.txt、.html 覆盖内置模板，也可以增加新的语言
+	TemplateDir   string `mapstructure:"template_dir"`
+	DefaultLocale string `mapstructure:"default_locale"` // 找不到用户语言对应的模板时使用，默认 en
+
+	// 邮件先写入 outbox 表，由后台任务投递，失败后按 retry_delay 指数退避重试，
+	// 达到 max_attempts 次后不再重试（dead），可由管理员重新投递
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	MaxAttempts  int           `mapstructure:"max_attempts"`
+	RetryDelay   time.Duration `mapstructure:"retry_delay"`
+}
+
+type QRCodeConfig struct {
+	Size       int    `mapstructure:"size"`
+	Level      string `mapstructure:"level"`
+	Margin     *int   `mapstructure:"margin"` // 未配置时为 4，可配置为 0 不留静区
+	Foreground string `mapstructure:"foreground"`
+	Background string `mapstructure:"background"`
+}
+
+// OIDCConfig 一个 OpenID Connect 身份提供方
+type OIDCConfig struct {
+	Name                string   `mapstructure:"name"` // 出现在登录地址中：/api/auth/oidc/:name/login
+	Issuer              string   `mapstructure:"issuer"`
+	ClientID            string   `mapstructure:"client_id"`
+	ClientSecret        string   `mapstructure:"client_secret"`
+	RedirectURL         string   `mapstructure:"redirect_url"`
+	Scopes              []string `mapstructure:"scopes"`                // 默认 openid email profile
+	AllowedEmailDomains []string `mapstructure:"allowed_email_domains"` // 为空时不限制
+}
+
+// RateLimitConfig 按路由组限流，groups 的键为路由组名：
+// redirect（短链接跳转）、create_url（创建短链接）、login（登录）、email_code（发送邮箱验证码），
+// 未配置的路由组不限流。bulk_rows 不是路由组：批量创建按行数计入该额度，始终按用户区分
+type RateLimitConfig struct {
+	Enabled bool                     `mapstructure:"enabled"`
+	Groups  map[string]RateLimitRule `mapstructure:"groups"`
+}
+
+type RateLimitRule struct {
+	Algorithm string        `mapstructure:"algorithm"` // token_bucket（默认）或 sliding_window
+	Limit     int           `mapstructure:"limit"`     // window 内允许的请求数，令牌桶即桶容量
+	Window    time.Duration `mapstructure:"window"`
+	Key       string        `mapstructure:"key"` // ip（默认）、user、api_key，取不到时依次退回 user、ip
+}
+
+// LockoutConfig 登录和邮箱验证码的防暴力破解，未配置的项使用默认值
+type LockoutConfig struct {
+	FreeAttempts    int           `mapstructure:"free_attempts"`     // 账号连续失败这么多次之内不限制
+	Delay           time.Duration `mapstructure:"delay"`             // 超出后每次失败需等待的时间，逐次翻倍
+	MaxDelay        time.Duration `mapstructure:"max_delay"`         // 等待时间上限
+	MaxFailures     int           `mapstructure:"max_failures"`      // 账号连续失败达到后锁定并邮件通知
+	Duration        time.Duration `mapstructure:"duration"`          // 锁定时长，也是失败计数的保留时间
+	IPMaxFailures   int           `mapstructure:"ip_max_failures"`   // 同一 IP 失败（含验证码）达到后锁定该 IP
+	CodeMaxFailures int           `mapstructure:"code_max_failures"` // 邮箱验证码输错这么多次后作废
+}
+
+// ReminderConfig 短链接即将过期时提醒创建者，未配置的项使用默认值
+type ReminderConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Interval time.Duration `mapstructure:"interval"`  // 检查的间隔，默认 1h
+	Window   time.Duration `mapstructure:"window"`    // 提醒在这段时间内过期的短链接，默认 72h
+	ExtendBy time.Duration `mapstructure:"extend_by"` // 一键延期延长的时间，默认使用 app.default_duration
+}
+
+// WebhookConfig 短链接事件的 webhook 推送，未配置的项使用默认值
+type WebhookConfig struct {
+	PollInterval    time.Duration `mapstructure:"poll_interval"`     // 默认 10s
+	MaxAttempts     int           `mapstructure:"max_attempts"`      // 默认 8，之后放弃，可手动重放
+	RetryDelay      time.Duration `mapstructure:"retry_delay"`       // 默认 30s
+	Timeout         time.Duration `mapstructure:"timeout"`           // 单次请求的超时时间，默认 10s
+	ClickSampleRate float64       `mapstructure:"click_sample_rate"` // link.clicked 的采样比例，0 不推送，1 全部推送
 }
@@ -0,0 +1,4 @@
+ALTER TABLE urls
+    DROP COLUMN title,
+    DROP COLUMN description,
+    DROP COLUMN interstitial;
@@ -0,0 +1,4 @@
+ALTER TABLE urls
+    ADD COLUMN title VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN description TEXT,
+    ADD COLUMN interstitial BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,5 @@
+ALTER TABLE urls DROP INDEX idx_domain_short_code;
+ALTER TABLE urls ADD UNIQUE INDEX short_code (short_code);
+ALTER TABLE urls DROP COLUMN domain_id;
+
+DROP TABLE IF EXISTS domains;
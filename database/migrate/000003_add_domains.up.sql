@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS domains (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    host VARCHAR(255) NOT NULL UNIQUE,
+    verify_token VARCHAR(64) NOT NULL,
+    verified BOOLEAN NOT NULL DEFAULT FALSE,
+    verified_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_domains_user_id (user_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- domain_id = 0 表示默认域名，因此不加外键
+ALTER TABLE urls ADD COLUMN domain_id BIGINT NOT NULL DEFAULT 0 AFTER user_id;
+ALTER TABLE urls DROP INDEX short_code;
+ALTER TABLE urls ADD UNIQUE INDEX idx_domain_short_code (domain_id, short_code);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS landing_pages (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    domain_id BIGINT NOT NULL DEFAULT 0,
+    kind VARCHAR(20) NOT NULL,
+    template TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_user_domain_kind (user_id, domain_id, kind),
+    INDEX idx_domain_kind (domain_id, kind),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
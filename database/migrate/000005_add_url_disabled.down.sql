@@ -0,0 +1,4 @@
+ALTER TABLE urls
+    DROP COLUMN disabled,
+    DROP COLUMN disabled_reason,
+    DROP COLUMN disabled_at;
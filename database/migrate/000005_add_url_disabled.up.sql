@@ -0,0 +1,4 @@
+ALTER TABLE urls
+    ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN disabled_reason VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN disabled_at TIMESTAMP NULL DEFAULT NULL;
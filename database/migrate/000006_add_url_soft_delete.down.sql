@@ -0,0 +1,2 @@
+DROP INDEX idx_urls_deleted_at ON urls;
+ALTER TABLE urls DROP COLUMN deleted_at;
@@ -0,0 +1,2 @@
+ALTER TABLE urls ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL;
+CREATE INDEX idx_urls_deleted_at ON urls(deleted_at);
@@ -0,0 +1,6 @@
+ALTER TABLE users
+    ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'user' AFTER totp_enabled,
+    ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT FALSE AFTER role;
+
+-- 首个管理员需要手动指定：
+-- UPDATE users SET role = 'admin' WHERE email = 'admin@example.com';
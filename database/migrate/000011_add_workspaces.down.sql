@@ -0,0 +1,5 @@
+DROP INDEX idx_urls_workspace_id ON urls;
+ALTER TABLE urls DROP COLUMN workspace_id;
+DROP TABLE IF EXISTS workspace_invites;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS workspaces (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    personal_user_id BIGINT NULL DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_personal_user_id (personal_user_id),
+    FOREIGN KEY (personal_user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    role VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (workspace_id, user_id),
+    INDEX idx_workspace_members_user_id (user_id),
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS workspace_invites (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id BIGINT NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(16) NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    invited_by BIGINT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    accepted_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_token_hash (token_hash),
+    INDEX idx_workspace_invites_workspace_id (workspace_id),
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE
+);
+
+-- 短链接归属工作区，user_id 保留为创建者
+ALTER TABLE urls ADD COLUMN workspace_id BIGINT NOT NULL DEFAULT 0 AFTER user_id;
+CREATE INDEX idx_urls_workspace_id ON urls(workspace_id);
+
+-- 已有用户各建一个个人工作区，原有短链接归入其中
+INSERT INTO workspaces (name, personal_user_id) SELECT 'Personal', id FROM users;
+INSERT INTO workspace_members (workspace_id, user_id, role)
+    SELECT id, personal_user_id, 'owner' FROM workspaces WHERE personal_user_id IS NOT NULL;
+UPDATE urls JOIN workspaces ON workspaces.personal_user_id = urls.user_id
+    SET urls.workspace_id = workspaces.id;
@@ -0,0 +1,5 @@
+ALTER TABLE email_outbox
+    DROP COLUMN html;
+
+ALTER TABLE users
+    DROP COLUMN locale;
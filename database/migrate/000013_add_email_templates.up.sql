@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN locale VARCHAR(35) NOT NULL DEFAULT '' AFTER disabled;
+
+ALTER TABLE email_outbox
+    ADD COLUMN html TEXT NOT NULL AFTER body;
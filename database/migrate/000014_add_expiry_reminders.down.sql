@@ -0,0 +1,7 @@
+ALTER TABLE urls
+    DROP INDEX idx_urls_reminder,
+    DROP COLUMN reminded_at;
+
+ALTER TABLE users
+    DROP COLUMN reminder_webhook_url,
+    DROP COLUMN expiry_reminders;
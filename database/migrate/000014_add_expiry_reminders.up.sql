@@ -0,0 +1,7 @@
+ALTER TABLE users
+    ADD COLUMN expiry_reminders BOOLEAN NOT NULL DEFAULT TRUE AFTER locale,
+    ADD COLUMN reminder_webhook_url VARCHAR(2048) NOT NULL DEFAULT '' AFTER expiry_reminders;
+
+ALTER TABLE urls
+    ADD COLUMN reminded_at TIMESTAMP NULL DEFAULT NULL AFTER expired_at,
+    ADD INDEX idx_urls_reminder (reminded_at, expired_at);
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS bulk_jobs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    workspace_id BIGINT NOT NULL DEFAULT 0,
+    domain VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    total INT NOT NULL DEFAULT 0,
+    processed INT NOT NULL DEFAULT 0,
+    succeeded INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    finished_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_bulk_jobs_user_id (user_id),
+    INDEX idx_bulk_jobs_status (status),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS bulk_job_rows (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    job_id BIGINT NOT NULL,
+    row_num INT NOT NULL,
+    original_url TEXT NOT NULL,
+    custom_code VARCHAR(100) NOT NULL DEFAULT '',
+    duration INT NULL DEFAULT NULL,
+    short_url VARCHAR(512) NOT NULL DEFAULT '',
+    error VARCHAR(255) NOT NULL DEFAULT '',
+    UNIQUE INDEX idx_bulk_job_rows_job_row (job_id, row_num),
+    FOREIGN KEY (job_id) REFERENCES bulk_jobs(id) ON DELETE CASCADE
+);
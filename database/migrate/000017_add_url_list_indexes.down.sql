@@ -0,0 +1,3 @@
+DROP INDEX idx_urls_workspace_views ON urls;
+DROP INDEX idx_urls_workspace_expired ON urls;
+DROP INDEX idx_urls_workspace_created ON urls;
@@ -0,0 +1,4 @@
+-- 短链接列表按这些列排序、keyset 分页
+CREATE INDEX idx_urls_workspace_created ON urls(workspace_id, created_at);
+CREATE INDEX idx_urls_workspace_expired ON urls(workspace_id, expired_at);
+CREATE INDEX idx_urls_workspace_views ON urls(workspace_id, views);
@@ -0,0 +1,8 @@
+ALTER TABLE urls
+    DROP INDEX idx_urls_workspace_folder,
+    DROP COLUMN notes,
+    DROP COLUMN folder_id;
+
+DROP TABLE IF EXISTS folders;
+DROP TABLE IF EXISTS url_tags;
+DROP TABLE IF EXISTS tags;
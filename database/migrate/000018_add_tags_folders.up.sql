@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS tags (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id BIGINT NOT NULL,
+    name VARCHAR(32) NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_tags_workspace_name (workspace_id, name),
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS url_tags (
+    url_id BIGINT NOT NULL,
+    tag_id BIGINT NOT NULL,
+    PRIMARY KEY (url_id, tag_id),
+    INDEX idx_url_tags_tag_id (tag_id),
+    FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+-- parent_id 为 0 表示位于工作区根目录
+CREATE TABLE IF NOT EXISTS folders (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id BIGINT NOT NULL,
+    parent_id BIGINT NOT NULL DEFAULT 0,
+    name VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_folders_parent_name (workspace_id, parent_id, name),
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE
+);
+
+ALTER TABLE urls
+    ADD COLUMN folder_id BIGINT NOT NULL DEFAULT 0 AFTER domain_id,
+    ADD COLUMN notes TEXT NULL AFTER description,
+    ADD INDEX idx_urls_workspace_folder (workspace_id, folder_id);
@@ -0,0 +1,4 @@
+-- 已配置的地址不在 SQL 中生成密钥（RAND()、UUID() 可被猜测），
+-- 密钥为空时不推送，用户重新保存提醒设置时用 crypto/rand 生成
+ALTER TABLE users
+    ADD COLUMN reminder_webhook_secret VARCHAR(64) NOT NULL DEFAULT '' AFTER reminder_webhook_url;
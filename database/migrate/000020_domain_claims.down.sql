@@ -0,0 +1,13 @@
+ALTER TABLE domains
+    DROP INDEX idx_domains_verified_host,
+    DROP COLUMN verified_host;
+
+-- 每个域名只保留已验证的或最早的申请
+DELETE d FROM domains d
+JOIN domains o ON o.host = d.host AND o.id <> d.id
+WHERE NOT d.verified AND (o.verified OR o.id < d.id);
+
+ALTER TABLE domains
+    DROP INDEX idx_domains_host,
+    DROP INDEX idx_domains_user_host,
+    ADD UNIQUE INDEX host (host);
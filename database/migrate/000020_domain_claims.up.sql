@@ -0,0 +1,10 @@
+-- 同一域名可以被多个用户申请，只有验证通过的那个生效
+ALTER TABLE domains
+    DROP INDEX host,
+    ADD UNIQUE INDEX idx_domains_user_host (user_id, host),
+    ADD INDEX idx_domains_host (host);
+
+-- 未验证时为 NULL，不参与唯一约束
+ALTER TABLE domains
+    ADD COLUMN verified_host VARCHAR(255) AS (IF(verified, host, NULL)) STORED,
+    ADD UNIQUE INDEX idx_domains_verified_host (verified_host);
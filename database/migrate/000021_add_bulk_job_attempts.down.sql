@@ -0,0 +1,4 @@
+UPDATE bulk_jobs SET status = 'done' WHERE status = 'failed';
+
+ALTER TABLE bulk_jobs
+    DROP COLUMN attempts;
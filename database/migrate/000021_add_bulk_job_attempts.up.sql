@@ -0,0 +1,3 @@
+-- 同一批连续保存失败的次数，达到上限后这一批按失败处理
+ALTER TABLE bulk_jobs
+    ADD COLUMN attempts INT NOT NULL DEFAULT 0 AFTER failed;
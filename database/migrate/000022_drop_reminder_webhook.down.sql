@@ -0,0 +1,3 @@
+ALTER TABLE users
+    ADD COLUMN reminder_webhook_url VARCHAR(2048) NOT NULL DEFAULT '' AFTER expiry_reminders,
+    ADD COLUMN reminder_webhook_secret VARCHAR(64) NOT NULL DEFAULT '' AFTER reminder_webhook_url;
@@ -0,0 +1,4 @@
+-- 过期提醒的 webhook 改为订阅 link.expiring 事件，经 webhook 投递队列推送
+ALTER TABLE users
+    DROP COLUMN reminder_webhook_secret,
+    DROP COLUMN reminder_webhook_url;
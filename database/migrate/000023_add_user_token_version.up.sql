@@ -0,0 +1,4 @@
+-- token 版本以数据库为准，Redis 只做缓存：清空 Redis 后已吊销的 token 不会重新生效。
+-- 之前只存在 Redis 中的版本号不迁移，版本号大于 0 的用户需要重新登录
+ALTER TABLE users
+    ADD COLUMN token_version BIGINT NOT NULL DEFAULT 0 AFTER disabled;
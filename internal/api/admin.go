@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/service"
+)
+
+type AdminServicer interface {
+	SearchUsers(ctx context.Context, req dto.AdminSearchRequest) (*dto.AdminUsersResponse, error)
+	SetUserRole(ctx context.Context, req dto.SetUserRoleRequest) error
+	SetUserDisabled(ctx context.Context, req dto.SetUserDisabledRequest) error
+	SearchURLs(ctx context.Context, req dto.AdminSearchRequest) (*dto.AdminURLsResponse, error)
+	ForceExpireURL(ctx context.Context, req dto.AdminURLRequest) error
+	TransferURL(ctx context.Context, req dto.TransferURLRequest) error
+}
+
+// AdminHandler 处理 /api/admin 下的管理请求，权限由路由上的 RequireRole 控制
+type AdminHandler struct {
+	adminService AdminServicer
+}
+
+func NewAdminHandler(adminService AdminServicer) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+	}
+}
+
+// GET /api/admin/users?q=&page=&size=
+func (h *AdminHandler) SearchUsers(c *gin.Context) {
+	req, ok := adminSearchRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.adminService.SearchUsers(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// PUT /api/admin/users/:id/role role
+func (h *AdminHandler) SetUserRole(c *gin.Context) {
+	actorID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req dto.SetUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ID = id
+	req.ActorID = actorID
+
+	if err := h.adminService.SetUserRole(c.Request.Context(), req); err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// POST /api/admin/users/:id/disable
+func (h *AdminHandler) DisableUser(c *gin.Context) {
+	h.setUserDisabled(c, true)
+}
+
+// POST /api/admin/users/:id/enable
+func (h *AdminHandler) EnableUser(c *gin.Context) {
+	h.setUserDisabled(c, false)
+}
+
+func (h *AdminHandler) setUserDisabled(c *gin.Context, disabled bool) {
+	actorID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	req := dto.SetUserDisabledRequest{
+		Disabled: disabled,
+		ID:       id,
+		ActorID:  actorID,
+	}
+
+	if err := h.adminService.SetUserDisabled(c.Request.Context(), req); err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GET /api/admin/urls?q=&user_id=&page=&size=
+func (h *AdminHandler) SearchURLs(c *gin.Context) {
+	req, ok := adminSearchRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.adminService.SearchURLs(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// POST /api/admin/urls/:code/expire?domain=
+func (h *AdminHandler) ForceExpireURL(c *gin.Context) {
+	req := dto.AdminURLRequest{
+		Code:   c.Param("code"),
+		Domain: c.Query("domain"),
+	}
+
+	if err := h.adminService.ForceExpireURL(c.Request.Context(), req); err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// POST /api/admin/urls/:code/transfer?domain= user_id
+func (h *AdminHandler) TransferURL(c *gin.Context) {
+	var req dto.TransferURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Code = c.Param("code")
+	req.Domain = c.Query("domain")
+
+	if err := h.adminService.TransferURL(c.Request.Context(), req); err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func adminSearchRequestFrom(c *gin.Context) (dto.AdminSearchRequest, bool) {
+	var req dto.AdminSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return req, false
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return req, false
+	}
+	return req, true
+}
+
+func adminErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrURLNotFound), errors.Is(err, service.ErrDomainNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrModifySelf):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrURLExpired):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+var _ AdminServicer = (*service.AdminService)(nil)
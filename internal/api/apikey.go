@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/service"
+)
+
+type APIKeyServicer interface {
+	CreateAPIKey(ctx context.Context, req dto.CreateAPIKeyRequest) (*dto.CreateAPIKeyResponse, error)
+	GetAPIKeys(ctx context.Context, userID int) ([]dto.APIKeyResponse, error)
+	UpdateAPIKey(ctx context.Context, req dto.UpdateAPIKeyRequest) error
+	RevokeAPIKey(ctx context.Context, req dto.APIKeyRequest) error
+}
+
+// APIKeyHandler 处理个人 API Key 相关的HTTP请求
+type APIKeyHandler struct {
+	apiKeyService APIKeyServicer
+}
+
+func NewAPIKeyHandler(apiKeyService APIKeyServicer) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// POST /api/keys name, scopes, [expires_in_days] -> 完整 key（仅此一次）
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	var req dto.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserID = userID
+
+	resp, err := h.apiKeyService.CreateAPIKey(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GET /api/keys
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	resp, err := h.apiKeyService.GetAPIKeys(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": resp})
+}
+
+// PATCH /api/keys/:id name
+func (h *APIKeyHandler) UpdateAPIKey(c *gin.Context) {
+	keyReq, ok := apiKeyRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ID = keyReq.ID
+	req.UserID = keyReq.UserID
+
+	if err := h.apiKeyService.UpdateAPIKey(c.Request.Context(), req); err != nil {
+		c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DELETE /api/keys/:id 吊销，记录保留以便查看
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	req, ok := apiKeyRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(c.Request.Context(), req); err != nil {
+		c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func apiKeyRequestFrom(c *gin.Context) (dto.APIKeyRequest, bool) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return dto.APIKeyRequest{}, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid api key id"})
+		return dto.APIKeyRequest{}, false
+	}
+	return dto.APIKeyRequest{ID: id, UserID: userID}, true
+}
+
+func apiKeyErrorStatus(err error) int {
+	if errors.Is(err, service.ErrAPIKeyNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+var _ APIKeyServicer = (*service.APIKeyService)(nil)
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/service"
+)
+
+// 上传文件或请求体的大小上限
+const maxBulkUploadSize = 20 << 20
+
+type BulkServicer interface {
+	CreateURLs(ctx context.Context, req dto.BulkCreateURLRequest) (*dto.BulkCreateURLResponse, error)
+	CreateJob(ctx context.Context, req dto.BulkCreateURLRequest) (*dto.BulkJob, error)
+	GetJob(ctx context.Context, req dto.GetBulkJobRequest) (*dto.GetBulkJobResponse, error)
+}
+
+// BulkHandler 批量创建短链接，支持 JSON 数组和 CSV 文件
+type BulkHandler struct {
+	bulkService BulkServicer
+}
+
+func NewBulkHandler(bulkService BulkServicer) *BulkHandler {
+	return &BulkHandler{
+		bulkService: bulkService,
+	}
+}
+
+// POST /api/urls/bulk?workspace_id=&domain=&async=
+// 请求体为 JSON 数组或 CSV（text/csv），也可以用 multipart 的 file 字段上传 .json 或 .csv 文件。
+// 同步模式返回每行的结果，async=true 时返回任务，通过 GET /api/urls/bulk/:id 查询进度和结果
+func (h *BulkHandler) CreateURLs(c *gin.Context) {
+	var req dto.BulkCreateURLRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+	req.UserID = userID
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBulkUploadSize)
+	items, err := bulkItemsFrom(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Items = items
+
+	if req.Async {
+		job, err := h.bulkService.CreateJob(c.Request.Context(), req)
+		if err != nil {
+			if abortRetryAfter(c, err) {
+				return
+			}
+			c.JSON(bulkErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusAccepted, job)
+		return
+	}
+	resp, err := h.bulkService.CreateURLs(c.Request.Context(), req)
+	if err != nil {
+		if abortRetryAfter(c, err) {
+			return
+		}
+		c.JSON(bulkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GET /api/urls/bulk/:id?failed_only=&page=&size= 任务进度和每行的结果
+func (h *BulkHandler) GetJob(c *gin.Context) {
+	var req dto.GetBulkJobRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid job id"})
+		return
+	}
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+	req.ID = id
+	req.UserID = userID
+
+	resp, err := h.bulkService.GetJob(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(bulkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// bulkItemsFrom 按 Content-Type 解析请求体，multipart 上传时按文件扩展名判断格式
+func bulkItemsFrom(c *gin.Context) ([]dto.BulkURLItem, error) {
+	switch c.ContentType() {
+	case "multipart/form-data":
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		if strings.EqualFold(filepath.Ext(header.Filename), ".json") {
+			return parseBulkJSON(file)
+		}
+		return parseBulkCSV(file)
+	case "text/csv":
+		return parseBulkCSV(c.Request.Body)
+	default:
+		return parseBulkJSON(c.Request.Body)
+	}
+}
+
+func parseBulkJSON(r io.Reader) ([]dto.BulkURLItem, error) {
+	var items []dto.BulkURLItem
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, fmt.Errorf("invalid json array: %w", err)
+	}
+	return items, nil
+}
+
+// parseBulkCSV 列依次为 original_url、custom_code、duration，后两列可省略；
+// 第一行包含 original_url 时视为表头，按列名取值
+func parseBulkCSV(r io.Reader) ([]dto.BulkURLItem, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv: %w", err)
+	}
+	columns := map[string]int{"original_url": 0, "custom_code": 1, "duration": 2}
+	line := 1
+	if len(records) > 0 && isBulkCSVHeader(records[0]) {
+		columns = map[string]int{}
+		for i, name := range records[0] {
+			columns[normalizeBulkColumn(name)] = i
+		}
+		records = records[1:]
+		line++
+	}
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	items := make([]dto.BulkURLItem, len(records))
+	for i, record := range records {
+		items[i] = dto.BulkURLItem{
+			OriginalURL: field(record, "original_url"),
+			CustomCode:  field(record, "custom_code"),
+		}
+		if s := field(record, "duration"); s != "" {
+			duration, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid duration %q", line+i, s)
+			}
+			items[i].Duration = &duration
+		}
+	}
+	return items, nil
+}
+
+func isBulkCSVHeader(record []string) bool {
+	for _, name := range record {
+		if normalizeBulkColumn(name) == "original_url" {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeBulkColumn 忽略大小写、空白和 Excel 导出时带的 BOM
+func normalizeBulkColumn(name string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+}
+
+func bulkErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrBulkEmpty), errors.Is(err, service.ErrDomainNotFound):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrBulkTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrBulkJobNotFound), errors.Is(err, service.ErrWorkspaceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrWorkspaceForbidden):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+var _ BulkServicer = (*service.BulkService)(nil)
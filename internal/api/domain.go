@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/service"
+)
+
+type DomainServicer interface {
+	AddDomain(ctx context.Context, req dto.AddDomainRequest) (*dto.DomainResponse, error)
+	GetDomains(ctx context.Context, userID int) ([]dto.DomainResponse, error)
+	VerifyDomain(ctx context.Context, req dto.DomainRequest) (*dto.DomainResponse, error)
+	DeleteDomain(ctx context.Context, req dto.DomainRequest) error
+}
+
+// DomainHandler 处理自定义域名相关的HTTP请求
+type DomainHandler struct {
+	domainService DomainServicer
+}
+
+func NewDomainHandler(domainService DomainServicer) *DomainHandler {
+	return &DomainHandler{
+		domainService: domainService,
+	}
+}
+
+// POST /api/domains host -> 待添加的 TXT 记录
+func (h *DomainHandler) AddDomain(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	var req dto.AddDomainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserID = userID
+
+	resp, err := h.domainService.AddDomain(c.Request.Context(), req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrDomainTaken) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GET /api/domains
+func (h *DomainHandler) GetDomains(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	resp, err := h.domainService.GetDomains(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": resp})
+}
+
+// POST /api/domains/:id/verify 检查 DNS TXT 记录
+func (h *DomainHandler) VerifyDomain(c *gin.Context) {
+	req, ok := domainRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.domainService.VerifyDomain(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(domainErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DELETE /api/domains/:id
+func (h *DomainHandler) DeleteDomain(c *gin.Context) {
+	req, ok := domainRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	if err := h.domainService.DeleteDomain(c.Request.Context(), req); err != nil {
+		c.JSON(domainErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func domainRequestFrom(c *gin.Context) (dto.DomainRequest, bool) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return dto.DomainRequest{}, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid domain id"})
+		return dto.DomainRequest{}, false
+	}
+	return dto.DomainRequest{ID: id, UserID: userID}, true
+}
+
+func domainErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrDomainNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrDomainVerifyFailed):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrDomainInUse):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+var _ DomainServicer = (*service.DomainService)(nil)
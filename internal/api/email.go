@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/service"
+)
+
+type EmailServicer interface {
+	GetEmails(ctx context.Context, req dto.GetEmailsRequest) (*dto.GetEmailsResponse, error)
+	RetryEmail(ctx context.Context, id uint64) error
+}
+
+// EmailHandler 管理员查看待发送和投递失败的邮件
+type EmailHandler struct {
+	emailService EmailServicer
+}
+
+func NewEmailHandler(emailService EmailServicer) *EmailHandler {
+	return &EmailHandler{
+		emailService: emailService,
+	}
+}
+
+// GET /api/admin/emails?status=&page=&size=
+func (h *EmailHandler) GetEmails(c *gin.Context) {
+	var req dto.GetEmailsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.emailService.GetEmails(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// POST /api/admin/emails/:id/retry 重新投递已放弃的邮件
+func (h *EmailHandler) RetryEmail(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid email id"})
+		return
+	}
+
+	if err := h.emailService.RetryEmail(c.Request.Context(), id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrEmailNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+var _ EmailServicer = (*service.EmailOutbox)(nil)
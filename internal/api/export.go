@@ -0,0 +1,182 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/pkg/xlsx"
+)
+
+// 导出文件的列，CSV 和 XLSX 的表头
+var exportColumns = []string{
+	"id", "short_url", "short_code", "original_url", "title", "workspace_id",
+	"is_custom", "views", "disabled", "disabled_reason", "created_at", "expired_at",
+}
+
+// GET /api/urls/export?format=csv|jsonl|xlsx&workspace_id=
+// 边查询边写入响应，导出中途出错时只能中断连接
+func (h *URLHandler) ExportURLs(c *gin.Context) {
+	var req dto.ExportURLsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+	req.UserID = userID
+	if req.Format == "" {
+		req.Format = "csv"
+	}
+
+	// 写入第一行时才发送响应头，之前出错仍可返回 JSON 错误
+	var exporter urlExporter
+	start := func() error {
+		filename := "urls-" + time.Now().Format("20060102") + "." + req.Format
+		c.Header("Content-Type", exportContentTypes[req.Format])
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+		c.Status(http.StatusOK)
+		var err error
+		exporter, err = newURLExporter(req.Format, c.Writer)
+		return err
+	}
+	err := h.urlService.ExportURLs(c.Request.Context(), req, func(u dto.ExportedURL) error {
+		if exporter == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return exporter.Write(u)
+	})
+	if err != nil && exporter == nil {
+		c.JSON(urlErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("export urls for user %d aborted: %v", userID, err)
+		c.Abort()
+		return
+	}
+	// 没有短链接时也返回只有表头的文件
+	if exporter == nil {
+		if err := start(); err != nil {
+			log.Printf("export urls for user %d aborted: %v", userID, err)
+			return
+		}
+	}
+	if err := exporter.Close(); err != nil {
+		log.Printf("export urls for user %d aborted: %v", userID, err)
+	}
+}
+
+var exportContentTypes = map[string]string{
+	"csv":   "text/csv; charset=utf-8",
+	"jsonl": "application/x-ndjson",
+	"xlsx":  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// urlExporter 把导出的短链接逐行编码写入响应
+type urlExporter interface {
+	Write(u dto.ExportedURL) error
+	Close() error
+}
+
+func newURLExporter(format string, w io.Writer) (urlExporter, error) {
+	switch format {
+	case "jsonl":
+		return &jsonlExporter{enc: json.NewEncoder(w)}, nil
+	case "xlsx":
+		xw, err := xlsx.NewWriter(w, "urls")
+		if err != nil {
+			return nil, err
+		}
+		header := make([]any, len(exportColumns))
+		for i, col := range exportColumns {
+			header[i] = col
+		}
+		if err := xw.WriteRow(header...); err != nil {
+			return nil, err
+		}
+		return &xlsxExporter{w: xw}, nil
+	default:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(exportColumns); err != nil {
+			return nil, err
+		}
+		return &csvExporter{w: cw}, nil
+	}
+}
+
+type csvExporter struct {
+	w *csv.Writer
+}
+
+func (e *csvExporter) Write(u dto.ExportedURL) error {
+	return e.w.Write([]string{
+		strconv.FormatUint(u.ID, 10),
+		u.ShortURL,
+		u.ShortCode,
+		csvSafe(u.OriginalURL),
+		csvSafe(u.Title),
+		strconv.FormatUint(u.WorkspaceID, 10),
+		strconv.FormatBool(u.IsCustom),
+		strconv.FormatInt(int64(u.Views), 10),
+		strconv.FormatBool(u.Disabled),
+		csvSafe(u.DisabledReason),
+		u.CreatedAt.Format(time.RFC3339),
+		u.ExpiredAt.Format(time.RFC3339),
+	})
+}
+
+func (e *csvExporter) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// csvSafe 用户填写的内容以公式字符开头时加上单引号，避免在表格软件中被当作公式执行
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+type jsonlExporter struct {
+	enc *json.Encoder
+}
+
+func (e *jsonlExporter) Write(u dto.ExportedURL) error {
+	return e.enc.Encode(u)
+}
+
+func (e *jsonlExporter) Close() error {
+	return nil
+}
+
+type xlsxExporter struct {
+	w *xlsx.Writer
+}
+
+func (e *xlsxExporter) Write(u dto.ExportedURL) error {
+	return e.w.WriteRow(u.ID, u.ShortURL, u.ShortCode, u.OriginalURL, u.Title, u.WorkspaceID,
+		u.IsCustom, u.Views, u.Disabled, u.DisabledReason, u.CreatedAt, u.ExpiredAt)
+}
+
+func (e *xlsxExporter) Close() error {
+	return e.w.Close()
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/service"
+)
+
+type FolderServicer interface {
+	CreateFolder(ctx context.Context, req dto.CreateFolderRequest) (*dto.FolderResponse, error)
+	GetFolders(ctx context.Context, req dto.GetFoldersRequest) ([]dto.FolderResponse, error)
+	UpdateFolder(ctx context.Context, req dto.UpdateFolderRequest) error
+	DeleteFolder(ctx context.Context, req dto.FolderRequest) error
+	MoveURLs(ctx context.Context, req dto.MoveURLsRequest) (*dto.MoveURLsResponse, error)
+}
+
+// FolderHandler 管理工作区的文件夹，批量把短链接移入文件夹
+type FolderHandler struct {
+	folderService FolderServicer
+}
+
+func NewFolderHandler(folderService FolderServicer) *FolderHandler {
+	return &FolderHandler{
+		folderService: folderService,
+	}
+}
+
+// POST /api/folders workspace_id, parent_id, name
+func (h *FolderHandler) CreateFolder(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	var req dto.CreateFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserID = userID
+
+	resp, err := h.folderService.CreateFolder(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(folderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GET /api/folders?workspace_id= 平铺列表，按 parent_id 组装成树
+func (h *FolderHandler) GetFolders(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	var req dto.GetFoldersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserID = userID
+
+	resp, err := h.folderService.GetFolders(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(folderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": resp})
+}
+
+// PATCH /api/folders/:id [name], [parent_id] 重命名或移动
+func (h *FolderHandler) UpdateFolder(c *gin.Context) {
+	folderReq, ok := folderRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ID = folderReq.ID
+	req.UserID = folderReq.UserID
+
+	if err := h.folderService.UpdateFolder(c.Request.Context(), req); err != nil {
+		c.JSON(folderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DELETE /api/folders/:id 只能删除空文件夹
+func (h *FolderHandler) DeleteFolder(c *gin.Context) {
+	req, ok := folderRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	if err := h.folderService.DeleteFolder(c.Request.Context(), req); err != nil {
+		c.JSON(folderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// POST /api/urls/move url_ids, folder_id
+func (h *FolderHandler) MoveURLs(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	var req dto.MoveURLsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserID = userID
+
+	resp, err := h.folderService.MoveURLs(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(folderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func folderRequestFrom(c *gin.Context) (dto.FolderRequest, bool) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return dto.FolderRequest{}, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid folder id"})
+		return dto.FolderRequest{}, false
+	}
+	return dto.FolderRequest{ID: id, UserID: userID}, true
+}
+
+func folderErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrFolderNotFound), errors.Is(err, service.ErrURLNotFound),
+		errors.Is(err, service.ErrWorkspaceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrWorkspaceForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrFolderExists), errors.Is(err, service.ErrFolderNotEmpty):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidFolderName), errors.Is(err, service.ErrInvalidFolderParent),
+		errors.Is(err, service.ErrFolderMismatch):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+var _ FolderServicer = (*service.FolderService)(nil)
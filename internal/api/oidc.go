@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/service"
+)
+
+type OIDCServicer interface {
+	GetProviders() []string
+	AuthURL(ctx context.Context, provider string) (*dto.OIDCAuthResponse, error)
+	Callback(ctx context.Context, req dto.OIDCCallbackRequest) (*dto.LoginResponse, error)
+}
+
+// 保存 state 的 cookie，回调时与参数中的 state 核对
+const oidcStateCookie = "oidc_state"
+
+// OIDCHandler 处理企业身份提供方登录
+type OIDCHandler struct {
+	oidcService OIDCServicer
+}
+
+func NewOIDCHandler(oidcService OIDCServicer) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+	}
+}
+
+// GET /api/auth/oidc/providers 可用的身份提供方
+func (h *OIDCHandler) GetProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"items": h.oidcService.GetProviders()})
+}
+
+// GET /api/auth/oidc/:provider/login 跳转到 IdP 登录，state 写入只在回调地址下发送的 cookie。
+// IdP 跳转回来是顶级导航，SameSite=Lax 的 cookie 会被带上
+func (h *OIDCHandler) Login(c *gin.Context) {
+	resp, err := h.oidcService.AuthURL(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		c.JSON(oidcErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, resp.State, int(resp.MaxAge.Seconds()), resp.CallbackPath, "", resp.Secure, true)
+	c.Redirect(http.StatusFound, resp.URL)
+}
+
+// GET /api/auth/oidc/:provider/callback?code=&state= -> access token 和 refresh token，
+// 开启了两步验证时同密码登录返回挑战 token
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	var req dto.OIDCCallbackRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Provider = c.Param("provider")
+	// 没有 cookie 时为空，由 service 拒绝
+	req.CookieState, _ = c.Cookie(oidcStateCookie)
+
+	resp, err := h.oidcService.Callback(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(oidcErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func oidcErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrOIDCProviderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrOIDCStateInvalid), errors.Is(err, service.ErrOIDCLoginFailed):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrOIDCEmailUnverified), errors.Is(err, service.ErrOIDCEmailNotAllowed), errors.Is(err, service.ErrUserDisabled):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+var _ OIDCServicer = (*service.OIDCService)(nil)
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/service"
+)
+
+type LandingPageServicer interface {
+	SetPage(ctx context.Context, req dto.SetLandingPageRequest) (*dto.LandingPageResponse, error)
+	GetPages(ctx context.Context, userID int) ([]dto.LandingPageResponse, error)
+	DeletePage(ctx context.Context, req dto.DeleteLandingPageRequest) error
+}
+
+// PageHandler 管理自定义落地页
+type PageHandler struct {
+	pageService LandingPageServicer
+}
+
+func NewPageHandler(pageService LandingPageServicer) *PageHandler {
+	return &PageHandler{
+		pageService: pageService,
+	}
+}
+
+// PUT /api/pages/:kind domain, template
+func (h *PageHandler) SetPage(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	var req dto.SetLandingPageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Kind = c.Param("kind")
+	req.UserID = userID
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.pageService.SetPage(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(pageErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GET /api/pages
+func (h *PageHandler) GetPages(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	resp, err := h.pageService.GetPages(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": resp})
+}
+
+// DELETE /api/pages/:kind?domain=
+func (h *PageHandler) DeletePage(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	req := dto.DeleteLandingPageRequest{
+		Kind:   c.Param("kind"),
+		Domain: c.Query("domain"),
+		UserID: userID,
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.pageService.DeletePage(c.Request.Context(), req); err != nil {
+		c.JSON(pageErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func pageErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidPageTemplate):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrDomainNotFound), errors.Is(err, service.ErrPageNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+var _ LandingPageServicer = (*service.LandingPageService)(nil)
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/service"
+)
+
+type ReminderServicer interface {
+	GetExtend(ctx context.Context, token string) (*dto.ExtendURLResponse, error)
+	Extend(ctx context.Context, token string) (*dto.ExtendURLResponse, error)
+	GetSettings(ctx context.Context, userID int) (*dto.ReminderSettings, error)
+	UpdateSettings(ctx context.Context, req dto.ReminderSettings) (*dto.ReminderSettings, error)
+}
+
+// ReminderHandler 过期提醒设置，以及提醒邮件中的一键延期页面
+type ReminderHandler struct {
+	reminderService ReminderServicer
+}
+
+func NewReminderHandler(reminderService ReminderServicer) *ReminderHandler {
+	return &ReminderHandler{
+		reminderService: reminderService,
+	}
+}
+
+// GET /api/urls/extend?token= 延期确认页，GET 不改变状态，避免邮件客户端预取时误触发
+func (h *ReminderHandler) ExtendPage(c *gin.Context) {
+	token := c.Query("token")
+	resp, err := h.reminderService.GetExtend(c.Request.Context(), token)
+	if err != nil {
+		h.extendError(c, err)
+		return
+	}
+	c.HTML(http.StatusOK, "extend.html", gin.H{
+		"ShortURL":     resp.ShortURL,
+		"OriginalURL":  resp.OriginalURL,
+		"ExpiredAt":    resp.ExpiredAt,
+		"NewExpiredAt": resp.NewExpiredAt,
+		"Token":        token,
+	})
+}
+
+// POST /api/urls/extend token 确认延期，令牌只能使用一次
+func (h *ReminderHandler) Extend(c *gin.Context) {
+	resp, err := h.reminderService.Extend(c.Request.Context(), c.PostForm("token"))
+	if err != nil {
+		h.extendError(c, err)
+		return
+	}
+	c.HTML(http.StatusOK, "extend.html", gin.H{
+		"Done":         true,
+		"ShortURL":     resp.ShortURL,
+		"OriginalURL":  resp.OriginalURL,
+		"NewExpiredAt": resp.NewExpiredAt,
+	})
+}
+
+func (h *ReminderHandler) extendError(c *gin.Context, err error) {
+	status := reminderErrorStatus(err)
+	msg := err.Error()
+	if status == http.StatusInternalServerError {
+		msg = "something went wrong, please try again later"
+	}
+	c.HTML(status, "extend.html", gin.H{"Error": msg})
+}
+
+// GET /api/me/reminders
+func (h *ReminderHandler) GetSettings(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	resp, err := h.reminderService.GetSettings(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(reminderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// PUT /api/me/reminders expiry_reminders
+func (h *ReminderHandler) UpdateSettings(c *gin.Context) {
+	var req dto.ReminderSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+	req.UserID = userID
+
+	resp, err := h.reminderService.UpdateSettings(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(reminderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func reminderErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrExtendLinkInvalid):
+		return http.StatusGone
+	case errors.Is(err, service.ErrURLNotFound), errors.Is(err, service.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrWorkspaceForbidden):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+var _ ReminderServicer = (*service.ReminderService)(nil)
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/service"
+)
+
+type TagServicer interface {
+	CreateTag(ctx context.Context, req dto.CreateTagRequest) (*dto.TagResponse, error)
+	GetTags(ctx context.Context, req dto.GetTagsRequest) ([]dto.TagResponse, error)
+	UpdateTag(ctx context.Context, req dto.UpdateTagRequest) error
+	DeleteTag(ctx context.Context, req dto.TagRequest) error
+	TagURLs(ctx context.Context, req dto.TagURLsRequest) (*dto.TagURLsResponse, error)
+}
+
+// TagHandler 管理工作区的标签，批量给短链接打标签
+type TagHandler struct {
+	tagService TagServicer
+}
+
+func NewTagHandler(tagService TagServicer) *TagHandler {
+	return &TagHandler{
+		tagService: tagService,
+	}
+}
+
+// POST /api/tags workspace_id, name, color
+func (h *TagHandler) CreateTag(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	var req dto.CreateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserID = userID
+
+	resp, err := h.tagService.CreateTag(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GET /api/tags?workspace_id=
+func (h *TagHandler) GetTags(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	var req dto.GetTagsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserID = userID
+
+	resp, err := h.tagService.GetTags(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": resp})
+}
+
+// PATCH /api/tags/:id [name], [color]
+func (h *TagHandler) UpdateTag(c *gin.Context) {
+	tagReq, ok := tagRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ID = tagReq.ID
+	req.UserID = tagReq.UserID
+
+	if err := h.tagService.UpdateTag(c.Request.Context(), req); err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DELETE /api/tags/:id 同时从所有短链接上移除
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	req, ok := tagRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	if err := h.tagService.DeleteTag(c.Request.Context(), req); err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// POST /api/urls/tags url_ids, add, remove
+func (h *TagHandler) TagURLs(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	var req dto.TagURLsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserID = userID
+
+	resp, err := h.tagService.TagURLs(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func tagRequestFrom(c *gin.Context) (dto.TagRequest, bool) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return dto.TagRequest{}, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tag id"})
+		return dto.TagRequest{}, false
+	}
+	return dto.TagRequest{ID: id, UserID: userID}, true
+}
+
+func tagErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrTagNotFound), errors.Is(err, service.ErrURLNotFound),
+		errors.Is(err, service.ErrWorkspaceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrWorkspaceForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrTagExists):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidTagName):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+var _ TagServicer = (*service.TagService)(nil)
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/service"
+)
+
+type TOTPServicer interface {
+	Enroll(ctx context.Context, userID int) (*dto.TOTPEnrollResponse, error)
+	Activate(ctx context.Context, req dto.TOTPCodeRequest) (*dto.RecoveryCodesResponse, error)
+	Disable(ctx context.Context, req dto.TOTPCodeRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, req dto.TOTPCodeRequest) (*dto.RecoveryCodesResponse, error)
+	LoginTwoFactor(ctx context.Context, req dto.LoginTwoFactorRequest) (*dto.LoginResponse, error)
+}
+
+// TOTPHandler 处理两步验证相关的HTTP请求
+type TOTPHandler struct {
+	totpService TOTPServicer
+}
+
+func NewTOTPHandler(totpService TOTPServicer) *TOTPHandler {
+	return &TOTPHandler{
+		totpService: totpService,
+	}
+}
+
+// POST /api/auth/2fa/enroll -> 密钥、otpauth:// 地址和二维码
+func (h *TOTPHandler) Enroll(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	resp, err := h.totpService.Enroll(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(totpErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// POST /api/auth/2fa/activate code -> 恢复码
+func (h *TOTPHandler) Activate(c *gin.Context) {
+	req, ok := totpCodeRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.totpService.Activate(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(totpErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// POST /api/auth/2fa/disable code（验证码或恢复码）
+func (h *TOTPHandler) Disable(c *gin.Context) {
+	req, ok := totpCodeRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	if err := h.totpService.Disable(c.Request.Context(), req); err != nil {
+		c.JSON(totpErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// POST /api/auth/2fa/recovery-codes code -> 新的恢复码
+func (h *TOTPHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	req, ok := totpCodeRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.totpService.RegenerateRecoveryCodes(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(totpErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// POST /api/auth/login/2fa challenge_token, code -> access token 和 refresh token
+func (h *TOTPHandler) LoginTwoFactor(c *gin.Context) {
+	var req dto.LoginTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.IP = c.ClientIP()
+
+	resp, err := h.totpService.LoginTwoFactor(c.Request.Context(), req)
+	if err != nil {
+		if abortRetryAfter(c, err) {
+			return
+		}
+		c.JSON(totpErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func totpCodeRequestFrom(c *gin.Context) (dto.TOTPCodeRequest, bool) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return dto.TOTPCodeRequest{}, false
+	}
+	var req dto.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return dto.TOTPCodeRequest{}, false
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return dto.TOTPCodeRequest{}, false
+	}
+	req.UserID = userID
+	return req, true
+}
+
+func totpErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrTOTPAlreadyEnabled):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrTOTPNotEnrolled), errors.Is(err, service.ErrTOTPNotEnabled):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrTOTPCodeInvalid), errors.Is(err, service.ErrMFAChallengeInvalid):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrUserDisabled):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+var _ TOTPServicer = (*service.TOTPService)(nil)
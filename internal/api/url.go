@@ -3,32 +3,52 @@ package api
 import (
 	"context"
 	"errors"
+	"html/template"
 	"log"
 	"net/http"
+	neturl "net/url"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
 	"github.com/go-playground/validator/v10"
 	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/model"
 	"github.com/jekyulll/url_shortener/internal/service"
+	"github.com/jekyulll/url_shortener/internal/web"
+	"github.com/jekyulll/url_shortener/pkg/qrcode"
 )
 
 type URLServicer interface {
-	DefaultURL(ctx context.Context) error
 	CreateURL(ctx context.Context, req dto.CreateURLRequest) (*dto.CreateURLResponse, error)
-	GetURL(ctx context.Context, shortCode string) (string, error)
+	GetURL(ctx context.Context, host, shortCode string) (*dto.URL, error)
 	GetURLs(ctx context.Context, req dto.GetURLsRequest) (*dto.GetURLsResponse, error)
-	IncreViews(ctx context.Context, shortCode string) error
-	DeleteURL(ctx context.Context, shortCode string) error
+	ExportURLs(ctx context.Context, req dto.ExportURLsRequest, write func(dto.ExportedURL) error) error
+	IncreViews(ctx context.Context, url *dto.URL) error
+	DeleteURL(ctx context.Context, req dto.DeleteURLRequest) error
 	UpdateURLDuration(ctx context.Context, req dto.UpdateURLDurationReq) error
+	GetQRCode(ctx context.Context, req dto.QRCodeRequest) (*dto.QRCodeResponse, error)
+	GetTrash(ctx context.Context, req dto.GetURLsRequest) (*dto.GetTrashResponse, error)
+	RestoreURL(ctx context.Context, req dto.RestoreURLRequest) error
+	PauseURL(ctx context.Context, req dto.PauseURLRequest) error
+	ResumeURL(ctx context.Context, req dto.PauseURLRequest) error
+	UpdateURLDetails(ctx context.Context, req dto.UpdateURLDetailsRequest) error
+}
+
+// LandingPager 查找用户自定义的落地页模板，没有时返回 nil
+type LandingPager interface {
+	FindPage(ctx context.Context, kind, host string, ownerID uint64) (*template.Template, error)
 }
 
 type URLHandler struct {
 	urlService URLServicer
+	pages      LandingPager
 }
 
-func NewURLHandler(urlService URLServicer) *URLHandler {
+func NewURLHandler(urlService URLServicer, pages LandingPager) *URLHandler {
 	return &URLHandler{
 		urlService: urlService,
+		pages:      pages,
 	}
 }
 
@@ -60,12 +80,24 @@ func (h *URLHandler) CreateURL(c *gin.Context) {
 		})
 		return
 	}
+	// 归属只取自 token
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+	req.UserID = userID
 	// 3. 调用业务函数
 	resp, err := h.urlService.CreateURL(c.Request.Context(), req)
 	if err != nil {
 		status := http.StatusInternalServerError
-		if errors.Is(err, service.ErrShortCodeTaken) {
+		switch {
+		case errors.Is(err, service.ErrShortCodeTaken), errors.Is(err, service.ErrShortCodeReserved), errors.Is(err, service.ErrDomainNotFound):
 			status = http.StatusBadRequest
+		case errors.Is(err, service.ErrWorkspaceNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrWorkspaceForbidden):
+			status = http.StatusForbidden
 		}
 		c.JSON(status, gin.H{"error": err.Error()})
 		return
@@ -75,46 +107,124 @@ func (h *URLHandler) CreateURL(c *gin.Context) {
 }
 
 // GET /:code 把短url重定向到长URL
-// TODO 每次访问时，统计该短链接访问次数
+// GET /:code+ 不跳转，展示预览页
 func (h *URLHandler) RedirectURL(c *gin.Context) {
-	// 取出 code
+	// 取出 code，带 + 后缀表示预览
 	shortCode := c.Param("code")
-	// shortcode -> url
-	originalURL, err := h.urlService.GetURL(c.Request.Context(), shortCode)
+	preview := strings.HasSuffix(shortCode, previewSuffix)
+	shortCode = strings.TrimSuffix(shortCode, previewSuffix)
+	// Host + shortcode -> url
+	url, err := h.urlService.GetURL(c.Request.Context(), c.Request.Host, shortCode)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
-	if originalURL == "" {
-		c.JSON(404, gin.H{
-			"error": "no such short code",
+	data := dto.LandingPageData{
+		Host:      c.Request.Host,
+		ShortCode: shortCode,
+	}
+	if url == nil {
+		data.Kind = model.PageNotFound
+		h.renderLanding(c, http.StatusNotFound, data, 0)
+		return
+	}
+	if url.Disabled {
+		data.Kind = model.PageDisabled
+		data.Reason = url.DisabledReason
+		h.renderLanding(c, http.StatusForbidden, data, url.UserID)
+		return
+	}
+	if url.Expired {
+		data.Kind = model.PageExpired
+		data.ExpiredAt = &url.ExpiredAt
+		h.renderLanding(c, http.StatusGone, data, url.UserID)
+		return
+	}
+
+	// 主动预览不计入访问次数；链接开启了中间页时，展示中间页即视为一次访问
+	if !preview {
+		go func() {
+			if err := h.urlService.IncreViews(context.Background(), url); err != nil {
+				log.Printf("failed to incre %s's view ", shortCode)
+			}
+		}()
+	}
+
+	if preview || url.Interstitial {
+		c.HTML(http.StatusOK, "preview.html", gin.H{
+			"Domain":      hostOf(url.OriginalURL),
+			"OriginalURL": url.OriginalURL,
+			"ShortCode":   url.ShortCode,
+			"Title":       url.Title,
+			"Description": url.Description,
+			"CreatedAt":   url.CreatedAt,
 		})
 		return
 	}
 
-	// 增加访问次数
-	go func() {
-		if err := h.urlService.IncreViews(context.Background(), shortCode); err != nil {
-			log.Printf("failed to incre %s's view ", shortCode)
-		}
-	}()
+	// 临时重定向且不允许缓存：暂停、修改或过期后立即生效，每次访问都能计数
+	c.Header("Cache-Control", "private, no-store")
+	c.Redirect(http.StatusFound, url.OriginalURL)
+}
 
-	// 永久重定向（浏览器会缓存）
-	c.Redirect(http.StatusPermanentRedirect, originalURL)
+// NoRoute 未匹配到任何路由时同样展示 404 页
+func (h *URLHandler) NotFound(c *gin.Context) {
+	h.renderLanding(c, http.StatusNotFound, dto.LandingPageData{
+		Kind:      model.PageNotFound,
+		Host:      c.Request.Host,
+		ShortCode: strings.TrimPrefix(c.Request.URL.Path, "/"),
+	}, 0)
 }
 
+// renderLanding 浏览器返回 HTML 落地页（优先使用用户自定义模板），API 客户端返回 JSON
+func (h *URLHandler) renderLanding(c *gin.Context, status int, data dto.LandingPageData, ownerID uint64) {
+	if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) != gin.MIMEHTML {
+		c.JSON(status, gin.H{"error": landingErrors[data.Kind].Error()})
+		return
+	}
+
+	tmpl, err := h.pages.FindPage(c.Request.Context(), data.Kind, c.Request.Host, ownerID)
+	if err != nil {
+		// 自定义模板出错时退回内置页面
+		log.Printf("failed to find landing page: %v", err)
+	}
+	if tmpl != nil {
+		// 自定义模板由用户编写：禁止脚本、外部样式和表单提交，只允许 https 图片
+		c.Header("Content-Security-Policy", landingPageCSP)
+		c.Render(status, render.HTML{Template: tmpl, Name: web.PageTemplateName, Data: data})
+		return
+	}
+	c.HTML(status, data.Kind+".html", data)
+}
+
+const landingPageCSP = "default-src 'none'; style-src 'unsafe-inline'; img-src https: data:; form-action 'none'; base-uri 'none'; frame-ancestors 'none'"
+
+var landingErrors = map[string]error{
+	model.PageNotFound: service.ErrURLNotFound,
+	model.PageExpired:  service.ErrURLExpired,
+	model.PageDisabled: service.ErrURLDisabled,
+}
+
+// GET /api/urls?workspace_id=&page=&size=&q=&status=&custom=&tag=&folder_id=&sort=&order=&cursor=
+// 不指定 workspace_id 时返回所在全部工作区的短链接。
+// 时间范围（created_from、created_to、expires_from、expires_to）使用 RFC3339 格式，
+// 数据量大时使用返回的 next_cursor 翻页
 func (h *URLHandler) GetURLs(c *gin.Context) {
-	userID, exists := c.Get("userID")
-	if !exists {
+	userID, ok := userIDFrom(c)
+	if !ok {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
 		return
 	}
 
 	var req dto.GetURLsRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -126,29 +236,47 @@ func (h *URLHandler) GetURLs(c *gin.Context) {
 		req.Size = 10
 	}
 
-	req.UserID = userID.(int)
+	req.UserID = userID
 
 	resp, err := h.urlService.GetURLs(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(urlErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, resp)
 }
 
+// DELETE /api/url/:code?domain=
+// 需要在短链接所属工作区中有 editor 及以上角色
 func (h *URLHandler) DeleteURL(c *gin.Context) {
-	shortCode := c.Param("code")
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+	req := dto.DeleteURLRequest{
+		Code:   c.Param("code"),
+		Domain: c.Query("domain"),
+		UserID: userID,
+	}
 
-	if err := h.urlService.DeleteURL(c.Request.Context(), shortCode); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.urlService.DeleteURL(c.Request.Context(), req); err != nil {
+		c.JSON(urlErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
+// PATCH /api/url/:code?domain=
+// 需要在短链接所属工作区中有 editor 及以上角色
 func (h *URLHandler) UpdateURLDuration(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
 	var req dto.UpdateURLDurationReq
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -156,15 +284,171 @@ func (h *URLHandler) UpdateURLDuration(c *gin.Context) {
 	}
 
 	req.Code = c.Param("code")
+	req.Domain = c.Query("domain")
+	req.UserID = userID
 
 	if err := h.urlService.UpdateURLDuration(c.Request.Context(), req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(urlErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
+// GET /api/urls/trash?workspace_id=&page=&size=
+// 回收站中仍可恢复的短链接
+func (h *URLHandler) GetTrash(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	var req dto.GetURLsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.Size == 0 {
+		req.Size = 10
+	}
+	req.UserID = userID
+
+	resp, err := h.urlService.GetTrash(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(urlErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// POST /api/url/:code/restore?domain=
+// 从回收站恢复
+func (h *URLHandler) RestoreURL(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+	req := dto.RestoreURLRequest{
+		Code:   c.Param("code"),
+		Domain: c.Query("domain"),
+		UserID: userID,
+	}
+
+	if err := h.urlService.RestoreURL(c.Request.Context(), req); err != nil {
+		c.JSON(urlErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// POST /api/url/:code/pause?domain= reason
+// 暂停短链接，不删除数据
+func (h *URLHandler) PauseURL(c *gin.Context) {
+	var req dto.PauseURLRequest
+	// 允许不带请求体
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.setURLDisabled(c, req, h.urlService.PauseURL)
+}
+
+// POST /api/url/:code/resume?domain=
+func (h *URLHandler) ResumeURL(c *gin.Context) {
+	h.setURLDisabled(c, dto.PauseURLRequest{}, h.urlService.ResumeURL)
+}
+
+// PATCH /api/url/:code/details?domain= [title], [description], [notes], [interstitial]
+// 备注只有工作区成员可见
+func (h *URLHandler) UpdateURLDetails(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+	var req dto.UpdateURLDetailsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Code = c.Param("code")
+	req.Domain = c.Query("domain")
+	req.UserID = userID
+
+	if err := h.urlService.UpdateURLDetails(c.Request.Context(), req); err != nil {
+		c.JSON(urlErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *URLHandler) setURLDisabled(c *gin.Context, req dto.PauseURLRequest, fn func(context.Context, dto.PauseURLRequest) error) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+	req.Code = c.Param("code")
+	req.Domain = c.Query("domain")
+	req.UserID = userID
+
+	if err := fn(c.Request.Context(), req); err != nil {
+		c.JSON(urlErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GET /api/url/:code/qr?domain=&format=png|svg&size=&level=&margin=&fg=&bg=
+// 生成短链接的二维码
+func (h *URLHandler) GetQRCode(c *gin.Context) {
+	var req dto.QRCodeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Code = c.Param("code")
+
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.urlService.GetQRCode(c.Request.Context(), req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrURLNotFound), errors.Is(err, service.ErrDomainNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrURLDisabled):
+			status = http.StatusForbidden
+		case errors.Is(err, service.ErrURLExpired):
+			status = http.StatusGone
+		case errors.Is(err, qrcode.ErrInvalidOption):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, resp.ContentType, resp.Data)
+}
+
 // TODO
 // GET /api/url/:code
 // 获取该 url 的浏览量
@@ -172,4 +456,41 @@ func (h *URLHandler) UpdateURLDuration(c *gin.Context) {
 // 2. 去 redis 缓存中查看浏览量 views2
 // 3. 返回 views1 + views2
 
+// urlErrorStatus 管理短链接时的错误码：其他工作区的短链接与不存在的一样返回 404，
+// 在工作区中只有查看权限时返回 403
+func urlErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrURLNotFound), errors.Is(err, service.ErrDomainNotFound),
+		errors.Is(err, service.ErrWorkspaceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrWorkspaceForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrInvalidCursor):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// 预览后缀，如 /abc+
+const previewSuffix = "+"
+
+// userIDFrom 取出 JWT 中间件写入的用户ID
+func userIDFrom(c *gin.Context) (int, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		return 0, false
+	}
+	id, ok := userID.(int)
+	return id, ok
+}
+
+func hostOf(rawURL string) string {
+	u, err := neturl.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return u.Hostname()
+}
+
 var _ URLServicer = (*service.URLService)(nil)
+var _ LandingPager = (*service.LandingPageService)(nil)
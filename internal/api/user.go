@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 	"github.com/jekyulll/url_shortener/internal/dto"
 	"github.com/jekyulll/url_shortener/internal/service"
 )
@@ -15,8 +18,11 @@ type UserServicer interface {
 	Login(ctx context.Context, req dto.LoginRequest) (*dto.LoginResponse, error)
 	IsEmailAvailable(ctx context.Context, email string) error
 	Register(ctx context.Context, req dto.RegisterReqeust) (*dto.LoginResponse, error)
-	SendEmailCode(ctx context.Context, email string) error
+	SendEmailCode(ctx context.Context, req dto.SendCodeRequest) error
 	ResetPassword(ctx context.Context, req dto.ForgetPasswordReqeust) (*dto.LoginResponse, error)
+	Refresh(ctx context.Context, req dto.RefreshRequest) (*dto.LoginResponse, error)
+	Logout(ctx context.Context, req dto.LogoutRequest) error
+	SetLocale(ctx context.Context, req dto.SetLocaleRequest) error
 }
 
 // UserHandler 处理用户相关的HTTP请求
@@ -36,17 +42,28 @@ func (h *UserHandler) Login(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
+		return
 	}
 
+	req.IP = c.ClientIP()
+
 	resp, err := h.userService.Login(c.Request.Context(), req)
 	if err != nil {
+		if abortRetryAfter(c, err) {
+			return
+		}
 		if errors.Is(err, service.ErrUserNameOrPasswordFailed) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			// TODO check
+			return
+		}
+		if errors.Is(err, service.ErrUserDisabled) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
+		return
 	}
 	c.JSON(http.StatusOK, resp)
 }
@@ -67,13 +84,23 @@ func (h *UserHandler) Register(c *gin.Context) {
 		}
 	}
 
+	req.IP = c.ClientIP()
+	if req.Locale == "" {
+		req.Locale = c.GetHeader("Accept-Language")
+	}
 	resp, err := h.userService.Register(c.Request.Context(), req)
 	if err != nil {
+		if abortRetryAfter(c, err) {
+			return
+		}
 		if errors.Is(err, service.ErrEmailCodeNotEqual) {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": err.Error(),
 			})
+			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 	c.JSON(http.StatusCreated, resp)
 }
@@ -94,8 +121,12 @@ func (h *UserHandler) ForgetPassword(c *gin.Context) {
 		})
 	}
 
+	req.IP = c.ClientIP()
 	resp, err := h.userService.ResetPassword(c.Request.Context(), req)
 	if err != nil {
+		if abortRetryAfter(c, err) {
+			return
+		}
 		if errors.Is(err, service.ErrEmailCodeNotEqual) {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": err.Error(),
@@ -105,20 +136,125 @@ func (h *UserHandler) ForgetPassword(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
+		return
 	}
 	c.JSON(http.StatusOK, resp)
 }
 
 func (h *UserHandler) SendEmailCode(c *gin.Context) {
-	email := c.Param("email")
-	log.Printf("email: %s", email)
+	req := dto.SendCodeRequest{
+		Email:  c.Param("email"),
+		Locale: c.GetHeader("Accept-Language"),
+	}
+	log.Printf("email: %s", req.Email)
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	if err := h.userService.SendEmailCode(c.Request.Context(), email); err != nil {
+	if err := h.userService.SendEmailCode(c.Request.Context(), req); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// POST /api/auth/refresh refresh_token -> 新的 access token 和 refresh token
+func (h *UserHandler) Refresh(c *gin.Context) {
+	var req dto.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.userService.Refresh(c.Request.Context(), req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidRefreshToken) {
+			status = http.StatusUnauthorized
+		}
+		if errors.Is(err, service.ErrUserDisabled) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// POST /api/auth/logout [refresh_token] [all]
+// 吊销当前 access token，all 为 true 时退出所有设备
+func (h *UserHandler) Logout(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	var req dto.LogoutRequest
+	// 请求体可以为空
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	req.UserID = userID
+	req.TokenID = c.GetString("tokenID")
+	req.TokenExpiresAt = c.GetTime("tokenExpiresAt")
+
+	if err := h.userService.Logout(c.Request.Context(), req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// PUT /api/me/locale 设置邮件使用的语言
+func (h *UserHandler) SetLocale(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	var req dto.SetLocaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserID = userID
+
+	if err := h.userService.SetLocale(c.Request.Context(), req); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidLocale) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
 	}
 	c.Status(http.StatusNoContent)
 }
 
 var _ UserServicer = (*service.UserService)(nil)
+
+// abortRetryAfter 登录或验证码尝试次数过多时返回 429 和 Retry-After
+func abortRetryAfter(c *gin.Context, err error) bool {
+	var retry *service.RetryAfterError
+	if !errors.As(err, &retry) {
+		return false
+	}
+	c.Header("Retry-After", strconv.Itoa(max(1, int(math.Ceil(retry.RetryAfter.Seconds())))))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	return true
+}
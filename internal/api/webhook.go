@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/service"
+)
+
+type WebhookServicer interface {
+	CreateWebhook(ctx context.Context, req dto.CreateWebhookRequest) (*dto.CreateWebhookResponse, error)
+	GetWebhooks(ctx context.Context, userID int) ([]dto.WebhookResponse, error)
+	UpdateWebhook(ctx context.Context, req dto.UpdateWebhookRequest) error
+	DeleteWebhook(ctx context.Context, req dto.WebhookRequest) error
+	GetDeliveries(ctx context.Context, req dto.GetWebhookDeliveriesRequest) (*dto.GetWebhookDeliveriesResponse, error)
+	ReplayDelivery(ctx context.Context, req dto.WebhookRequest, deliveryID uint64) error
+}
+
+// WebhookHandler 管理短链接事件的 webhook 订阅和投递记录
+type WebhookHandler struct {
+	webhookService WebhookServicer
+}
+
+func NewWebhookHandler(webhookService WebhookServicer) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// POST /api/webhooks url, events -> 签名密钥（仅此一次）
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	var req dto.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserID = userID
+
+	resp, err := h.webhookService.CreateWebhook(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GET /api/webhooks
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	resp, err := h.webhookService.GetWebhooks(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": resp})
+}
+
+// PATCH /api/webhooks/:id [url], [events], [active]
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	webhookReq, ok := webhookRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ID = webhookReq.ID
+	req.UserID = webhookReq.UserID
+
+	if err := h.webhookService.UpdateWebhook(c.Request.Context(), req); err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DELETE /api/webhooks/:id 投递记录一并删除
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	req, ok := webhookRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.Request.Context(), req); err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GET /api/webhooks/:id/deliveries?status=&page=&size=
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	webhookReq, ok := webhookRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	var req dto.GetWebhookDeliveriesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.WebhookRequest = webhookReq
+
+	resp, err := h.webhookService.GetDeliveries(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// POST /api/webhooks/:id/deliveries/:delivery_id/replay 按原请求体重新推送
+func (h *WebhookHandler) ReplayDelivery(c *gin.Context) {
+	req, ok := webhookRequestFrom(c)
+	if !ok {
+		return
+	}
+	deliveryID, err := strconv.ParseUint(c.Param("delivery_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery id"})
+		return
+	}
+
+	if err := h.webhookService.ReplayDelivery(c.Request.Context(), req, deliveryID); err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusAccepted)
+}
+
+func webhookRequestFrom(c *gin.Context) (dto.WebhookRequest, bool) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return dto.WebhookRequest{}, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook id"})
+		return dto.WebhookRequest{}, false
+	}
+	return dto.WebhookRequest{ID: id, UserID: userID}, true
+}
+
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrWebhookNotFound), errors.Is(err, service.ErrWebhookDeliveryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrTooManyWebhooks):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrWebhookURLForbidden):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+var _ WebhookServicer = (*service.WebhookService)(nil)
@@ -0,0 +1,269 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/service"
+)
+
+type WorkspaceServicer interface {
+	CreateWorkspace(ctx context.Context, req dto.CreateWorkspaceRequest) (*dto.WorkspaceResponse, error)
+	GetWorkspaces(ctx context.Context, userID int) ([]dto.WorkspaceResponse, error)
+	GetWorkspace(ctx context.Context, req dto.WorkspaceRequest) (*dto.WorkspaceDetailResponse, error)
+	UpdateWorkspace(ctx context.Context, req dto.UpdateWorkspaceRequest) (*dto.WorkspaceResponse, error)
+	DeleteWorkspace(ctx context.Context, req dto.WorkspaceRequest) error
+	InviteMember(ctx context.Context, req dto.InviteMemberRequest) (*dto.InviteResponse, error)
+	AcceptInvite(ctx context.Context, req dto.AcceptInviteRequest) (*dto.WorkspaceResponse, error)
+	UpdateMember(ctx context.Context, req dto.MemberRequest) error
+	RemoveMember(ctx context.Context, req dto.MemberRequest) error
+}
+
+// WorkspaceHandler 处理工作区、成员和邀请相关的HTTP请求
+type WorkspaceHandler struct {
+	workspaceService WorkspaceServicer
+}
+
+func NewWorkspaceHandler(workspaceService WorkspaceServicer) *WorkspaceHandler {
+	return &WorkspaceHandler{
+		workspaceService: workspaceService,
+	}
+}
+
+// POST /api/workspaces name -> 新的团队工作区
+func (h *WorkspaceHandler) CreateWorkspace(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	var req dto.CreateWorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserID = userID
+
+	resp, err := h.workspaceService.CreateWorkspace(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GET /api/workspaces 当前用户所在的工作区及角色
+func (h *WorkspaceHandler) GetWorkspaces(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	resp, err := h.workspaceService.GetWorkspaces(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": resp})
+}
+
+// GET /api/workspaces/:id 工作区详情和成员列表
+func (h *WorkspaceHandler) GetWorkspace(c *gin.Context) {
+	req, ok := workspaceRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.workspaceService.GetWorkspace(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(workspaceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// PATCH /api/workspaces/:id name
+func (h *WorkspaceHandler) UpdateWorkspace(c *gin.Context) {
+	wsReq, ok := workspaceRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateWorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ID = wsReq.ID
+	req.UserID = wsReq.UserID
+
+	resp, err := h.workspaceService.UpdateWorkspace(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(workspaceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DELETE /api/workspaces/:id
+func (h *WorkspaceHandler) DeleteWorkspace(c *gin.Context) {
+	req, ok := workspaceRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	if err := h.workspaceService.DeleteWorkspace(c.Request.Context(), req); err != nil {
+		c.JSON(workspaceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// POST /api/workspaces/:id/invites email, role 通过邮件邀请成员
+func (h *WorkspaceHandler) InviteMember(c *gin.Context) {
+	wsReq, ok := workspaceRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	var req dto.InviteMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ID = wsReq.ID
+	req.UserID = wsReq.UserID
+
+	resp, err := h.workspaceService.InviteMember(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(workspaceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// POST /api/workspaces/invites/accept token 接受邀请，需用受邀邮箱登录
+func (h *WorkspaceHandler) AcceptInvite(c *gin.Context) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return
+	}
+
+	var req dto.AcceptInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserID = userID
+
+	resp, err := h.workspaceService.AcceptInvite(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(workspaceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// PUT /api/workspaces/:id/members/:user_id role 修改成员角色
+func (h *WorkspaceHandler) UpdateMember(c *gin.Context) {
+	req, ok := memberRequestFrom(c)
+	if !ok {
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validator.New().Struct(req); err != nil || req.Role == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be one of owner, editor, viewer"})
+		return
+	}
+
+	if err := h.workspaceService.UpdateMember(c.Request.Context(), req); err != nil {
+		c.JSON(workspaceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DELETE /api/workspaces/:id/members/:user_id 移除成员，user_id 为自己时即退出工作区
+func (h *WorkspaceHandler) RemoveMember(c *gin.Context) {
+	req, ok := memberRequestFrom(c)
+	if !ok {
+		return
+	}
+
+	if err := h.workspaceService.RemoveMember(c.Request.Context(), req); err != nil {
+		c.JSON(workspaceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func workspaceRequestFrom(c *gin.Context) (dto.WorkspaceRequest, bool) {
+	userID, ok := userIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户ID"})
+		return dto.WorkspaceRequest{}, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace id"})
+		return dto.WorkspaceRequest{}, false
+	}
+	return dto.WorkspaceRequest{ID: id, UserID: userID}, true
+}
+
+func memberRequestFrom(c *gin.Context) (dto.MemberRequest, bool) {
+	wsReq, ok := workspaceRequestFrom(c)
+	if !ok {
+		return dto.MemberRequest{}, false
+	}
+	memberID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return dto.MemberRequest{}, false
+	}
+	return dto.MemberRequest{ID: wsReq.ID, MemberID: memberID, UserID: wsReq.UserID}, true
+}
+
+func workspaceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrWorkspaceNotFound), errors.Is(err, service.ErrMemberNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrWorkspaceForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrInviteInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrWorkspaceNotEmpty), errors.Is(err, service.ErrWorkspaceMemberExists),
+		errors.Is(err, service.ErrLastOwner), errors.Is(err, service.ErrPersonalWorkspace):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+var _ WorkspaceServicer = (*service.WorkspaceService)(nil)
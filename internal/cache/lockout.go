@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	loginFailPrefix  = "login_fail:"  // 连续失败次数，key 为 account:<email> 或 ip:<ip>
+	loginBlockPrefix = "login_block:" // 在过期前拒绝登录，用于逐次加倍的等待和临时锁定
+)
+
+// IncrLoginFailures 记录一次失败并返回累计次数，ttl 内没有新的失败时清零
+func (cache *RedisCache) IncrLoginFailures(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	pipe := cache.client.TxPipeline()
+	incr := pipe.Incr(ctx, loginFailPrefix+key)
+	pipe.PExpire(ctx, loginFailPrefix+key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return int(incr.Val()), nil
+}
+
+func (cache *RedisCache) ClearLoginFailures(ctx context.Context, key string) error {
+	return cache.client.Del(ctx, loginFailPrefix+key).Err()
+}
+
+// BlockLogin 在 ttl 内拒绝该 key 登录，覆盖之前的等待时间
+func (cache *RedisCache) BlockLogin(ctx context.Context, key string, ttl time.Duration) error {
+	return cache.client.Set(ctx, loginBlockPrefix+key, 1, ttl).Err()
+}
+
+func (cache *RedisCache) UnblockLogin(ctx context.Context, key string) error {
+	return cache.client.Del(ctx, loginBlockPrefix+key).Err()
+}
+
+// LoginBlockedFor 返回还需等待的时间，未被限制时返回 0
+func (cache *RedisCache) LoginBlockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := cache.client.PTTL(ctx, loginBlockPrefix+key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// key 不存在时为 -2，没有过期时间时为 -1
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
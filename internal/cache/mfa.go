@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	mfaChallengePrefix = "mfa_challenge:" // 登录第一步通过后的挑战，key 为 token 的哈希
+	totpUsedPrefix     = "totp_used:"     // 已使用过的 TOTP 码，防止重放
+)
+
+// MFAChallenge 等待输入两步验证码的登录
+type MFAChallenge struct {
+	UserID   int
+	Email    string
+	Failures int
+}
+
+func (cache *RedisCache) SetMFAChallenge(ctx context.Context, hash string, userID int, email string, ttl time.Duration) error {
+	key := mfaChallengePrefix + hash
+	pipe := cache.client.TxPipeline()
+	pipe.HSet(ctx, key, "user_id", userID, "email", email, "failures", 0)
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetMFAChallenge 不存在或已过期时返回 nil
+func (cache *RedisCache) GetMFAChallenge(ctx context.Context, hash string) (*MFAChallenge, error) {
+	values, err := cache.client.HGetAll(ctx, mfaChallengePrefix+hash).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	userID, err := strconv.Atoi(values["user_id"])
+	if err != nil {
+		return nil, err
+	}
+	failures, _ := strconv.Atoi(values["failures"])
+	return &MFAChallenge{
+		UserID:   userID,
+		Email:    values["email"],
+		Failures: failures,
+	}, nil
+}
+
+// IncrMFAChallengeFailures 记录一次验证码错误，返回累计次数
+func (cache *RedisCache) IncrMFAChallengeFailures(ctx context.Context, hash string) (int, error) {
+	n, err := cache.client.HIncrBy(ctx, mfaChallengePrefix+hash, "failures", 1).Result()
+	return int(n), err
+}
+
+func (cache *RedisCache) DelMFAChallenge(ctx context.Context, hash string) error {
+	return cache.client.Del(ctx, mfaChallengePrefix+hash).Err()
+}
+
+// MarkTOTPUsed 标记验证码已使用，同一个码在有效期内再次出现时返回 false
+func (cache *RedisCache) MarkTOTPUsed(ctx context.Context, userID int, code string, ttl time.Duration) (bool, error) {
+	ok, err := cache.client.SetNX(ctx, totpUsedPrefix+strconv.Itoa(userID)+":"+code, 1, ttl).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	return ok, err
+}
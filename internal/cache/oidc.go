@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const oidcStatePrefix = "oidc_state:"
+
+// OIDCState 发起登录到回调之间需要保存的数据
+type OIDCState struct {
+	Provider string `json:"provider"`
+	Verifier string `json:"verifier"` // PKCE code_verifier
+	Nonce    string `json:"nonce"`
+}
+
+func (cache *RedisCache) SetOIDCState(ctx context.Context, state string, data OIDCState, ttl time.Duration) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return cache.client.Set(ctx, oidcStatePrefix+state, b, ttl).Err()
+}
+
+// TakeOIDCState 取出并删除，state 只能使用一次。不存在时返回 nil
+func (cache *RedisCache) TakeOIDCState(ctx context.Context, state string) (*OIDCState, error) {
+	b, err := cache.client.GetDel(ctx, oidcStatePrefix+state).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var data OIDCState
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
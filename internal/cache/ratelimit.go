@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/jekyulll/url_shortener/pkg/ratelimit"
+	"github.com/redis/go-redis/v9"
+)
+
+const rateLimitPrefix = "ratelimit:"
+
+// 两个脚本与 pkg/ratelimit 中的内存实现算法一致，时间取 Redis 服务器时间，多实例共享计数。
+// 返回 {是否放行, 剩余额度, reset 毫秒, retry 毫秒}
+
+// KEYS[1] 桶；ARGV: limit, window(ms), cost
+var tokenBucketScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local t = redis.call('TIME')
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+local rate = limit / window
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or limit
+local ts = tonumber(state[2]) or now
+tokens = math.min(limit, tokens + math.max(0, now - ts) * rate)
+local allowed, retry = 0, 0
+if tokens >= cost then
+	tokens = tokens - cost
+	allowed = 1
+else
+	retry = math.ceil((cost - tokens) / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], window)
+return {allowed, math.floor(tokens), math.ceil((limit - tokens) / rate), retry}
+`)
+
+// KEYS[1] 窗口计数；ARGV: limit, window(ms), cost
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local t = redis.call('TIME')
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+local index = math.floor(now / window)
+local elapsed = now - index * window
+local state = redis.call('HMGET', KEYS[1], 'index', 'prev', 'curr')
+local prev = tonumber(state[2]) or 0
+local curr = tonumber(state[3]) or 0
+local last = tonumber(state[1])
+if last == index - 1 then
+	prev, curr = curr, 0
+elseif last ~= index then
+	prev, curr = 0, 0
+end
+local estimate = prev * (1 - elapsed / window) + curr
+local allowed, retry = 0, 0
+if estimate + cost <= limit then
+	curr = curr + cost
+	estimate = estimate + cost
+	allowed = 1
+elseif curr + cost <= limit and prev > 0 then
+	retry = math.ceil(window * (1 - (limit - cost - curr) / prev) - elapsed)
+else
+	retry = math.ceil(window - elapsed + window * (1 - (limit - cost) / math.max(curr, 1)))
+end
+redis.call('HSET', KEYS[1], 'index', index, 'prev', prev, 'curr', curr)
+redis.call('PEXPIRE', KEYS[1], window * 2)
+return {allowed, math.max(0, math.floor(limit - estimate)), window - elapsed, retry}
+`)
+
+// Allow implements ratelimit.Limiter.
+func (cache *RedisCache) Allow(ctx context.Context, key string, rule ratelimit.Rule) (ratelimit.Result, error) {
+	script := tokenBucketScript
+	if rule.Algorithm == ratelimit.SlidingWindow {
+		script = slidingWindowScript
+	}
+	vals, err := script.Run(ctx, cache.client, []string{rateLimitPrefix + rule.Algorithm + ":" + key},
+		rule.Limit, rule.Window.Milliseconds(), max(rule.Cost, 1)).Int64Slice()
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	return ratelimit.Result{
+		Allowed:    vals[0] == 1,
+		Limit:      rule.Limit,
+		Remaining:  int(vals[1]),
+		Reset:      time.Duration(vals[2]) * time.Millisecond,
+		RetryAfter: time.Duration(vals[3]) * time.Millisecond,
+	}, nil
+}
+
+var _ ratelimit.Limiter = (*RedisCache)(nil)
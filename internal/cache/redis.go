@@ -42,6 +42,8 @@ func NewRedisCache(cfg config.RedisConfig) (*RedisCache, error) {
 		bloomCapacity:   cfg.BloomCapacity,
 		UseBloom:        true,
 		CacheTTL:        cfg.CacheTTL,
+
+		emailCodeDuration: EmailCodeDuration,
 	}
 	// 初始化布隆过滤器
 	err := cache.InitBloomFilter(context.Background(), cfg.BloomFilterName, cfg.BloomErrorRate, cfg.BloomCapacity)
@@ -68,7 +70,7 @@ func (cache *RedisCache) InitBloomFilter(ctx context.Context, name string, error
 }
 
 func (cache *RedisCache) SetURL(ctx context.Context, url model.URL) error {
-	if err := cache.BloomAdd(ctx, url.ShortCode); err != nil {
+	if err := cache.BloomAdd(ctx, url.Key()); err != nil {
 		// TODO 如果添加失败，之后再查找该短链接是否会直接显示不存在？是否需要处理？
 		log.Printf("failed to set bloom filter: %v", err)
 	}
@@ -82,23 +84,24 @@ func (cache *RedisCache) SetURL(ctx context.Context, url model.URL) error {
 		expiration = cache.CacheTTL
 	}
 	expiration = time.Until(url.ExpiredAt)
-	cmd := cache.client.Set(ctx, url.ShortCode, data, expiration)
+	cmd := cache.client.Set(ctx, urlPrefix+url.Key(), data, expiration)
 	if cmd.Err() != nil {
 		return cmd.Err()
 	}
 	return nil
 }
 
-func (cache *RedisCache) GetURL(ctx context.Context, shortCode string) (*model.URL, error) {
+// key 由 model.URLKey 生成
+func (cache *RedisCache) GetURL(ctx context.Context, key string) (*model.URL, error) {
 	// 查找布隆过滤器
-	exist, err := cache.BloomExists(ctx, shortCode)
+	exist, err := cache.BloomExists(ctx, key)
 	if err != nil {
 		log.Printf("failed to read bloom filter: %v", err)
 	}
 	if !exist {
 		return nil, nil
 	}
-	cmd := cache.client.Get(ctx, shortCode)
+	cmd := cache.client.Get(ctx, urlPrefix+key)
 	if err := cmd.Err(); err != nil {
 		// Get 不到值时会返回 redis.Nil，这是「缓存未命中」的正常情况
 		if err == redis.Nil {
@@ -114,8 +117,8 @@ func (cache *RedisCache) GetURL(ctx context.Context, shortCode string) (*model.U
 	return &u, nil
 }
 
-func (cache *RedisCache) DelURL(ctx context.Context, shortCode string) error {
-	return cache.client.Del(ctx, urlPrefix+shortCode).Err()
+func (cache *RedisCache) DelURL(ctx context.Context, key string) error {
+	return cache.client.Del(ctx, urlPrefix+key).Err()
 }
 
 func (cache *RedisCache) Close() error {
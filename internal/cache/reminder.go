@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+const extendTokenPrefix = "extend_token:" // 过期提醒中一键延期的令牌，key 为 token 的哈希
+
+// ExtendToken 允许 UserID 延长一个短链接的有效期，CreatedAt 用于识别短码过期后被重新创建的情况
+type ExtendToken struct {
+	URLID     uint64
+	UserID    int
+	CreatedAt int64
+}
+
+func (cache *RedisCache) SetExtendToken(ctx context.Context, hash string, token ExtendToken, ttl time.Duration) error {
+	key := extendTokenPrefix + hash
+	pipe := cache.client.TxPipeline()
+	pipe.HSet(ctx, key, "url_id", token.URLID, "user_id", token.UserID, "created_at", token.CreatedAt)
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetExtendToken 不存在或已过期时返回 nil
+func (cache *RedisCache) GetExtendToken(ctx context.Context, hash string) (*ExtendToken, error) {
+	values, err := cache.client.HGetAll(ctx, extendTokenPrefix+hash).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	urlID, err := strconv.ParseUint(values["url_id"], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := strconv.Atoi(values["user_id"])
+	if err != nil {
+		return nil, err
+	}
+	createdAt, err := strconv.ParseInt(values["created_at"], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &ExtendToken{
+		URLID:     urlID,
+		UserID:    userID,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// DelExtendToken 返回令牌是否存在，用于保证只能使用一次
+func (cache *RedisCache) DelExtendToken(ctx context.Context, hash string) (bool, error) {
+	n, err := cache.client.Del(ctx, extendTokenPrefix+hash).Result()
+	return n == 1, err
+}
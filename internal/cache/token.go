@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	refreshPrefix      = "refresh:"       // 有效的 refresh token，key 为 token 的哈希
+	refreshUsedPrefix  = "refresh_used:"  // 已轮换掉的 refresh token，用于发现重放
+	tokenVersionPrefix = "token_version:" // 用户当前的 token 版本，数据库中 users.token_version 的缓存
+	tokenDenyPrefix    = "token_deny:"    // 已吊销的 access token（按 jti）
+)
+
+// RefreshSession 是 refresh token 对应的会话信息
+type RefreshSession struct {
+	UserID  int    `json:"user_id"`
+	Email   string `json:"email"`
+	Version int64  `json:"version"`
+}
+
+func (cache *RedisCache) SetRefreshToken(ctx context.Context, hash string, session RefreshSession, ttl time.Duration) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return cache.client.Set(ctx, refreshPrefix+hash, data, ttl).Err()
+}
+
+// TakeRefreshToken 取出并删除 refresh token，保证每个 token 只能使用一次。
+// token 不存在时返回 nil；若该 token 之前已被轮换过，reusedBy 为其所属用户
+func (cache *RedisCache) TakeRefreshToken(ctx context.Context, hash string, usedTTL time.Duration) (session *RefreshSession, reusedBy int, err error) {
+	data, err := cache.client.GetDel(ctx, refreshPrefix+hash).Bytes()
+	if errors.Is(err, redis.Nil) {
+		reusedBy, err := cache.client.Get(ctx, refreshUsedPrefix+hash).Int()
+		if errors.Is(err, redis.Nil) {
+			return nil, 0, nil
+		}
+		return nil, reusedBy, err
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	var s RefreshSession
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, 0, err
+	}
+	if err := cache.client.Set(ctx, refreshUsedPrefix+hash, s.UserID, usedTTL).Err(); err != nil {
+		return nil, 0, err
+	}
+	return &s, 0, nil
+}
+
+func (cache *RedisCache) DelRefreshToken(ctx context.Context, hash string) error {
+	return cache.client.Del(ctx, refreshPrefix+hash).Err()
+}
+
+// GetTokenVersion 缓存中没有时 ok 为 false，由调用方从数据库加载
+func (cache *RedisCache) GetTokenVersion(ctx context.Context, userID int) (version int64, ok bool, err error) {
+	v, err := cache.client.Get(ctx, tokenVersionPrefix+strconv.Itoa(userID)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, false, nil
+	}
+	return v, err == nil, err
+}
+
+// SetTokenVersion 数据库中的版本号增加后覆盖缓存
+func (cache *RedisCache) SetTokenVersion(ctx context.Context, userID int, version int64, ttl time.Duration) error {
+	return cache.client.Set(ctx, tokenVersionPrefix+strconv.Itoa(userID), version, ttl).Err()
+}
+
+// AddTokenVersion 从数据库加载后写入缓存，已有值时不覆盖：加载期间版本号可能已经增加
+func (cache *RedisCache) AddTokenVersion(ctx context.Context, userID int, version int64, ttl time.Duration) error {
+	return cache.client.SetNX(ctx, tokenVersionPrefix+strconv.Itoa(userID), version, ttl).Err()
+}
+
+// DenyToken 吊销单个 access token，保留到它自然过期为止
+func (cache *RedisCache) DenyToken(ctx context.Context, tokenID string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	return cache.client.Set(ctx, tokenDenyPrefix+tokenID, 1, ttl).Err()
+}
+
+func (cache *RedisCache) IsTokenDenied(ctx context.Context, tokenID string) (bool, error) {
+	n, err := cache.client.Exists(ctx, tokenDenyPrefix+tokenID).Result()
+	return n > 0, err
+}
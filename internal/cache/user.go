@@ -1,14 +1,49 @@
 package cache
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
 
 const emailPrifix = "email:"
 
-func (cache *RedisCache) GetEmailCode(ctx context.Context, email string) (string, error) {
-	emailCode := cache.client.Get(ctx, emailPrifix+email).Val()
-	return emailCode, nil
-}
+// EmailCodeDuration 邮箱验证码的有效期
+const EmailCodeDuration = 10 * time.Minute
+
+// KEYS[1] 验证码；ARGV: code, 最多允许输错的次数。
+// 输对后删除，保证只能使用一次；输错达到次数后同样删除
+var checkEmailCodeScript = redis.NewScript(`
+if redis.call('TYPE', KEYS[1]).ok ~= 'hash' then
+	return 0
+end
+if redis.call('HGET', KEYS[1], 'code') == ARGV[1] then
+	redis.call('DEL', KEYS[1])
+	return 1
+end
+if redis.call('HINCRBY', KEYS[1], 'failures', 1) >= tonumber(ARGV[2]) then
+	redis.call('DEL', KEYS[1])
+end
+return 0
+`)
 
+// SetEmailCode 保存新的验证码，同时清零之前的错误次数
 func (cache *RedisCache) SetEmailCode(ctx context.Context, email, emailCode string) error {
-	return cache.client.Set(ctx, emailPrifix+email, emailCode, cache.emailCodeDuration).Err()
+	key := emailPrifix + email
+	pipe := cache.client.TxPipeline()
+	pipe.Del(ctx, key)
+	pipe.HSet(ctx, key, "code", emailCode, "failures", 0)
+	pipe.Expire(ctx, key, cache.emailCodeDuration)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// CheckEmailCode 校验并消费验证码，不存在、已过期或不一致时返回 false
+func (cache *RedisCache) CheckEmailCode(ctx context.Context, email, emailCode string, maxFailures int) (bool, error) {
+	ok, err := checkEmailCodeScript.Run(ctx, cache.client, []string{emailPrifix + email}, emailCode, maxFailures).Int()
+	if err != nil {
+		return false, err
+	}
+	return ok == 1, nil
 }
@@ -2,22 +2,33 @@ package cache
 
 import (
 	"context"
+	"strings"
 
 	"github.com/redis/go-redis/v9"
 )
 
 const viewPrifix = "views:"
 
-func (r *RedisCache) IncreViews(ctx context.Context, shortCode string) error {
-	return r.client.Incr(context.Background(), viewPrifix+shortCode).Err()
+// 以下方法的 key 均由 model.URLKey 生成，不含 views: 前缀
+
+func (r *RedisCache) IncreViews(ctx context.Context, key string) error {
+	return r.client.Incr(context.Background(), viewPrifix+key).Err()
 }
 
+// ScanViews 返回去掉前缀后的 key
 func (r *RedisCache) ScanViews(ctx context.Context, cursor uint64, batchSize int64) (keys []string, nextCursor uint64, err error) {
-	return r.client.Scan(ctx, cursor, viewPrifix, batchSize).Result()
+	keys, nextCursor, err = r.client.Scan(ctx, cursor, viewPrifix+"*", batchSize).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range keys {
+		keys[i] = strings.TrimPrefix(keys[i], viewPrifix)
+	}
+	return keys, nextCursor, nil
 }
 
-func (r *RedisCache) GetViews(ctx context.Context, shortCode string) (int, error) {
-	views, err := r.client.Get(ctx, viewPrifix+shortCode).Int()
+func (r *RedisCache) GetViews(ctx context.Context, key string) (int, error) {
+	views, err := r.client.Get(ctx, viewPrifix+key).Int()
 	if err == redis.Nil {
 		return 0, nil
 	}
@@ -30,5 +41,5 @@ func (r *RedisCache) GetViews(ctx context.Context, shortCode string) (int, error
 }
 
 func (r *RedisCache) DelViews(ctx context.Context, key string) error {
-	return r.client.Del(ctx, key).Err()
+	return r.client.Del(ctx, viewPrifix+key).Err()
 }
@@ -0,0 +1,56 @@
+package dto
+
+import "time"
+
+type AdminSearchRequest struct {
+	Q      string `form:"q"`       // 用户按邮箱，短链接按短码或原始链接模糊匹配
+	UserID uint64 `form:"user_id"` // 仅短链接：按所属用户过滤
+	Page   int    `form:"page" validate:"omitempty,min=1"`
+	Size   int    `form:"size" validate:"omitempty,min=1,max=100"`
+}
+
+type AdminUser struct {
+	ID          uint64    `json:"id"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	Disabled    bool      `json:"disabled"`
+	TOTPEnabled bool      `json:"totp_enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type AdminUsersResponse struct {
+	Items []AdminUser `json:"items"`
+	Total int64       `json:"total"`
+}
+
+type AdminURL struct {
+	FullURL
+	UserID uint64 `json:"user_id"`
+}
+
+type AdminURLsResponse struct {
+	Items []AdminURL `json:"items"`
+	Total int64      `json:"total"`
+}
+
+type SetUserRoleRequest struct {
+	Role    string `json:"role" validate:"required,oneof=user moderator admin"`
+	ID      uint64 `json:"-"`
+	ActorID int    `json:"-"` // 操作的管理员
+}
+
+type SetUserDisabledRequest struct {
+	Disabled bool   `json:"-"`
+	ID       uint64 `json:"-"`
+	ActorID  int    `json:"-"`
+}
+
+type AdminURLRequest struct {
+	Code   string `uri:"code"`
+	Domain string `form:"domain"`
+}
+
+type TransferURLRequest struct {
+	AdminURLRequest
+	ToUserID uint64 `json:"user_id" validate:"required,min=1"`
+}
@@ -0,0 +1,37 @@
+package dto
+
+import "time"
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" validate:"required,max=64"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,oneof=read write"`
+	ExpiresInDays *int     `json:"expires_in_days,omitempty" validate:"omitempty,min=1,max=3650"` // 不传则永不过期
+	UserID        int      `json:"-"`
+}
+
+type UpdateAPIKeyRequest struct {
+	Name   string `json:"name" validate:"required,max=64"`
+	ID     uint64 `json:"-"`
+	UserID int    `json:"-"`
+}
+
+type APIKeyRequest struct {
+	ID     uint64 `uri:"id"`
+	UserID int    `json:"-"`
+}
+
+type APIKeyResponse struct {
+	ID         uint64     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"` // 完整的 key 只在创建时返回一次
+}
@@ -0,0 +1,59 @@
+package dto
+
+import "time"
+
+// BulkURLItem 批量创建中的一行，校验规则同 CreateURLRequest
+type BulkURLItem struct {
+	OriginalURL string `json:"original_url"`
+	CustomCode  string `json:"custom_code,omitempty"`
+	Duration    *int   `json:"duration,omitempty"` // 小时，不传使用默认有效期
+}
+
+// BulkCreateURLRequest 行来自 JSON 数组或 CSV 文件，其余参数取自 query，对所有行生效
+type BulkCreateURLRequest struct {
+	Items       []BulkURLItem `form:"-"`
+	WorkspaceID uint64        `form:"workspace_id"`                     // 不传则放入个人工作区
+	Domain      string        `form:"domain" validate:"omitempty,fqdn"` // 自定义域名，不传使用默认域名
+	Async       bool          `form:"async"`                            // 创建后台任务，通过任务状态接口查询结果
+	UserID      int           `form:"-"`
+}
+
+type BulkURLResult struct {
+	Row         int    `json:"row"` // 从 1 开始，不含 CSV 表头
+	OriginalURL string `json:"original_url"`
+	ShortURL    string `json:"short_url,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+type BulkCreateURLResponse struct {
+	Total     int             `json:"total"`
+	Succeeded int             `json:"succeeded"`
+	Failed    int             `json:"failed"`
+	Items     []BulkURLResult `json:"items"`
+}
+
+type BulkJob struct {
+	ID         uint64     `json:"id"`
+	Status     string     `json:"status"`
+	Total      int        `json:"total"`
+	Processed  int        `json:"processed"`
+	Succeeded  int        `json:"succeeded"`
+	Failed     int        `json:"failed"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+type GetBulkJobRequest struct {
+	FailedOnly bool   `form:"failed_only"`
+	Page       int    `form:"page" validate:"omitempty,min=1"`
+	Size       int    `form:"size" validate:"omitempty,min=1,max=1000"`
+	ID         uint64 `form:"-"`
+	UserID     int    `form:"-"`
+}
+
+// GetBulkJobResponse 任务进度和已处理行的结果，结果分页返回
+type GetBulkJobResponse struct {
+	BulkJob
+	Results      []BulkURLResult `json:"results"`
+	ResultsTotal int64           `json:"results_total"`
+}
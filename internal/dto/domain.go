@@ -0,0 +1,23 @@
+package dto
+
+import "time"
+
+type AddDomainRequest struct {
+	Host   string `json:"host" validate:"required,fqdn"`
+	UserID int    `json:"-"`
+}
+
+type DomainRequest struct {
+	ID     uint64 `uri:"id"`
+	UserID int    `json:"-"`
+}
+
+type DomainResponse struct {
+	ID         uint64     `json:"id"`
+	Host       string     `json:"host"`
+	Verified   bool       `json:"verified"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	TXTName    string     `json:"txt_name"`  // 需要添加的 DNS TXT 记录名
+	TXTValue   string     `json:"txt_value"` // 需要添加的 DNS TXT 记录值
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -0,0 +1,26 @@
+package dto
+
+import "time"
+
+type GetEmailsRequest struct {
+	Status string `form:"status" validate:"omitempty,oneof=pending sent dead"`
+	Page   int    `form:"page" validate:"omitempty,min=1"`
+	Size   int    `form:"size" validate:"omitempty,min=1,max=100"`
+}
+
+type OutboxEmail struct {
+	ID            uint64     `json:"id"`
+	Recipient     string     `json:"recipient"`
+	Subject       string     `json:"subject"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type GetEmailsResponse struct {
+	Items []OutboxEmail `json:"items"`
+	Total int64         `json:"total"`
+}
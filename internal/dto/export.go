@@ -0,0 +1,25 @@
+package dto
+
+import "time"
+
+type ExportURLsRequest struct {
+	Format      string `form:"format" validate:"omitempty,oneof=csv jsonl xlsx"` // 默认 csv
+	WorkspaceID uint64 `form:"workspace_id"`                                     // 只导出某个工作区，不传为所在的全部工作区
+	UserID      int    `form:"-"`
+}
+
+// ExportedURL 导出的一行，访问量为最近一次同步到数据库的值
+type ExportedURL struct {
+	ID             uint64    `json:"id"`
+	ShortURL       string    `json:"short_url"`
+	ShortCode      string    `json:"short_code"`
+	OriginalURL    string    `json:"original_url"`
+	Title          string    `json:"title"`
+	WorkspaceID    uint64    `json:"workspace_id"`
+	IsCustom       bool      `json:"is_custom"`
+	Views          int32     `json:"views"`
+	Disabled       bool      `json:"disabled"`
+	DisabledReason string    `json:"disabled_reason"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiredAt      time.Time `json:"expired_at"`
+}
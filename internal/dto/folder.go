@@ -0,0 +1,48 @@
+package dto
+
+import "time"
+
+type CreateFolderRequest struct {
+	WorkspaceID uint64 `json:"workspace_id,omitempty"` // 不传则为个人工作区，指定了 parent_id 时以父文件夹为准
+	ParentID    uint64 `json:"parent_id,omitempty"`
+	Name        string `json:"name" validate:"required,max=64"`
+	UserID      int    `json:"-"`
+}
+
+// UpdateFolderRequest 重命名或移动到其他文件夹下，未传的字段保持不变
+type UpdateFolderRequest struct {
+	Name     *string `json:"name,omitempty" validate:"omitempty,min=1,max=64"`
+	ParentID *uint64 `json:"parent_id,omitempty"` // 0 表示移到根目录
+	ID       uint64  `json:"-"`
+	UserID   int     `json:"-"`
+}
+
+type FolderRequest struct {
+	ID     uint64 `uri:"id"`
+	UserID int    `json:"-"`
+}
+
+type GetFoldersRequest struct {
+	WorkspaceID uint64 `form:"workspace_id"` // 不传为所在的全部工作区
+	UserID      int    `form:"-"`
+}
+
+type FolderResponse struct {
+	ID          uint64    `json:"id"`
+	WorkspaceID uint64    `json:"workspace_id"`
+	ParentID    uint64    `json:"parent_id"`
+	Name        string    `json:"name"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// MoveURLsRequest 批量把短链接移入文件夹，folder_id 为 0 时移出文件夹。
+// 短链接必须和文件夹在同一个工作区
+type MoveURLsRequest struct {
+	URLIDs   []uint64 `json:"url_ids" validate:"required,min=1,max=1000"`
+	FolderID uint64   `json:"folder_id"`
+	UserID   int      `json:"-"`
+}
+
+type MoveURLsResponse struct {
+	Updated int `json:"updated"`
+}
@@ -0,0 +1,32 @@
+package dto
+
+import "time"
+
+type SetLandingPageRequest struct {
+	Kind     string `uri:"kind" validate:"required,oneof=not_found expired disabled"`
+	Domain   string `json:"domain,omitempty" validate:"omitempty,fqdn"` // 为空时对用户名下所有短链接生效
+	Template string `json:"template" validate:"required,max=65536"`     // html/template 语法，可用字段见 LandingPageData
+	UserID   int    `json:"-"`
+}
+
+type DeleteLandingPageRequest struct {
+	Kind   string `uri:"kind" validate:"required,oneof=not_found expired disabled"`
+	Domain string `form:"domain" validate:"omitempty,fqdn"`
+	UserID int    `json:"-"`
+}
+
+type LandingPageResponse struct {
+	Kind      string    `json:"kind"`
+	Domain    string    `json:"domain,omitempty"`
+	Template  string    `json:"template"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LandingPageData 渲染落地页模板时可用的数据
+type LandingPageData struct {
+	Kind      string
+	Host      string
+	ShortCode string
+	ExpiredAt *time.Time // 仅过期页
+	Reason    string     // 仅停用页
+}
@@ -0,0 +1,16 @@
+package dto
+
+import "time"
+
+type ReminderSettings struct {
+	ExpiryReminders bool `json:"expiry_reminders"`
+	UserID          int  `json:"-"`
+}
+
+// ExtendURLResponse 一键延期页面展示的信息
+type ExtendURLResponse struct {
+	ShortURL     string
+	OriginalURL  string
+	ExpiredAt    time.Time
+	NewExpiredAt time.Time
+}
@@ -0,0 +1,49 @@
+package dto
+
+import "time"
+
+type CreateTagRequest struct {
+	WorkspaceID uint64 `json:"workspace_id,omitempty"` // 不传则为个人工作区
+	Name        string `json:"name" validate:"required,max=32"`
+	Color       string `json:"color,omitempty" validate:"omitempty,hexcolor"`
+	UserID      int    `json:"-"`
+}
+
+// UpdateTagRequest 未传的字段保持不变
+type UpdateTagRequest struct {
+	Name   *string `json:"name,omitempty" validate:"omitempty,min=1,max=32"`
+	Color  *string `json:"color,omitempty" validate:"omitempty,hexcolor"`
+	ID     uint64  `json:"-"`
+	UserID int     `json:"-"`
+}
+
+type TagRequest struct {
+	ID     uint64 `uri:"id"`
+	UserID int    `json:"-"`
+}
+
+type GetTagsRequest struct {
+	WorkspaceID uint64 `form:"workspace_id"` // 不传为所在的全部工作区
+	UserID      int    `form:"-"`
+}
+
+type TagResponse struct {
+	ID          uint64    `json:"id"`
+	WorkspaceID uint64    `json:"workspace_id"`
+	Name        string    `json:"name"`
+	Color       string    `json:"color,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TagURLsRequest 批量给短链接添加、移除标签。标签按名称指定，
+// 添加时短链接所在工作区中没有的标签自动创建
+type TagURLsRequest struct {
+	URLIDs []uint64 `json:"url_ids" validate:"required,min=1,max=1000"`
+	Add    []string `json:"add,omitempty" validate:"max=20,dive,required,max=32"`
+	Remove []string `json:"remove,omitempty" validate:"max=20,dive,required,max=32"`
+	UserID int      `json:"-"`
+}
+
+type TagURLsResponse struct {
+	Updated int `json:"updated"`
+}
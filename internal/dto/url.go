@@ -3,49 +3,149 @@ package dto
 import "time"
 
 type CreateURLRequest struct {
-	OriginalURL string `json:"original_url" validate:"required,url"`
-	CustomeCode string `json:"custom_code,omitempty" validate:"omitempty,min=4,max=10,alphanum"`
-	Duration    *int   `json:"duration,omitempty" validate:"omitempty,min=1,max=100"`
-	UserID      int    `json:"user_id" validate:"omitempty,min=1"`
-	// UserID      int    `json:"-"`
+	OriginalURL  string `json:"original_url" validate:"required,url"`
+	CustomeCode  string `json:"custom_code,omitempty" validate:"omitempty,min=4,max=10,alphanum"`
+	Duration     *int   `json:"duration,omitempty" validate:"omitempty,min=1,max=100"`
+	UserID       int    `json:"-"`                                          // 只取自 token，不接受请求体中的值
+	WorkspaceID  uint64 `json:"workspace_id,omitempty"`                     // 不传则放入个人工作区
+	Domain       string `json:"domain,omitempty" validate:"omitempty,fqdn"` // 自定义域名，不传使用默认域名
+	Title        string `json:"title,omitempty" validate:"omitempty,max=255"`
+	Description  string `json:"description,omitempty" validate:"omitempty,max=1000"`
+	Interstitial bool   `json:"interstitial,omitempty"` // 访问时总是先展示预览页
+	QR           bool   `json:"qr,omitempty"`           // 是否在响应中附带二维码
 }
 
 type CreateURLResponse struct {
 	ShortUrl string `json:"short_url"`
 	//ExpiredAt time.Time `json:"expired_at"`
+	QR string `json:"qr,omitempty"` // data URI 形式的 PNG 二维码
 }
 
 type GetURLsRequest struct {
-	Page   uint `query:"page"`
-	Size   uint `query:"size"`
-	UserID int  `query:"id"`
-	// UserID int  `query:"-"`
+	Page        uint   `form:"page"`
+	Size        uint   `form:"size" validate:"omitempty,max=100"`
+	WorkspaceID uint64 `form:"workspace_id"` // 只看某个工作区，不传为所在的全部工作区
+	UserID      int    `form:"-"`
+
+	// 以下只用于短链接列表，回收站忽略
+	Q           string    `form:"q" validate:"omitempty,max=255"` // 搜索原始链接、短码和标题
+	Status      string    `form:"status" validate:"omitempty,oneof=active expired disabled"`
+	Custom      *bool     `form:"custom"` // true 只看自定义短码，false 只看自动生成的
+	Tag         string    `form:"tag" validate:"omitempty,max=32"`
+	FolderID    *uint64   `form:"folder_id"` // 0 只看不在文件夹中的
+	CreatedFrom time.Time `form:"created_from"`
+	CreatedTo   time.Time `form:"created_to"`
+	ExpiresFrom time.Time `form:"expires_from"`
+	ExpiresTo   time.Time `form:"expires_to"`
+	MinViews    *int32    `form:"min_views" validate:"omitempty,min=0"`
+	MaxViews    *int32    `form:"max_views" validate:"omitempty,min=0"`
+	Sort        string    `form:"sort" validate:"omitempty,oneof=created_at expired_at views short_code title original_url"`
+	Order       string    `form:"order" validate:"omitempty,oneof=asc desc"`
+	Cursor      string    `form:"cursor"` // 上一页返回的 next_cursor，传入后忽略 page
 }
 
 type GetURLsResponse struct {
-	Items []FullURL `json:"items"`
-	Total int       `json:"total"`
+	Items      []FullURL `json:"items"`
+	Total      int64     `json:"total"`                 // 符合过滤条件的短链接总数
+	NextCursor string    `json:"next_cursor,omitempty"` // 没有下一页时为空
 }
 
 type FullURL struct {
 	ID          int       `json:"id"`
 	OriginalURL string    `json:"original_url"`
 	ShortURL    string    `json:"short_url"`
+	Title       string    `json:"title,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
 	ExpiredAt   time.Time `json:"expired_at"`
 	IsCustom    bool      `json:"is_custom"`
 	Views       uint      `json:"views"`
+	WorkspaceID uint64    `json:"workspace_id"`
+	FolderID    uint64    `json:"folder_id,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Notes       string    `json:"notes,omitempty"`
+
+	Disabled       bool   `json:"disabled"`
+	DisabledReason string `json:"disabled_reason,omitempty"`
 }
 
 type URL struct {
-	OriginalURL string
-	ShortCode   string
+	ID           uint64
+	WorkspaceID  uint64
+	OriginalURL  string
+	ShortCode    string
+	DomainID     uint64
+	Title        string
+	Description  string
+	Interstitial bool
+	UserID       uint64
+	CreatedAt    time.Time
+	ExpiredAt    time.Time
+	Expired      bool
+
+	Disabled       bool
+	DisabledReason string
 }
 
 type DeleteURLRequest struct {
-	Code string `param:"code" validate:"required,len=6,alphanum"`
+	Code   string `param:"code" validate:"required,len=6,alphanum"`
+	Domain string `query:"domain"`
+	UserID int    `json:"-"`
 }
 
 type UpdateURLDurationReq struct {
 	Code      string    `param:"code" validate:"required,len=6,alphanum"`
+	Domain    string    `query:"domain"`
 	ExpiredAt time.Time `json:"expired_at" validate:"required,after"`
+	UserID    int       `json:"-"`
+}
+
+type QRCodeRequest struct {
+	Code       string `uri:"code"`
+	Domain     string `form:"domain"`
+	Format     string `form:"format" validate:"omitempty,oneof=png svg"`
+	Size       int    `form:"size" validate:"omitempty,min=64,max=2048"`
+	Level      string `form:"level" validate:"omitempty,oneof=L M Q H"`
+	Margin     *int   `form:"margin" validate:"omitempty,min=0,max=16"`
+	Foreground string `form:"fg" validate:"omitempty,len=6,hexadecimal"`
+	Background string `form:"bg" validate:"omitempty,len=6,hexadecimal"`
+}
+
+type QRCodeResponse struct {
+	ContentType string
+	Data        []byte
+}
+
+// PauseURLRequest 暂停、恢复共用
+type PauseURLRequest struct {
+	Code   string `param:"code"`
+	Domain string `query:"domain"`
+	Reason string `json:"reason,omitempty" validate:"omitempty,max=255"`
+	UserID int    `json:"-"`
+}
+
+type TrashedURL struct {
+	FullURL
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"` // 超过该时间后将被彻底删除，无法恢复
+}
+
+type GetTrashResponse struct {
+	Items []TrashedURL `json:"items"`
+}
+
+type RestoreURLRequest struct {
+	Code   string `param:"code"`
+	Domain string `query:"domain"`
+	UserID int    `json:"-"`
+}
+
+// UpdateURLDetailsRequest 修改标题、描述、备注和是否展示预览页，未传的字段保持不变
+type UpdateURLDetailsRequest struct {
+	Code         string  `param:"code"`
+	Domain       string  `query:"domain"`
+	Title        *string `json:"title,omitempty" validate:"omitempty,max=255"`
+	Description  *string `json:"description,omitempty" validate:"omitempty,max=1000"`
+	Notes        *string `json:"notes,omitempty" validate:"omitempty,max=10000"`
+	Interstitial *bool   `json:"interstitial,omitempty"`
+	UserID       int     `json:"-"`
 }
@@ -1,19 +1,29 @@
 package dto
 
+import "time"
+
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=8,max=20"`
+
+	IP string `json:"-"` // 用于按 IP 统计失败次数
 }
 
 type LoginResponse struct {
-	AccessToken string `json:"access_token"`
-	Email       string `json:"email"`
-	UserID      int    `json:"user_id"`
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int    `json:"expires_in,omitempty"` // access token 剩余秒数
+	Email        string `json:"email"`
+	UserID       int    `json:"user_id"`
+	// 开启两步验证时不返回 token，需用 challenge_token 和验证码调用 /api/auth/login/2fa
+	MFARequired    bool   `json:"mfa_required,omitempty"`
+	ChallengeToken string `json:"challenge_token,omitempty"`
 }
 
 type RegisterReqeust struct {
 	LoginRequest
 	EmailCode string `json:"email_code" validate:"required,len=6"`
+	Locale    string `json:"locale" validate:"omitempty,max=35"` // 邮件使用的语言，为空时取 Accept-Language
 }
 
 type ForgetPasswordReqeust struct {
@@ -22,5 +32,64 @@ type ForgetPasswordReqeust struct {
 }
 
 type SendCodeRequest struct {
-	Email string `validate:"required,email"`
+	Email  string `validate:"required,email"`
+	Locale string `json:"-"` // Accept-Language，邮箱未注册时使用
+}
+
+type SetLocaleRequest struct {
+	Locale string `json:"locale" validate:"required,max=35"` // 如 zh-CN、en
+	UserID int    `json:"-"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"` // 可选，一并吊销当前会话的 refresh token
+	All          bool   `json:"all"`           // 退出所有设备
+
+	UserID         int       `json:"-"`
+	TokenID        string    `json:"-"` // 当前 access token 的 jti
+	TokenExpiresAt time.Time `json:"-"`
+}
+
+// OIDCAuthResponse 跳转到 IdP 的地址。State 同时写入发起登录的浏览器的 cookie，
+// 只在回调地址下发送，Secure 为回调地址是否为 https
+type OIDCAuthResponse struct {
+	URL          string
+	State        string
+	CallbackPath string
+	Secure       bool
+	MaxAge       time.Duration
+}
+
+type OIDCCallbackRequest struct {
+	Provider    string `uri:"provider"`
+	Code        string `form:"code"`
+	State       string `form:"state"`
+	Error       string `form:"error"` // IdP 拒绝授权时返回
+	CookieState string `form:"-"`     // 发起登录时写入 cookie 的 state
+}
+
+type LoginTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required,max=32"` // TOTP 验证码或恢复码
+
+	IP string `json:"-"` // 输错同样计入账号和 IP 的失败次数
+}
+
+type TOTPEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth://，可直接生成二维码
+	QR              string `json:"qr"`               // data URI 形式的 PNG 二维码
+}
+
+type TOTPCodeRequest struct {
+	Code   string `json:"code" validate:"required,max=32"`
+	UserID int    `json:"-"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"` // 只展示这一次
 }
@@ -0,0 +1,82 @@
+package dto
+
+import "time"
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,http_url,max=2048"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=link.created link.updated link.deleted link.expired link.clicked link.expiring"`
+	UserID int      `json:"-"`
+}
+
+// UpdateWebhookRequest 未传的字段保持不变
+type UpdateWebhookRequest struct {
+	URL    *string  `json:"url,omitempty" validate:"omitempty,http_url,max=2048"`
+	Events []string `json:"events,omitempty" validate:"omitempty,min=1,dive,oneof=link.created link.updated link.deleted link.expired link.clicked link.expiring"`
+	Active *bool    `json:"active,omitempty"`
+	ID     uint64   `json:"-"`
+	UserID int      `json:"-"`
+}
+
+type WebhookRequest struct {
+	ID     uint64 `uri:"id"`
+	UserID int    `json:"-"`
+}
+
+type WebhookResponse struct {
+	ID        uint64    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateWebhookResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"` // 校验签名用，只在创建时返回一次
+}
+
+type GetWebhookDeliveriesRequest struct {
+	Status string `form:"status" validate:"omitempty,oneof=pending delivered dead"`
+	Page   int    `form:"page" validate:"omitempty,min=1"`
+	Size   int    `form:"size" validate:"omitempty,min=1,max=100"`
+	WebhookRequest
+}
+
+type WebhookDelivery struct {
+	ID             uint64     `json:"id"`
+	Event          string     `json:"event"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type GetWebhookDeliveriesResponse struct {
+	Items []WebhookDelivery `json:"items"`
+	Total int64             `json:"total"`
+}
+
+// WebhookEvent 推送的请求体，重放时 ID 不变，接收方可据此去重
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      WebhookLink `json:"data"`
+}
+
+type WebhookLink struct {
+	ID          uint64    `json:"id"`
+	ShortURL    string    `json:"short_url"`
+	ShortCode   string    `json:"short_code"`
+	OriginalURL string    `json:"original_url"`
+	WorkspaceID uint64    `json:"workspace_id"`
+	ExpiredAt   time.Time `json:"expired_at"`
+	Disabled    bool      `json:"disabled"`
+	Views       int32     `json:"views"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExtendURL   string    `json:"extend_url,omitempty"` // 只有 link.expiring 带
+}
@@ -0,0 +1,66 @@
+package dto
+
+import "time"
+
+type CreateWorkspaceRequest struct {
+	Name   string `json:"name" validate:"required,max=64"`
+	UserID int    `json:"-"`
+}
+
+type UpdateWorkspaceRequest struct {
+	Name   string `json:"name" validate:"required,max=64"`
+	ID     uint64 `json:"-"`
+	UserID int    `json:"-"`
+}
+
+type WorkspaceRequest struct {
+	ID     uint64 `uri:"id"`
+	UserID int    `json:"-"`
+}
+
+type WorkspaceResponse struct {
+	ID        uint64    `json:"id"`
+	Name      string    `json:"name"`
+	Personal  bool      `json:"personal"`
+	Role      string    `json:"role"` // 当前用户在该工作区的角色
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WorkspaceDetailResponse struct {
+	WorkspaceResponse
+	Members []WorkspaceMemberResponse `json:"members"`
+}
+
+type WorkspaceMemberResponse struct {
+	UserID   uint64    `json:"user_id"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type InviteMemberRequest struct {
+	Email  string `json:"email" validate:"required,email"`
+	Role   string `json:"role" validate:"required,oneof=owner editor viewer"`
+	ID     uint64 `json:"-"`
+	UserID int    `json:"-"`
+}
+
+type InviteResponse struct {
+	ID        uint64    `json:"id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type AcceptInviteRequest struct {
+	Token  string `json:"token" validate:"required"` // 邀请邮件中的 token
+	UserID int    `json:"-"`
+}
+
+// MemberRequest 修改成员角色时需要 Role，移除成员时忽略
+type MemberRequest struct {
+	Role     string `json:"role" validate:"omitempty,oneof=owner editor viewer"`
+	ID       uint64 `json:"-"`
+	MemberID uint64 `json:"-"`
+	UserID   int    `json:"-"`
+}
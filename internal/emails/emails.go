@@ -0,0 +1,237 @@
+package emails
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// 内置的邮件模板，每种语言一个目录，每封邮件一个 .txt（需定义 subject）和一个可选的 .html
+const (
+	Verification    = "verification"     // 注册验证码
+	PasswordReset   = "password_reset"   // 重置密码验证码
+	LinkExpiring    = "link_expiring"    // 短链接即将过期
+	SecurityAlert   = "security_alert"   // 账号安全提醒
+	WorkspaceInvite = "workspace_invite" // 工作区邀请
+)
+
+// 安全提醒的事件类型
+const (
+	EventAccountLocked   = "account_locked"
+	EventPasswordChanged = "password_changed"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+var textFuncs = texttemplate.FuncMap{
+	"date":    formatDate,
+	"minutes": minutes,
+}
+
+var htmlFuncs = htmltemplate.FuncMap{
+	"date":    formatDate,
+	"minutes": minutes,
+}
+
+func formatDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04 UTC")
+}
+
+func minutes(d time.Duration) int {
+	return int(d.Round(time.Minute).Minutes())
+}
+
+// CodeData 验证码邮件
+type CodeData struct {
+	Code    string
+	Minutes int // 有效期
+}
+
+// SecurityAlertData 安全提醒，Event 为 Event* 之一
+type SecurityAlertData struct {
+	Event    string
+	IP       string
+	Duration time.Duration // 锁定时长
+	Time     time.Time
+}
+
+// LinkExpiringData 短链接即将过期
+type LinkExpiringData struct {
+	ShortURL    string
+	OriginalURL string
+	ExpiresAt   time.Time
+	ExtendURL   string // 一键延期的链接，可为空
+}
+
+// WorkspaceInviteData 工作区邀请
+type WorkspaceInviteData struct {
+	Inviter   string
+	Workspace string
+	Role      string
+	BaseURL   string
+	Token     string
+	ExpiresAt time.Time
+}
+
+// Message 渲染后的邮件，HTML 为空时只发送纯文本
+type Message struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type template struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Templates 各语言的邮件模板
+type Templates struct {
+	templates     map[string]map[string]*template // 语言 -> 名字 -> 模板
+	locales       []string                        // 与 matcher 的顺序一致，第一个为默认语言
+	matcher       language.Matcher
+	defaultLocale string
+}
+
+// Load 解析内置模板，dir 不为空时用 dir/<语言>/<名字>.txt、.html 覆盖内置模板，也可以增加新的语言。
+// defaultLocale 为空时使用 en，找不到匹配的语言或模板时使用默认语言
+func Load(dir, defaultLocale string) (*Templates, error) {
+	if defaultLocale == "" {
+		defaultLocale = "en"
+	}
+	t := &Templates{
+		templates:     make(map[string]map[string]*template),
+		defaultLocale: defaultLocale,
+	}
+	sub, err := fs.Sub(templateFS, "templates")
+	if err != nil {
+		return nil, err
+	}
+	if err := t.parseDir(sub); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := t.parseDir(os.DirFS(dir)); err != nil {
+			return nil, err
+		}
+	}
+
+	defaults, ok := t.templates[defaultLocale]
+	if !ok {
+		return nil, fmt.Errorf("no email templates for default locale %q", defaultLocale)
+	}
+	for _, name := range []string{Verification, PasswordReset, LinkExpiring, SecurityAlert, WorkspaceInvite} {
+		if defaults[name] == nil || defaults[name].text == nil {
+			return nil, fmt.Errorf("email template %s/%s.txt missing", defaultLocale, name)
+		}
+	}
+
+	tags := []language.Tag{language.Make(defaultLocale)}
+	t.locales = []string{defaultLocale}
+	for locale := range t.templates {
+		if locale != defaultLocale {
+			tags = append(tags, language.Make(locale))
+			t.locales = append(t.locales, locale)
+		}
+	}
+	t.matcher = language.NewMatcher(tags)
+	return t, nil
+}
+
+// parseDir 解析 <语言>/<名字>.txt 和 <语言>/<名字>.html，同名模板覆盖之前的
+func (t *Templates) parseDir(fsys fs.FS) error {
+	return fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		locale, file := path.Split(p)
+		locale = strings.Trim(locale, "/")
+		ext := path.Ext(file)
+		if locale == "" || strings.Contains(locale, "/") || (ext != ".txt" && ext != ".html") {
+			return nil
+		}
+		src, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(file, ext)
+		if t.templates[locale] == nil {
+			t.templates[locale] = make(map[string]*template)
+		}
+		tmpl := t.templates[locale][name]
+		if tmpl == nil {
+			tmpl = &template{}
+			t.templates[locale][name] = tmpl
+		}
+		if ext == ".txt" {
+			tmpl.text, err = texttemplate.New(name).Funcs(textFuncs).Parse(string(src))
+			if err == nil && tmpl.text.Lookup("subject") == nil {
+				err = fmt.Errorf("subject not defined")
+			}
+		} else {
+			tmpl.html, err = htmltemplate.New(name).Funcs(htmlFuncs).Parse(string(src))
+		}
+		if err != nil {
+			return fmt.Errorf("email template %s: %w", filepath.FromSlash(p), err)
+		}
+		return nil
+	})
+}
+
+// Locale 按用户设置的语言或 Accept-Language 选出最接近的可用语言，依次尝试 prefs
+func (t *Templates) Locale(prefs ...string) string {
+	for _, pref := range prefs {
+		if pref == "" {
+			continue
+		}
+		tags, _, err := language.ParseAcceptLanguage(pref)
+		if err != nil || len(tags) == 0 {
+			continue
+		}
+		_, index, confidence := t.matcher.Match(tags...)
+		if confidence != language.No {
+			return t.locales[index]
+		}
+	}
+	return t.defaultLocale
+}
+
+// Render 渲染 locale 语言的模板，该语言没有这个模板时使用默认语言
+func (t *Templates) Render(locale, name string, data any) (*Message, error) {
+	tmpl := t.templates[t.Locale(locale)][name]
+	if tmpl == nil || tmpl.text == nil {
+		tmpl = t.templates[t.defaultLocale][name]
+	}
+	if tmpl == nil || tmpl.text == nil {
+		return nil, fmt.Errorf("no such email template %q", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("render %s subject: %w", name, err)
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("render %s: %w", name, err)
+	}
+	if tmpl.html != nil {
+		if err := tmpl.html.Execute(&html, data); err != nil {
+			return nil, fmt.Errorf("render %s html: %w", name, err)
+		}
+	}
+	return &Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
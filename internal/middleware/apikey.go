@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jekyulll/url_shortener/internal/model"
+	"github.com/jekyulll/url_shortener/internal/service"
+)
+
+// APIKeyChecker 校验 API Key 并检查 scope
+type APIKeyChecker interface {
+	Authenticate(ctx context.Context, raw, scope string) (userID int, keyID uint64, err error)
+}
+
+// APIKeyAuther 接受 "Authorization: ApiKey <key>" 或 "X-API-Key: <key>"，
+// 请求中没有 API Key 时交给 fallback（通常是 JWTAuther）处理。
+// GET/HEAD 需要 read 权限，其余方法需要 write 权限
+func APIKeyAuther(checker APIKeyChecker, fallback gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := apiKeyFrom(c)
+		if key == "" {
+			fallback(c)
+			return
+		}
+
+		scope := model.ScopeWrite
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			scope = model.ScopeRead
+		}
+		userID, keyID, err := checker.Authenticate(c.Request.Context(), key, scope)
+		if err != nil {
+			status := http.StatusUnauthorized
+			if errors.Is(err, service.ErrAPIKeyScopeDenied) {
+				status = http.StatusForbidden
+			}
+			c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set("userID", userID)
+		c.Set("apiKeyID", keyID)
+
+		c.Next()
+	}
+}
+
+func apiKeyFrom(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	parts := strings.Split(c.GetHeader("Authorization"), " ")
+	if len(parts) == 2 && parts[0] == "ApiKey" {
+		return parts[1]
+	}
+	return ""
+}
+
+var _ APIKeyChecker = (*service.APIKeyService)(nil)
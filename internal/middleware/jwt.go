@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
@@ -8,7 +9,12 @@ import (
 	"github.com/jekyulll/url_shortener/pkg/jwt"
 )
 
-func JWTAuther(jwt *jwt.JWT) gin.HandlerFunc {
+// TokenChecker 检查 token 是否已被吊销（登出、重置密码等）
+type TokenChecker interface {
+	CheckToken(ctx context.Context, userID int, version int64, tokenID string) error
+}
+
+func JWTAuther(jwt *jwt.JWT, checker TokenChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从请求头中获取Authorization字段
 		authHeader := c.GetHeader("Authorization")
@@ -32,9 +38,17 @@ func JWTAuther(jwt *jwt.JWT) gin.HandlerFunc {
 			})
 			return
 		}
+		// 签名有效但可能已被吊销
+		if err := checker.CheckToken(c.Request.Context(), claims.UserID, claims.Version, claims.ID); err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
 
 		c.Set("email", claims.Email)
 		c.Set("userID", claims.UserID)
+		c.Set("role", claims.Role)
+		c.Set("tokenID", claims.ID)
+		c.Set("tokenExpiresAt", claims.ExpiresAt.Time)
 
 		c.Next()
 	}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jekyulll/url_shortener/internal/model"
+)
+
+// RequireRole 要求角色不低于 min，需放在 JWTAuther 之后
+func RequireRole(min string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !model.RoleAtLeast(c.GetString("role"), min) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+			return
+		}
+		c.Next()
+	}
+}
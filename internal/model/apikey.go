@@ -0,0 +1,47 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// APIKey 用户的个人 API Key，用于 CI 等程序化调用，只保存哈希
+type APIKey struct {
+	ID         uint64     `gorm:"column:id;primaryKey;autoIncrement"`
+	UserID     uint64     `gorm:"column:user_id;not null;index"`
+	Name       string     `gorm:"column:name;type:varchar(64);not null"`
+	Prefix     string     `gorm:"column:prefix;type:varchar(16);not null"` // 明文前几位，便于用户辨认
+	KeyHash    string     `gorm:"column:key_hash;type:char(64);not null;uniqueIndex"`
+	Scopes     string     `gorm:"column:scopes;type:varchar(64);not null"` // 逗号分隔，如 "read,write"
+	ExpiresAt  *time.Time `gorm:"column:expires_at;type:timestamp"`        // 为空则永不过期
+	RevokedAt  *time.Time `gorm:"column:revoked_at;type:timestamp"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at;type:timestamp"`
+	CreatedAt  time.Time  `gorm:"column:created_at;type:timestamp;not null;autoCreateTime"`
+}
+
+func (k *APIKey) TableName() string {
+	return "api_keys"
+}
+
+func (k *APIKey) ScopeList() []string {
+	return strings.Split(k.Scopes, ",")
+}
+
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Usable 未吊销且未过期
+func (k *APIKey) Usable() bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || time.Now().Before(*k.ExpiresAt))
+}
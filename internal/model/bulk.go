@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+// 批量创建任务的状态
+const (
+	BulkJobPending = "pending"
+	BulkJobRunning = "running"
+	BulkJobDone    = "done"
+	BulkJobFailed  = "failed" // 写库一直失败，剩余的行不再处理
+)
+
+// BulkJob 异步批量创建短链接的任务，由后台任务按批处理，Processed 为已处理的行数
+type BulkJob struct {
+	ID          uint64     `gorm:"column:id;primaryKey;autoIncrement"`
+	UserID      uint64     `gorm:"column:user_id;not null;index"`
+	WorkspaceID uint64     `gorm:"column:workspace_id;not null;default:0"`
+	Domain      string     `gorm:"column:domain;type:varchar(255);not null;default:''"`
+	Status      string     `gorm:"column:status;type:varchar(16);not null;default:pending;index"`
+	Total       int        `gorm:"column:total;not null;default:0"`
+	Processed   int        `gorm:"column:processed;not null;default:0"`
+	Succeeded   int        `gorm:"column:succeeded;not null;default:0"`
+	Failed      int        `gorm:"column:failed;not null;default:0"`
+	Attempts    int        `gorm:"column:attempts;not null;default:0"` // 当前这一批连续保存失败的次数
+	FinishedAt  *time.Time `gorm:"column:finished_at;type:timestamp"`
+	CreatedAt   time.Time  `gorm:"column:created_at;type:timestamp;not null;autoCreateTime"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at;type:timestamp;not null;autoUpdateTime"`
+}
+
+func (j *BulkJob) TableName() string {
+	return "bulk_jobs"
+}
+
+// BulkJobRow 任务中的一行，创建时保存输入，处理后写入结果
+type BulkJobRow struct {
+	ID          uint64 `gorm:"column:id;primaryKey;autoIncrement"`
+	JobID       uint64 `gorm:"column:job_id;not null;uniqueIndex:idx_bulk_job_rows_job_row,priority:1"`
+	Row         int    `gorm:"column:row_num;not null;uniqueIndex:idx_bulk_job_rows_job_row,priority:2"` // 从 1 开始
+	OriginalURL string `gorm:"column:original_url;type:text;not null"`
+	CustomCode  string `gorm:"column:custom_code;type:varchar(100);not null;default:''"`
+	Duration    *int   `gorm:"column:duration"`
+	ShortURL    string `gorm:"column:short_url;type:varchar(512);not null;default:''"`
+	Error       string `gorm:"column:error;type:varchar(255);not null;default:''"`
+}
+
+func (r *BulkJobRow) TableName() string {
+	return "bulk_job_rows"
+}
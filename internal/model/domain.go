@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// Domain 用户绑定的自定义域名，每个域名拥有独立的短码空间
+type Domain struct {
+	ID          uint64     `gorm:"column:id;primaryKey;autoIncrement"`
+	UserID      uint64     `gorm:"column:user_id;not null;index"`
+	Host        string     `gorm:"column:host;type:varchar(255);not null;index"`  // 多个用户可以申请同一域名，只有一个能验证通过
+	VerifyToken string     `gorm:"column:verify_token;type:varchar(64);not null"` // DNS TXT 记录中需要包含的值
+	Verified    bool       `gorm:"column:verified;not null;default:false"`
+	VerifiedAt  *time.Time `gorm:"column:verified_at;type:timestamp"`
+	CreatedAt   time.Time  `gorm:"column:created_at;type:timestamp;not null;autoCreateTime"`
+}
+
+func (d *Domain) TableName() string {
+	return "domains"
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// 待发送邮件的状态
+const (
+	EmailPending = "pending"
+	EmailSent    = "sent"
+	EmailDead    = "dead" // 重试次数用完，不再自动投递
+)
+
+// OutboxEmail 待发送的邮件，由后台任务投递，失败后按指数退避重试
+type OutboxEmail struct {
+	ID            uint64     `gorm:"column:id;primaryKey;autoIncrement"`
+	Recipient     string     `gorm:"column:recipient;type:varchar(255);not null"`
+	Subject       string     `gorm:"column:subject;type:varchar(255);not null"`
+	Body          string     `gorm:"column:body;type:text;not null"`
+	HTML          string     `gorm:"column:html;type:text;not null"` // 为空时只发送纯文本
+	Status        string     `gorm:"column:status;type:varchar(16);not null;default:pending;index:idx_email_outbox_status_next,priority:1"`
+	Attempts      int        `gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;type:timestamp;not null;index:idx_email_outbox_status_next,priority:2"`
+	LastError     string     `gorm:"column:last_error;type:varchar(512);not null;default:''"`
+	SentAt        *time.Time `gorm:"column:sent_at;type:timestamp"`
+	CreatedAt     time.Time  `gorm:"column:created_at;type:timestamp;not null;autoCreateTime"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at;type:timestamp;not null;autoUpdateTime"`
+}
+
+func (e *OutboxEmail) TableName() string {
+	return "email_outbox"
+}
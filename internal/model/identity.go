@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// UserIdentity 外部身份提供方（OIDC）账号与本地用户的绑定关系
+type UserIdentity struct {
+	ID        uint64    `gorm:"column:id;primaryKey;autoIncrement"`
+	UserID    uint64    `gorm:"column:user_id;not null;index"`
+	Provider  string    `gorm:"column:provider;type:varchar(64);not null;uniqueIndex:idx_provider_subject"`
+	Subject   string    `gorm:"column:subject;type:varchar(255);not null;uniqueIndex:idx_provider_subject"` // IdP 中的 sub
+	Email     string    `gorm:"column:email;type:varchar(255);not null"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamp;not null;autoCreateTime"`
+}
+
+func (i *UserIdentity) TableName() string {
+	return "user_identities"
+}
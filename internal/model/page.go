@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// 落地页类型
+const (
+	PageNotFound = "not_found"
+	PageExpired  = "expired"
+	PageDisabled = "disabled"
+)
+
+// LandingPage 用户自定义的落地页模板
+// DomainID 为 0 时对该用户名下所有短链接生效，否则只对该域名生效
+type LandingPage struct {
+	ID        uint64    `gorm:"column:id;primaryKey;autoIncrement"`
+	UserID    uint64    `gorm:"column:user_id;not null;uniqueIndex:idx_user_domain_kind"`
+	DomainID  uint64    `gorm:"column:domain_id;not null;default:0;uniqueIndex:idx_user_domain_kind"`
+	Kind      string    `gorm:"column:kind;type:varchar(20);not null;uniqueIndex:idx_user_domain_kind"`
+	Template  string    `gorm:"column:template;type:text;not null"`
+	UpdatedAt time.Time `gorm:"column:updated_at;type:timestamp;not null;autoUpdateTime"`
+}
+
+func (p *LandingPage) TableName() string {
+	return "landing_pages"
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// RecoveryCode 两步验证的恢复码，只保存哈希，每个只能使用一次
+type RecoveryCode struct {
+	ID        uint64     `gorm:"column:id;primaryKey;autoIncrement"`
+	UserID    uint64     `gorm:"column:user_id;not null;index"`
+	CodeHash  string     `gorm:"column:code_hash;type:char(64);not null"`
+	UsedAt    *time.Time `gorm:"column:used_at;type:timestamp"`
+	CreatedAt time.Time  `gorm:"column:created_at;type:timestamp;not null;autoCreateTime"`
+}
+
+func (r *RecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// Tag 工作区内的标签，短链接与标签多对多
+type Tag struct {
+	ID          uint64    `gorm:"column:id;primaryKey;autoIncrement"`
+	WorkspaceID uint64    `gorm:"column:workspace_id;not null;uniqueIndex:idx_tags_workspace_name"`
+	Name        string    `gorm:"column:name;type:varchar(32);not null;uniqueIndex:idx_tags_workspace_name"`
+	Color       string    `gorm:"column:color;type:varchar(7);not null;default:''"` // 如 #1e90ff，为空时由前端决定
+	CreatedAt   time.Time `gorm:"column:created_at;type:timestamp;not null;autoCreateTime"`
+}
+
+func (t *Tag) TableName() string {
+	return "tags"
+}
+
+// URLTag 短链接与标签的关联
+type URLTag struct {
+	URLID uint64 `gorm:"column:url_id;primaryKey"`
+	TagID uint64 `gorm:"column:tag_id;primaryKey;index"`
+}
+
+func (t *URLTag) TableName() string {
+	return "url_tags"
+}
+
+// Folder 工作区内的文件夹，可以嵌套，每个短链接最多属于一个文件夹
+type Folder struct {
+	ID          uint64    `gorm:"column:id;primaryKey;autoIncrement"`
+	WorkspaceID uint64    `gorm:"column:workspace_id;not null;uniqueIndex:idx_folders_parent_name"`
+	ParentID    uint64    `gorm:"column:parent_id;not null;default:0;uniqueIndex:idx_folders_parent_name"` // 0 表示位于根目录
+	Name        string    `gorm:"column:name;type:varchar(64);not null;uniqueIndex:idx_folders_parent_name"`
+	CreatedAt   time.Time `gorm:"column:created_at;type:timestamp;not null;autoCreateTime"`
+}
+
+func (f *Folder) TableName() string {
+	return "folders"
+}
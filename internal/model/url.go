@@ -1,6 +1,12 @@
 package model
 
-import "time"
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // type URL struct {
 // 	ID          uint64    `gorm:"column:id;primaryKey;autoIncrement"`
@@ -13,15 +19,36 @@ import "time"
 // }
 
 type URL struct {
-	ID          uint64    `gorm:"column:id;primaryKey;autoIncrement"`
-	UserID      uint64    `gorm:"column:user_id;not null"` // 新增：关联用户ID
-	OriginalURL string    `gorm:"column:original_url;type:text;not null"`
-	ShortCode   string    `gorm:"column:short_code;type:varchar(100);not null;uniqueIndex"`
-	IsCustom    bool      `gorm:"column:is_custom;not null;default:false"`
-	Views       int32     `gorm:"column:views;not null;default:0"` // 修改：添加默认值0
-	ExpiredAt   time.Time `gorm:"column:expired_at;type:timestamp;not null"`
-	CreatedAt   time.Time `gorm:"column:created_at;type:timestamp;not null;autoCreateTime"`
-	User        *User     `gorm:"foreignKey:UserID"` // 新增：关联用户对象
+	ID          uint64     `gorm:"column:id;primaryKey;autoIncrement"`
+	UserID      uint64     `gorm:"column:user_id;not null"`                                               // 新增：关联用户ID
+	WorkspaceID uint64     `gorm:"column:workspace_id;not null;default:0;index"`                          // 所属工作区，UserID 为创建者
+	DomainID    uint64     `gorm:"column:domain_id;not null;default:0;uniqueIndex:idx_domain_short_code"` // 0 表示默认域名
+	FolderID    uint64     `gorm:"column:folder_id;not null;default:0"`                                   // 0 表示不在任何文件夹中
+	OriginalURL string     `gorm:"column:original_url;type:text;not null"`
+	ShortCode   string     `gorm:"column:short_code;type:varchar(100);not null;uniqueIndex:idx_domain_short_code"` // 每个域名下唯一
+	IsCustom    bool       `gorm:"column:is_custom;not null;default:false"`
+	Views       int32      `gorm:"column:views;not null;default:0"` // 修改：添加默认值0
+	ExpiredAt   time.Time  `gorm:"column:expired_at;type:timestamp;not null"`
+	RemindedAt  *time.Time `gorm:"column:reminded_at;type:timestamp"` // 已发送即将过期的提醒，修改有效期后清空
+	CreatedAt   time.Time  `gorm:"column:created_at;type:timestamp;not null;autoCreateTime"`
+	User        *User      `gorm:"foreignKey:UserID"` // 新增：关联用户对象
+
+	// 预览页（中间页）相关
+	Title        string `gorm:"column:title;type:varchar(255);not null;default:''"`
+	Description  string `gorm:"column:description;type:text"`
+	Interstitial bool   `gorm:"column:interstitial;not null;default:false"` // 访问时总是先展示预览页
+
+	// 整理：备注只有工作区成员可见，不出现在预览页
+	Notes string `gorm:"column:notes;type:text"`
+	Tags  []Tag  `gorm:"many2many:url_tags"`
+
+	// 暂停（停用）后保留统计数据和短码，恢复后继续可用
+	Disabled       bool       `gorm:"column:disabled;not null;default:false"`
+	DisabledReason string     `gorm:"column:disabled_reason;type:varchar(255);not null;default:''"`
+	DisabledAt     *time.Time `gorm:"column:disabled_at;type:timestamp"`
+
+	// 软删除：删除后进入回收站，保留期内短码仍被占用，可以恢复
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at;type:timestamp;index"`
 }
 
 func (u *URL) TableName() string {
@@ -35,3 +62,29 @@ func (u *URL) IsExpired() bool {
 func (u *URL) Renew(duration time.Duration) {
 	u.ExpiredAt = time.Now().Add(duration)
 }
+
+// Key 返回短链接在布隆过滤器、缓存中使用的键
+func (u *URL) Key() string {
+	return URLKey(u.DomainID, u.ShortCode)
+}
+
+// URLKey 默认域名下的键就是短码本身，自定义域名下为 "域名ID/短码"
+func URLKey(domainID uint64, shortCode string) string {
+	if domainID == 0 {
+		return shortCode
+	}
+	return strconv.FormatUint(domainID, 10) + "/" + shortCode
+}
+
+// ParseURLKey 是 URLKey 的逆操作
+func ParseURLKey(key string) (uint64, string) {
+	prefix, code, found := strings.Cut(key, "/")
+	if !found {
+		return 0, key
+	}
+	domainID, err := strconv.ParseUint(prefix, 10, 64)
+	if err != nil {
+		return 0, key
+	}
+	return domainID, code
+}
@@ -2,6 +2,23 @@ package model
 
 import "time"
 
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator" // 可查看所有用户和短链接、强制过期短链接
+	RoleAdmin     = "admin"     // 另可停用账号、修改角色、转移短链接
+)
+
+var roleRank = map[string]int{
+	RoleUser:      0,
+	RoleModerator: 1,
+	RoleAdmin:     2,
+}
+
+// RoleAtLeast 角色是否不低于 min，未知角色按普通用户处理
+func RoleAtLeast(role, min string) bool {
+	return roleRank[role] >= roleRank[min]
+}
+
 // type User struct {
 // 	ID           int32     `json:"id"`
 // 	Email        string    `json:"email"`
@@ -11,12 +28,20 @@ import "time"
 // }
 
 type User struct {
-	ID           uint64    `gorm:"column:id;primaryKey;autoIncrement"`
-	Email        string    `gorm:"column:email;type:varchar(255);not null;uniqueIndex"`
-	PasswordHash string    `gorm:"column:password_hash;type:text;not null"`
-	CreatedAt    time.Time `gorm:"column:created_at;type:timestamp;not null;autoCreateTime"`
-	UpdatedAt    time.Time `gorm:"column:updated_at;type:timestamp;not null;autoUpdateTime"`
-	URLs         []URL     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"` // 用户创建的所有URL
+	ID           uint64 `gorm:"column:id;primaryKey;autoIncrement"`
+	Email        string `gorm:"column:email;type:varchar(255);not null;uniqueIndex"`
+	PasswordHash string `gorm:"column:password_hash;type:text;not null"`
+	TOTPSecret   string `gorm:"column:totp_secret;type:varchar(64);not null;default:''"` // 开启前为待验证的密钥
+	TOTPEnabled  bool   `gorm:"column:totp_enabled;not null;default:false"`
+	Role         string `gorm:"column:role;type:varchar(16);not null;default:user"`
+	Disabled     bool   `gorm:"column:disabled;not null;default:false"`             // 被管理员停用后无法登录
+	TokenVersion int64  `gorm:"column:token_version;not null;default:0"`            // 加一使已签发的 token 全部失效，Redis 中为缓存
+	Locale       string `gorm:"column:locale;type:varchar(35);not null;default:''"` // 邮件使用的语言（BCP 47），为空时按请求的 Accept-Language
+	// 短链接即将过期时发邮件提醒创建者，webhook 通知通过订阅 link.expiring 事件
+	ExpiryReminders bool      `gorm:"column:expiry_reminders;not null;default:true"`
+	CreatedAt       time.Time `gorm:"column:created_at;type:timestamp;not null;autoCreateTime"`
+	UpdatedAt       time.Time `gorm:"column:updated_at;type:timestamp;not null;autoUpdateTime"`
+	URLs            []URL     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"` // 用户创建的所有URL
 }
 
 func (u *User) TableName() string {
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// 可订阅的短链接事件
+const (
+	EventLinkCreated  = "link.created"
+	EventLinkUpdated  = "link.updated"
+	EventLinkDeleted  = "link.deleted"
+	EventLinkExpired  = "link.expired"
+	EventLinkClicked  = "link.clicked"  // 按配置的比例采样
+	EventLinkExpiring = "link.expiring" // 过期提醒发出时，带一键延期链接
+)
+
+// webhook 投递的状态
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookDead      = "dead" // 重试次数用完，不再自动投递
+)
+
+// WebhookSubscription 用户订阅的 webhook，创建者的短链接发生事件时推送
+type WebhookSubscription struct {
+	ID        uint64    `gorm:"column:id;primaryKey;autoIncrement"`
+	UserID    uint64    `gorm:"column:user_id;not null;index"`
+	URL       string    `gorm:"column:url;type:varchar(2048);not null"`
+	Secret    string    `gorm:"column:secret;type:varchar(64);not null"`  // 签名用，需要明文保存
+	Events    string    `gorm:"column:events;type:varchar(255);not null"` // 逗号分隔，如 "link.created,link.deleted"
+	Active    bool      `gorm:"column:active;not null;default:true"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamp;not null;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;type:timestamp;not null;autoUpdateTime"`
+}
+
+func (w *WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+func (w *WebhookSubscription) EventList() []string {
+	return strings.Split(w.Events, ",")
+}
+
+func (w *WebhookSubscription) Subscribed(event string) bool {
+	return slices.Contains(w.EventList(), event)
+}
+
+// WebhookDelivery 一次事件推送，同时作为投递日志，失败后按指数退避重试
+type WebhookDelivery struct {
+	ID             uint64     `gorm:"column:id;primaryKey;autoIncrement"`
+	SubscriptionID uint64     `gorm:"column:subscription_id;not null;index"`
+	Event          string     `gorm:"column:event;type:varchar(32);not null"`
+	Payload        string     `gorm:"column:payload;type:text;not null"` // 请求体，重放时原样发送
+	Status         string     `gorm:"column:status;type:varchar(16);not null;default:pending;index:idx_webhook_deliveries_status_next,priority:1"`
+	Attempts       int        `gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt  time.Time  `gorm:"column:next_attempt_at;type:timestamp;not null;index:idx_webhook_deliveries_status_next,priority:2"`
+	ResponseStatus int        `gorm:"column:response_status;not null;default:0"` // 最近一次的 HTTP 状态码，请求失败时为 0
+	LastError      string     `gorm:"column:last_error;type:varchar(512);not null;default:''"`
+	DeliveredAt    *time.Time `gorm:"column:delivered_at;type:timestamp"`
+	CreatedAt      time.Time  `gorm:"column:created_at;type:timestamp;not null;autoCreateTime"`
+	UpdatedAt      time.Time  `gorm:"column:updated_at;type:timestamp;not null;autoUpdateTime"`
+}
+
+func (d *WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
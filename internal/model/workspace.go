@@ -0,0 +1,77 @@
+package model
+
+import "time"
+
+const (
+	WorkspaceViewer = "viewer" // 只能查看短链接
+	WorkspaceEditor = "editor" // 另可创建、修改和删除短链接
+	WorkspaceOwner  = "owner"  // 另可管理成员、邀请和工作区本身
+)
+
+// PersonalWorkspaceName 自动创建的个人工作区名称
+const PersonalWorkspaceName = "Personal"
+
+var workspaceRoleRank = map[string]int{
+	WorkspaceViewer: 1,
+	WorkspaceEditor: 2,
+	WorkspaceOwner:  3,
+}
+
+// WorkspaceRoleAtLeast 工作区角色是否不低于 min，空或未知角色视为非成员
+func WorkspaceRoleAtLeast(role, min string) bool {
+	rank := workspaceRoleRank[role]
+	return rank > 0 && rank >= workspaceRoleRank[min]
+}
+
+// Workspace 短链接归属的工作区，每个用户有一个个人工作区，也可以创建团队工作区共享管理
+type Workspace struct {
+	ID             uint64    `gorm:"column:id;primaryKey;autoIncrement"`
+	Name           string    `gorm:"column:name;type:varchar(64);not null"`
+	PersonalUserID *uint64   `gorm:"column:personal_user_id;uniqueIndex"` // 个人工作区所属用户，团队工作区为空
+	CreatedAt      time.Time `gorm:"column:created_at;type:timestamp;not null;autoCreateTime"`
+	UpdatedAt      time.Time `gorm:"column:updated_at;type:timestamp;not null;autoUpdateTime"`
+}
+
+func (w *Workspace) TableName() string {
+	return "workspaces"
+}
+
+func (w *Workspace) Personal() bool {
+	return w.PersonalUserID != nil
+}
+
+type WorkspaceMember struct {
+	WorkspaceID uint64     `gorm:"column:workspace_id;primaryKey"`
+	UserID      uint64     `gorm:"column:user_id;primaryKey;index"`
+	Role        string     `gorm:"column:role;type:varchar(16);not null"`
+	CreatedAt   time.Time  `gorm:"column:created_at;type:timestamp;not null;autoCreateTime"`
+	Workspace   *Workspace `gorm:"foreignKey:WorkspaceID"`
+	User        *User      `gorm:"foreignKey:UserID"`
+}
+
+func (m *WorkspaceMember) TableName() string {
+	return "workspace_members"
+}
+
+// WorkspaceInvite 发往邮箱的邀请，只保存 token 的哈希，接受后即失效
+type WorkspaceInvite struct {
+	ID          uint64     `gorm:"column:id;primaryKey;autoIncrement"`
+	WorkspaceID uint64     `gorm:"column:workspace_id;not null;index"`
+	Email       string     `gorm:"column:email;type:varchar(255);not null"`
+	Role        string     `gorm:"column:role;type:varchar(16);not null"`
+	TokenHash   string     `gorm:"column:token_hash;type:char(64);not null;uniqueIndex"`
+	InvitedBy   uint64     `gorm:"column:invited_by;not null"`
+	ExpiresAt   time.Time  `gorm:"column:expires_at;type:timestamp;not null"`
+	AcceptedAt  *time.Time `gorm:"column:accepted_at;type:timestamp"`
+	CreatedAt   time.Time  `gorm:"column:created_at;type:timestamp;not null;autoCreateTime"`
+	Workspace   *Workspace `gorm:"foreignKey:WorkspaceID"`
+}
+
+func (i *WorkspaceInvite) TableName() string {
+	return "workspace_invites"
+}
+
+// Pending 未接受且未过期
+func (i *WorkspaceInvite) Pending() bool {
+	return i.AcceptedAt == nil && time.Now().Before(i.ExpiresAt)
+}
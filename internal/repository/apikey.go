@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jekyulll/url_shortener/internal/model"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *model.APIKey) error
+	GetAPIKeysByUserID(ctx context.Context, userID uint64) ([]model.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (*model.APIKey, error)
+	UpdateAPIKeyName(ctx context.Context, userID, id uint64, name string) error
+	RevokeAPIKey(ctx context.Context, userID, id uint64) error
+	TouchAPIKey(ctx context.Context, id uint64, usedAt time.Time) error
+}
+
+type apiKeyRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepo(db *gorm.DB) *apiKeyRepositoryImpl {
+	return &apiKeyRepositoryImpl{
+		db: db,
+	}
+}
+
+// CreateAPIKey implements APIKeyRepository.
+func (r *apiKeyRepositoryImpl) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// GetAPIKeysByUserID implements APIKeyRepository.
+func (r *apiKeyRepositoryImpl) GetAPIKeysByUserID(ctx context.Context, userID uint64) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// GetAPIKeyByHash implements APIKeyRepository.
+// 找不到时返回 nil, nil
+func (r *apiKeyRepositoryImpl) GetAPIKeyByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ?", hash).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &key, err
+}
+
+// UpdateAPIKeyName implements APIKeyRepository.
+// 不属于该用户的 key 返回 gorm.ErrRecordNotFound
+func (r *apiKeyRepositoryImpl) UpdateAPIKeyName(ctx context.Context, userID, id uint64, name string) error {
+	var key model.APIKey
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&key).Error
+	if err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Model(&key).Update("name", name).Error
+}
+
+// RevokeAPIKey implements APIKeyRepository.
+// 不属于该用户或已吊销的 key 返回 gorm.ErrRecordNotFound
+func (r *apiKeyRepositoryImpl) RevokeAPIKey(ctx context.Context, userID, id uint64) error {
+	result := r.db.WithContext(ctx).
+		Model(&model.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// TouchAPIKey implements APIKeyRepository.
+func (r *apiKeyRepositoryImpl) TouchAPIKey(ctx context.Context, id uint64, usedAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.APIKey{}).
+		Where("id = ?", id).
+		Update("last_used_at", usedAt).Error
+}
+
+var _ APIKeyRepository = (*apiKeyRepositoryImpl)(nil)
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jekyulll/url_shortener/internal/model"
+	"gorm.io/gorm"
+)
+
+// 创建任务时每次插入的行数
+const bulkJobRowsInsertBatch = 1000
+
+type BulkJobRepository interface {
+	CreateJob(ctx context.Context, job *model.BulkJob, rows []model.BulkJobRow) error
+	GetJob(ctx context.Context, userID, id uint64) (*model.BulkJob, error)
+	GetNextJob(ctx context.Context) (*model.BulkJob, error)
+	GetJobRows(ctx context.Context, jobID uint64, afterRow, limit int) ([]model.BulkJobRow, error)
+	GetJobResults(ctx context.Context, jobID uint64, failedOnly bool, limit, offset int) ([]model.BulkJobRow, int64, error)
+	SaveJobBatch(ctx context.Context, job *model.BulkJob, rows []model.BulkJobRow, urls []*model.URL) error
+	UpdateJobStatus(ctx context.Context, job *model.BulkJob) error
+	DeleteJobs(ctx context.Context, finishedBefore time.Time) error
+}
+
+type bulkJobRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewBulkJobRepo(db *gorm.DB) *bulkJobRepositoryImpl {
+	return &bulkJobRepositoryImpl{
+		db: db,
+	}
+}
+
+// CreateJob implements BulkJobRepository.
+// 任务和所有输入行在一个事务中写入
+func (r *bulkJobRepositoryImpl) CreateJob(ctx context.Context, job *model.BulkJob, rows []model.BulkJobRow) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+		for i := range rows {
+			rows[i].JobID = job.ID
+		}
+		return tx.CreateInBatches(rows, bulkJobRowsInsertBatch).Error
+	})
+}
+
+// GetJob implements BulkJobRepository.
+// 不存在或不属于该用户时返回 nil, nil
+func (r *bulkJobRepositoryImpl) GetJob(ctx context.Context, userID, id uint64) (*model.BulkJob, error) {
+	var job model.BulkJob
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &job, err
+}
+
+// GetNextJob implements BulkJobRepository.
+// 最早的未完成任务，包括上次未处理完的，没有时返回 nil, nil。
+// 保存失败过的任务排在后面，不会一直挡住其他任务
+func (r *bulkJobRepositoryImpl) GetNextJob(ctx context.Context) (*model.BulkJob, error) {
+	var job model.BulkJob
+	err := r.db.WithContext(ctx).
+		Where("status IN ?", []string{model.BulkJobPending, model.BulkJobRunning}).
+		Order("attempts, id").
+		First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &job, err
+}
+
+// GetJobRows implements BulkJobRepository.
+// 行号大于 afterRow 的输入行，按行号排序
+func (r *bulkJobRepositoryImpl) GetJobRows(ctx context.Context, jobID uint64, afterRow, limit int) ([]model.BulkJobRow, error) {
+	var rows []model.BulkJobRow
+	err := r.db.WithContext(ctx).
+		Where("job_id = ? AND row_num > ?", jobID, afterRow).
+		Order("row_num").
+		Limit(limit).
+		Find(&rows).Error
+	return rows, err
+}
+
+// GetJobResults implements BulkJobRepository.
+// failedOnly 时只返回出错的行
+func (r *bulkJobRepositoryImpl) GetJobResults(ctx context.Context, jobID uint64, failedOnly bool, limit, offset int) ([]model.BulkJobRow, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.BulkJobRow{}).Where("job_id = ?", jobID)
+	if failedOnly {
+		query = query.Where("error <> ''")
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []model.BulkJobRow
+	err := query.Order("row_num").Limit(limit).Offset(offset).Find(&rows).Error
+	return rows, total, err
+}
+
+// SaveJobBatch implements BulkJobRepository.
+// 在一个事务中写入这批创建的短链接、每行的结果和任务进度，中断后从 Processed 之后继续
+func (r *bulkJobRepositoryImpl) SaveJobBatch(ctx context.Context, job *model.BulkJob, rows []model.BulkJobRow, urls []*model.URL) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(urls) > 0 {
+			if err := upsertURLs(tx, urls); err != nil {
+				return err
+			}
+		}
+		for i := range rows {
+			err := tx.Model(&rows[i]).
+				Select("short_url", "error").
+				Updates(&rows[i]).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Model(job).
+			Select("status", "processed", "succeeded", "failed", "attempts", "finished_at").
+			Updates(job).Error
+	})
+}
+
+// UpdateJobStatus implements BulkJobRepository.
+// 只更新状态和失败次数，进度不变
+func (r *bulkJobRepositoryImpl) UpdateJobStatus(ctx context.Context, job *model.BulkJob) error {
+	return r.db.WithContext(ctx).
+		Model(job).
+		Select("status", "attempts", "finished_at").
+		Updates(job).Error
+}
+
+// DeleteJobs implements BulkJobRepository.
+// 输入行随外键一并删除
+func (r *bulkJobRepositoryImpl) DeleteJobs(ctx context.Context, finishedBefore time.Time) error {
+	return r.db.WithContext(ctx).
+		Where("status IN ? AND finished_at < ?", []string{model.BulkJobDone, model.BulkJobFailed}, finishedBefore).
+		Delete(&model.BulkJob{}).Error
+}
+
+var _ BulkJobRepository = (*bulkJobRepositoryImpl)(nil)
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jekyulll/url_shortener/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DomainRepository interface {
+	CreateDomain(ctx context.Context, domain *model.Domain) error
+	GetDomainByID(ctx context.Context, id uint64) (*model.Domain, error)
+	GetVerifiedDomainByHost(ctx context.Context, host string) (*model.Domain, error)
+	GetUserDomainByHost(ctx context.Context, userID uint64, host string) (*model.Domain, error)
+	GetDomainsByIDs(ctx context.Context, ids []uint64) ([]model.Domain, error)
+	GetDomainsByUserID(ctx context.Context, userID uint64) ([]model.Domain, error)
+	VerifyDomain(ctx context.Context, domain *model.Domain, at time.Time) error
+	DeleteDomain(ctx context.Context, id uint64) error
+	CountURLsByDomainID(ctx context.Context, id uint64) (int64, error)
+}
+
+type domainRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewDomainRepo(db *gorm.DB) *domainRepositoryImpl {
+	return &domainRepositoryImpl{
+		db: db,
+	}
+}
+
+// CreateDomain implements DomainRepository.
+func (r *domainRepositoryImpl) CreateDomain(ctx context.Context, domain *model.Domain) error {
+	return r.db.WithContext(ctx).Create(domain).Error
+}
+
+// GetDomainByID implements DomainRepository.
+// 找不到时返回 nil, nil
+func (r *domainRepositoryImpl) GetDomainByID(ctx context.Context, id uint64) (*model.Domain, error) {
+	var domain model.Domain
+	err := r.db.WithContext(ctx).First(&domain, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &domain, err
+}
+
+// GetVerifiedDomainByHost implements DomainRepository.
+// 同一域名可能有多个未验证的申请，只返回验证通过的那个，没有时返回 nil, nil
+func (r *domainRepositoryImpl) GetVerifiedDomainByHost(ctx context.Context, host string) (*model.Domain, error) {
+	var domain model.Domain
+	err := r.db.WithContext(ctx).Where("host = ? AND verified", host).First(&domain).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &domain, err
+}
+
+// GetUserDomainByHost implements DomainRepository.
+// 找不到时返回 nil, nil
+func (r *domainRepositoryImpl) GetUserDomainByHost(ctx context.Context, userID uint64, host string) (*model.Domain, error) {
+	var domain model.Domain
+	err := r.db.WithContext(ctx).Where("user_id = ? AND host = ?", userID, host).First(&domain).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &domain, err
+}
+
+// GetDomainsByIDs implements DomainRepository.
+func (r *domainRepositoryImpl) GetDomainsByIDs(ctx context.Context, ids []uint64) ([]model.Domain, error) {
+	var domains []model.Domain
+	if len(ids) == 0 {
+		return domains, nil
+	}
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&domains).Error
+	return domains, err
+}
+
+// GetDomainsByUserID implements DomainRepository.
+func (r *domainRepositoryImpl) GetDomainsByUserID(ctx context.Context, userID uint64) ([]model.Domain, error) {
+	var domains []model.Domain
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&domains).Error
+	return domains, err
+}
+
+// VerifyDomain implements DomainRepository.
+// 标记为已验证并删除其他用户对同一域名的申请。锁住该域名的所有申请，
+// 同时验证时只有一个成功，其他申请已先验证通过时返回 gorm.ErrDuplicatedKey
+func (r *domainRepositoryImpl) VerifyDomain(ctx context.Context, domain *model.Domain, at time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var claims []model.Domain
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("host = ?", domain.Host).
+			Find(&claims).Error
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, c := range claims {
+			if c.ID == domain.ID {
+				found = true
+			} else if c.Verified {
+				return gorm.ErrDuplicatedKey
+			}
+		}
+		if !found {
+			return gorm.ErrRecordNotFound
+		}
+		err = tx.Model(&model.Domain{}).
+			Where("id = ?", domain.ID).
+			Updates(map[string]any{"verified": true, "verified_at": at}).Error
+		if err != nil {
+			return err
+		}
+		// 未验证的域名上不会有短链接，可以直接删除
+		return tx.Where("host = ? AND id <> ? AND NOT verified", domain.Host, domain.ID).
+			Delete(&model.Domain{}).Error
+	})
+}
+
+// DeleteDomain implements DomainRepository.
+func (r *domainRepositoryImpl) DeleteDomain(ctx context.Context, id uint64) error {
+	result := r.db.WithContext(ctx).Delete(&model.Domain{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CountURLsByDomainID implements DomainRepository.
+// 回收站中的短链接同样计入，恢复后仍使用该域名
+func (r *domainRepositoryImpl) CountURLsByDomainID(ctx context.Context, id uint64) (int64, error) {
+	var cnt int64
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&model.URL{}).
+		Where("domain_id = ?", id).
+		Count(&cnt).Error
+	return cnt, err
+}
+
+var _ DomainRepository = (*domainRepositoryImpl)(nil)
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jekyulll/url_shortener/internal/model"
+	"gorm.io/gorm"
+)
+
+func TestCountURLsByDomainIDIncludesTrash(t *testing.T) {
+	db, d := newRecordingDB(t)
+	_, _ = NewDomainRepo(db).CountURLsByDomainID(context.Background(), 3)
+
+	if len(d.stmts) != 1 {
+		t.Fatalf("got statements %q", d.stmts)
+	}
+	if strings.Contains(d.stmts[0], "deleted_at") {
+		t.Errorf("soft-deleted urls not counted: %s", d.stmts[0])
+	}
+}
+
+func TestVerifyDomainLocksClaims(t *testing.T) {
+	db, d := newRecordingDB(t)
+	domain := &model.Domain{ID: 1, Host: "go.example.com"}
+	err := NewDomainRepo(db).VerifyDomain(context.Background(), domain, time.Now())
+	// 记录驱动不返回任何行，即申请已被删除
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("got %v, want gorm.ErrRecordNotFound", err)
+	}
+	if len(d.stmts) != 3 || d.stmts[0] != "BEGIN" || d.stmts[2] != "ROLLBACK" {
+		t.Fatalf("got statements %q", d.stmts)
+	}
+	if !strings.HasSuffix(d.stmts[1], "FOR UPDATE") {
+		t.Errorf("claims not locked: %s", d.stmts[1])
+	}
+}
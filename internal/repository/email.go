@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jekyulll/url_shortener/internal/model"
+	"gorm.io/gorm"
+)
+
+type EmailOutboxRepository interface {
+	CreateEmail(ctx context.Context, email *model.OutboxEmail) error
+	GetDueEmails(ctx context.Context, now time.Time, limit int) ([]model.OutboxEmail, error)
+	UpdateEmailAttempt(ctx context.Context, email *model.OutboxEmail) error
+	GetEmails(ctx context.Context, status string, limit, offset int) ([]model.OutboxEmail, int64, error)
+	RequeueEmail(ctx context.Context, id uint64, now time.Time) error
+	DeleteSentEmails(ctx context.Context, before time.Time) error
+}
+
+type emailOutboxRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewEmailOutboxRepo(db *gorm.DB) *emailOutboxRepositoryImpl {
+	return &emailOutboxRepositoryImpl{
+		db: db,
+	}
+}
+
+// CreateEmail implements EmailOutboxRepository.
+func (r *emailOutboxRepositoryImpl) CreateEmail(ctx context.Context, email *model.OutboxEmail) error {
+	return r.db.WithContext(ctx).Create(email).Error
+}
+
+// GetDueEmails implements EmailOutboxRepository.
+// 到了投递时间的待发送邮件，先入先出
+func (r *emailOutboxRepositoryImpl) GetDueEmails(ctx context.Context, now time.Time, limit int) ([]model.OutboxEmail, error) {
+	var emails []model.OutboxEmail
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", model.EmailPending, now).
+		Order("id").
+		Limit(limit).
+		Find(&emails).Error
+	return emails, err
+}
+
+// UpdateEmailAttempt implements EmailOutboxRepository.
+// 保存一次投递的结果
+func (r *emailOutboxRepositoryImpl) UpdateEmailAttempt(ctx context.Context, email *model.OutboxEmail) error {
+	return r.db.WithContext(ctx).
+		Model(email).
+		Select("status", "attempts", "next_attempt_at", "last_error", "sent_at").
+		Updates(email).Error
+}
+
+// GetEmails implements EmailOutboxRepository.
+// status 为空时不过滤，新的在前
+func (r *emailOutboxRepositoryImpl) GetEmails(ctx context.Context, status string, limit, offset int) ([]model.OutboxEmail, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.OutboxEmail{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var emails []model.OutboxEmail
+	err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&emails).Error
+	return emails, total, err
+}
+
+// RequeueEmail implements EmailOutboxRepository.
+// 只能重新投递已放弃的邮件，其他状态返回 gorm.ErrRecordNotFound
+func (r *emailOutboxRepositoryImpl) RequeueEmail(ctx context.Context, id uint64, now time.Time) error {
+	res := r.db.WithContext(ctx).
+		Model(&model.OutboxEmail{}).
+		Where("id = ? AND status = ?", id, model.EmailDead).
+		Updates(map[string]any{
+			"status":          model.EmailPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteSentEmails implements EmailOutboxRepository.
+// 已发送的邮件中可能有验证码，保留一段时间后删除
+func (r *emailOutboxRepositoryImpl) DeleteSentEmails(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).
+		Where("status = ? AND sent_at < ?", model.EmailSent, before).
+		Delete(&model.OutboxEmail{}).Error
+}
+
+var _ EmailOutboxRepository = (*emailOutboxRepositoryImpl)(nil)
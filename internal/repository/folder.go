@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jekyulll/url_shortener/internal/model"
+	"gorm.io/gorm"
+)
+
+type FolderRepository interface {
+	CreateFolder(ctx context.Context, folder *model.Folder) error
+	GetFoldersByWorkspaceIDs(ctx context.Context, ids []uint64) ([]model.Folder, error)
+	GetFolder(ctx context.Context, id uint64) (*model.Folder, error)
+	GetFolderByName(ctx context.Context, workspaceID, parentID uint64, name string) (*model.Folder, error)
+	UpdateFolder(ctx context.Context, folder *model.Folder) error
+	DeleteFolder(ctx context.Context, id uint64) error
+
+	// CountFolderItems 子文件夹和短链接的数量，回收站中的不计入
+	CountFolderItems(ctx context.Context, id uint64) (int64, error)
+	MoveURLs(ctx context.Context, urlIDs []uint64, folderID uint64) error
+}
+
+type folderRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewFolderRepo(db *gorm.DB) *folderRepositoryImpl {
+	return &folderRepositoryImpl{
+		db: db,
+	}
+}
+
+// CreateFolder implements FolderRepository.
+func (r *folderRepositoryImpl) CreateFolder(ctx context.Context, folder *model.Folder) error {
+	return r.db.WithContext(ctx).Create(folder).Error
+}
+
+// GetFoldersByWorkspaceIDs implements FolderRepository.
+func (r *folderRepositoryImpl) GetFoldersByWorkspaceIDs(ctx context.Context, ids []uint64) ([]model.Folder, error) {
+	var folders []model.Folder
+	err := r.db.WithContext(ctx).
+		Where("workspace_id IN ?", ids).
+		Order("workspace_id, parent_id, name").
+		Find(&folders).Error
+	return folders, err
+}
+
+// GetFolder implements FolderRepository.
+// 找不到时返回 nil, nil
+func (r *folderRepositoryImpl) GetFolder(ctx context.Context, id uint64) (*model.Folder, error) {
+	var folder model.Folder
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&folder).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &folder, err
+}
+
+// GetFolderByName implements FolderRepository.
+// 找不到时返回 nil, nil
+func (r *folderRepositoryImpl) GetFolderByName(ctx context.Context, workspaceID, parentID uint64, name string) (*model.Folder, error) {
+	var folder model.Folder
+	err := r.db.WithContext(ctx).
+		Where("workspace_id = ? AND parent_id = ? AND name = ?", workspaceID, parentID, name).
+		First(&folder).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &folder, err
+}
+
+// UpdateFolder implements FolderRepository.
+func (r *folderRepositoryImpl) UpdateFolder(ctx context.Context, folder *model.Folder) error {
+	return r.db.WithContext(ctx).
+		Model(folder).
+		Select("name", "parent_id").
+		Updates(folder).Error
+}
+
+// DeleteFolder implements FolderRepository.
+// 回收站中仍属于该文件夹的短链接移到根目录，恢复后不会指向已删除的文件夹
+func (r *folderRepositoryImpl) DeleteFolder(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().
+			Model(&model.URL{}).
+			Where("folder_id = ?", id).
+			Update("folder_id", 0).Error
+		if err != nil {
+			return err
+		}
+		result := tx.Delete(&model.Folder{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// CountFolderItems implements FolderRepository.
+func (r *folderRepositoryImpl) CountFolderItems(ctx context.Context, id uint64) (int64, error) {
+	var folders, urls int64
+	db := r.db.WithContext(ctx)
+	if err := db.Model(&model.Folder{}).Where("parent_id = ?", id).Count(&folders).Error; err != nil {
+		return 0, err
+	}
+	err := db.Model(&model.URL{}).Where("folder_id = ?", id).Count(&urls).Error
+	return folders + urls, err
+}
+
+// MoveURLs implements FolderRepository.
+// folderID 为 0 时移出文件夹
+func (r *folderRepositoryImpl) MoveURLs(ctx context.Context, urlIDs []uint64, folderID uint64) error {
+	return r.db.WithContext(ctx).
+		Model(&model.URL{}).
+		Where("id IN ?", urlIDs).
+		Update("folder_id", folderID).Error
+}
+
+var _ FolderRepository = (*folderRepositoryImpl)(nil)
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jekyulll/url_shortener/internal/model"
+	"gorm.io/gorm"
+)
+
+type IdentityRepository interface {
+	GetIdentity(ctx context.Context, provider, subject string) (*model.UserIdentity, error)
+	CreateIdentity(ctx context.Context, identity *model.UserIdentity) error
+	CreateUserWithIdentity(ctx context.Context, user *model.User, identity *model.UserIdentity) error
+}
+
+type identityRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepo(db *gorm.DB) *identityRepositoryImpl {
+	return &identityRepositoryImpl{
+		db: db,
+	}
+}
+
+// GetIdentity implements IdentityRepository.
+// 找不到时返回 nil, nil
+func (r *identityRepositoryImpl) GetIdentity(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	err := r.db.WithContext(ctx).
+		Where("provider = ? AND subject = ?", provider, subject).
+		First(&identity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &identity, err
+}
+
+// CreateIdentity implements IdentityRepository.
+func (r *identityRepositoryImpl) CreateIdentity(ctx context.Context, identity *model.UserIdentity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+// CreateUserWithIdentity implements IdentityRepository.
+// 首次登录时同时创建用户和绑定关系
+func (r *identityRepositoryImpl) CreateUserWithIdentity(ctx context.Context, user *model.User, identity *model.UserIdentity) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
+
+var _ IdentityRepository = (*identityRepositoryImpl)(nil)
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jekyulll/url_shortener/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LandingPageRepository interface {
+	UpsertPage(ctx context.Context, page *model.LandingPage) error
+	GetPagesByUserID(ctx context.Context, userID uint64) ([]model.LandingPage, error)
+	GetPage(ctx context.Context, userID, domainID uint64, kind string) (*model.LandingPage, error)
+	GetDomainPage(ctx context.Context, domainID uint64, kind string) (*model.LandingPage, error)
+	DeletePage(ctx context.Context, userID, domainID uint64, kind string) error
+}
+
+type landingPageRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewLandingPageRepo(db *gorm.DB) *landingPageRepositoryImpl {
+	return &landingPageRepositoryImpl{
+		db: db,
+	}
+}
+
+// UpsertPage implements LandingPageRepository.
+func (r *landingPageRepositoryImpl) UpsertPage(ctx context.Context, page *model.LandingPage) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "domain_id"}, {Name: "kind"}},
+		DoUpdates: clause.AssignmentColumns([]string{"template", "updated_at"}),
+	}).Create(page).Error
+}
+
+// GetPagesByUserID implements LandingPageRepository.
+func (r *landingPageRepositoryImpl) GetPagesByUserID(ctx context.Context, userID uint64) ([]model.LandingPage, error) {
+	var pages []model.LandingPage
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("domain_id, kind").
+		Find(&pages).Error
+	return pages, err
+}
+
+// GetPage implements LandingPageRepository.
+// 找不到时返回 nil, nil
+func (r *landingPageRepositoryImpl) GetPage(ctx context.Context, userID, domainID uint64, kind string) (*model.LandingPage, error) {
+	var page model.LandingPage
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND domain_id = ? AND kind = ?", userID, domainID, kind).
+		First(&page).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &page, err
+}
+
+// GetDomainPage implements LandingPageRepository.
+// 域名级别的模板只能由域名所有者设置，因此无需指定用户
+func (r *landingPageRepositoryImpl) GetDomainPage(ctx context.Context, domainID uint64, kind string) (*model.LandingPage, error) {
+	var page model.LandingPage
+	err := r.db.WithContext(ctx).
+		Where("domain_id = ? AND kind = ?", domainID, kind).
+		First(&page).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &page, err
+}
+
+// DeletePage implements LandingPageRepository.
+func (r *landingPageRepositoryImpl) DeletePage(ctx context.Context, userID, domainID uint64, kind string) error {
+	result := r.db.WithContext(ctx).
+		Delete(&model.LandingPage{}, "user_id = ? AND domain_id = ? AND kind = ?", userID, domainID, kind)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+var _ LandingPageRepository = (*landingPageRepositoryImpl)(nil)
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jekyulll/url_shortener/internal/model"
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepository interface {
+	ReplaceRecoveryCodes(ctx context.Context, userID uint64, hashes []string) error
+	UseRecoveryCode(ctx context.Context, userID uint64, hash string) (bool, error)
+	DeleteRecoveryCodes(ctx context.Context, userID uint64) error
+}
+
+type recoveryCodeRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodeRepo(db *gorm.DB) *recoveryCodeRepositoryImpl {
+	return &recoveryCodeRepositoryImpl{
+		db: db,
+	}
+}
+
+// ReplaceRecoveryCodes implements RecoveryCodeRepository.
+// 旧的恢复码全部作废
+func (r *recoveryCodeRepositoryImpl) ReplaceRecoveryCodes(ctx context.Context, userID uint64, hashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]model.RecoveryCode, len(hashes))
+		for i, hash := range hashes {
+			codes[i] = model.RecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode implements RecoveryCodeRepository.
+// 恢复码有效且未使用时标记为已使用并返回 true
+func (r *recoveryCodeRepositoryImpl) UseRecoveryCode(ctx context.Context, userID uint64, hash string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteRecoveryCodes implements RecoveryCodeRepository.
+func (r *recoveryCodeRepositoryImpl) DeleteRecoveryCodes(ctx context.Context, userID uint64) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error
+}
+
+var _ RecoveryCodeRepository = (*recoveryCodeRepositoryImpl)(nil)
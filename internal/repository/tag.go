@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jekyulll/url_shortener/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TagRepository interface {
+	CreateTag(ctx context.Context, tag *model.Tag) error
+	GetTagsByWorkspaceIDs(ctx context.Context, ids []uint64) ([]model.Tag, error)
+	GetTag(ctx context.Context, id uint64) (*model.Tag, error)
+	GetTagByName(ctx context.Context, workspaceID uint64, name string) (*model.Tag, error)
+	GetTagsByNames(ctx context.Context, workspaceID uint64, names []string) ([]model.Tag, error)
+	UpdateTag(ctx context.Context, tag *model.Tag) error
+	DeleteTag(ctx context.Context, id uint64) error
+
+	// EnsureTags 按名称查找工作区中的标签，不存在的先创建
+	EnsureTags(ctx context.Context, workspaceID uint64, names []string) ([]model.Tag, error)
+	AddURLTags(ctx context.Context, urlIDs, tagIDs []uint64) error
+	RemoveURLTags(ctx context.Context, urlIDs, tagIDs []uint64) error
+}
+
+type tagRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTagRepo(db *gorm.DB) *tagRepositoryImpl {
+	return &tagRepositoryImpl{
+		db: db,
+	}
+}
+
+// CreateTag implements TagRepository.
+func (r *tagRepositoryImpl) CreateTag(ctx context.Context, tag *model.Tag) error {
+	return r.db.WithContext(ctx).Create(tag).Error
+}
+
+// GetTagsByWorkspaceIDs implements TagRepository.
+func (r *tagRepositoryImpl) GetTagsByWorkspaceIDs(ctx context.Context, ids []uint64) ([]model.Tag, error) {
+	var tags []model.Tag
+	err := r.db.WithContext(ctx).
+		Where("workspace_id IN ?", ids).
+		Order("workspace_id, name").
+		Find(&tags).Error
+	return tags, err
+}
+
+// GetTag implements TagRepository.
+// 找不到时返回 nil, nil
+func (r *tagRepositoryImpl) GetTag(ctx context.Context, id uint64) (*model.Tag, error) {
+	var tag model.Tag
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&tag).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &tag, err
+}
+
+// GetTagByName implements TagRepository.
+// 找不到时返回 nil, nil
+func (r *tagRepositoryImpl) GetTagByName(ctx context.Context, workspaceID uint64, name string) (*model.Tag, error) {
+	var tag model.Tag
+	err := r.db.WithContext(ctx).Where("workspace_id = ? AND name = ?", workspaceID, name).First(&tag).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &tag, err
+}
+
+// GetTagsByNames implements TagRepository.
+// 不存在的名称被忽略
+func (r *tagRepositoryImpl) GetTagsByNames(ctx context.Context, workspaceID uint64, names []string) ([]model.Tag, error) {
+	var tags []model.Tag
+	err := r.db.WithContext(ctx).Where("workspace_id = ? AND name IN ?", workspaceID, names).Find(&tags).Error
+	return tags, err
+}
+
+// UpdateTag implements TagRepository.
+func (r *tagRepositoryImpl) UpdateTag(ctx context.Context, tag *model.Tag) error {
+	return r.db.WithContext(ctx).
+		Model(tag).
+		Select("name", "color").
+		Updates(tag).Error
+}
+
+// DeleteTag implements TagRepository.
+// 与短链接的关联由外键级联删除
+func (r *tagRepositoryImpl) DeleteTag(ctx context.Context, id uint64) error {
+	result := r.db.WithContext(ctx).Delete(&model.Tag{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// EnsureTags implements TagRepository.
+// 并发创建同名标签时依靠唯一索引忽略重复
+func (r *tagRepositoryImpl) EnsureTags(ctx context.Context, workspaceID uint64, names []string) ([]model.Tag, error) {
+	tags := make([]model.Tag, len(names))
+	for i, name := range names {
+		tags[i] = model.Tag{WorkspaceID: workspaceID, Name: name}
+	}
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.GetTagsByNames(ctx, workspaceID, names)
+}
+
+// AddURLTags implements TagRepository.
+// 已有的关联保持不变
+func (r *tagRepositoryImpl) AddURLTags(ctx context.Context, urlIDs, tagIDs []uint64) error {
+	links := make([]model.URLTag, 0, len(urlIDs)*len(tagIDs))
+	for _, urlID := range urlIDs {
+		for _, tagID := range tagIDs {
+			links = append(links, model.URLTag{URLID: urlID, TagID: tagID})
+		}
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(links, 500).Error
+}
+
+// RemoveURLTags implements TagRepository.
+func (r *tagRepositoryImpl) RemoveURLTags(ctx context.Context, urlIDs, tagIDs []uint64) error {
+	return r.db.WithContext(ctx).
+		Where("url_id IN ? AND tag_id IN ?", urlIDs, tagIDs).
+		Delete(&model.URLTag{}).Error
+}
+
+var _ TagRepository = (*tagRepositoryImpl)(nil)
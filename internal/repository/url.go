@@ -13,21 +13,44 @@ import (
 type URLRepository interface {
 	CreateURL(ctx context.Context, url *model.URL) error
 
-	UpdateURLExpiredByShortCode(ctx context.Context, shortCode string, expiredAt time.Time) error
+	// 修改和删除都限定在 workspaceID 下，其他工作区的短链接视为不存在
+	UpdateURLExpiredByShortCode(ctx context.Context, workspaceID, domainID uint64, shortCode string, expiredAt time.Time) error
 	UpdateURL(ctx context.Context, url *model.URL) error
 	UpsertURL(ctx context.Context, url *model.URL) error
+	CreateURLs(ctx context.Context, urls []*model.URL) error
 
 	DeleteURLByID(ctx context.Context, id uint) error
-	DeleteURLByShortCode(ctx context.Context, shortCode string) error
+	DeleteURLByShortCode(ctx context.Context, workspaceID, domainID uint64, shortCode string) error
 
-	GetURLByShortCode(ctx context.Context, shortCode string) (*model.URL, error)
-	GetURLsByUserID(ctx context.Context, id int32, limit int32, offset int32) ([]*model.URL, error)
+	GetURLByShortCode(ctx context.Context, domainID uint64, shortCode string) (*model.URL, error)
+	GetURLByID(ctx context.Context, id uint64) (*model.URL, error)
+	GetURLsByIDs(ctx context.Context, ids []uint64) ([]*model.URL, error)
+	ListURLs(ctx context.Context, filter URLFilter, after *URLCursor, limit, offset int) ([]*model.URL, int64, error)
+	GetURLsAfterID(ctx context.Context, workspaceIDs []uint64, afterID uint64, limit int) ([]*model.URL, error)
 	GetAllURLs(ctx context.Context) ([]model.URL, error)
 	GetAllActiveURLs(ctx context.Context) ([]model.URL, error)
 
-	DeleteAllExpired(ctx context.Context) error
+	GetExpiredURLs(ctx context.Context, now time.Time, limit int) ([]*model.URL, error)
+	DeleteExpiredURLs(ctx context.Context, ids []uint64, now time.Time) error
 
-	UpdateViewsByShortCode(ctx context.Context, shortCode string, views int32) error
+	// 回收站
+	GetTrashedURLsByWorkspaceIDs(ctx context.Context, ids []uint64, deletedAfter time.Time, limit int32, offset int32) ([]*model.URL, error)
+	GetTrashedURL(ctx context.Context, domainID uint64, shortCode string) (*model.URL, error)
+	RestoreURL(ctx context.Context, workspaceID, domainID uint64, shortCode string, deletedAfter time.Time) error
+	PurgeTrash(ctx context.Context, deletedBefore time.Time) error
+
+	UpdateViewsByShortCode(ctx context.Context, domainID uint64, shortCode string, views int32) error
+
+	// 过期提醒
+	GetURLsToRemind(ctx context.Context, from, to time.Time, limit int) ([]*model.URL, error)
+	MarkURLReminded(ctx context.Context, id uint64, at time.Time) error
+
+	UpdateURLDisabled(ctx context.Context, workspaceID, domainID uint64, shortCode string, disabled bool, reason string) error
+	UpdateURLDetails(ctx context.Context, url *model.URL) error
+
+	// 管理接口
+	SearchURLs(ctx context.Context, q string, userID uint64, limit, offset int) ([]*model.URL, int64, error)
+	TransferURL(ctx context.Context, domainID uint64, shortCode string, toUserID, toWorkspaceID uint64) error
 
 	// TODO UpdateOriginalURL、ListRecent
 
@@ -38,8 +61,85 @@ type gormURLRepositoryImpl struct {
 }
 
 // UpdateViewsByShortCode implements URLRepository.
-func (r *gormURLRepositoryImpl) UpdateViewsByShortCode(ctx context.Context, shortCode string, views int32) error {
-	panic("unimplemented")
+// views 为增量，累加到数据库中；回收站中的短链接同样累加，恢复后统计不丢失
+func (r *gormURLRepositoryImpl) UpdateViewsByShortCode(ctx context.Context, domainID uint64, shortCode string, views int32) error {
+	return r.db.WithContext(ctx).
+		Unscoped().
+		Model(&model.URL{}).
+		Where("domain_id = ? AND short_code = ?", domainID, shortCode).
+		Update("views", gorm.Expr("views + ?", views)).
+		Error
+}
+
+// UpdateURLDisabled implements URLRepository.
+// 只会修改 workspaceID 下的短链接
+// 注意：值未变化时 MySQL 的 RowsAffected 为 0，因此不据此判断是否存在
+func (r *gormURLRepositoryImpl) UpdateURLDisabled(ctx context.Context, workspaceID, domainID uint64, shortCode string, disabled bool, reason string) error {
+	var disabledAt *time.Time
+	if disabled {
+		now := time.Now()
+		disabledAt = &now
+	}
+	return r.db.WithContext(ctx).
+		Model(&model.URL{}).
+		Where("workspace_id = ? AND domain_id = ? AND short_code = ?", workspaceID, domainID, shortCode).
+		Updates(map[string]interface{}{
+			"disabled":        disabled,
+			"disabled_reason": reason,
+			"disabled_at":     disabledAt,
+		}).Error
+}
+
+// UpdateURLDetails implements URLRepository.
+// 只更新标题、描述、备注和是否展示预览页
+func (r *gormURLRepositoryImpl) UpdateURLDetails(ctx context.Context, url *model.URL) error {
+	return r.db.WithContext(ctx).
+		Model(url).
+		Select("title", "description", "notes", "interstitial").
+		Updates(url).Error
+}
+
+// SearchURLs implements URLRepository.
+// 按短码或原始链接模糊匹配，userID 为 0 时不限用户
+func (r *gormURLRepositoryImpl) SearchURLs(ctx context.Context, q string, userID uint64, limit, offset int) ([]*model.URL, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.URL{})
+	if q != "" {
+		pattern := likePattern(q)
+		query = query.Where("short_code LIKE ? OR original_url LIKE ?", pattern, pattern)
+	}
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var urls []*model.URL
+	err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&urls).Error
+	return urls, total, err
+}
+
+// TransferURL implements URLRepository.
+// 标签和文件夹属于原工作区，转移后清空
+func (r *gormURLRepositoryImpl) TransferURL(ctx context.Context, domainID uint64, shortCode string, toUserID, toWorkspaceID uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var url model.URL
+		if err := tx.Where("domain_id = ? AND short_code = ?", domainID, shortCode).First(&url).Error; err != nil {
+			return err
+		}
+		if url.WorkspaceID == toWorkspaceID {
+			return tx.Model(&url).Update("user_id", toUserID).Error
+		}
+		err := tx.Model(&url).Updates(map[string]interface{}{
+			"user_id":      toUserID,
+			"workspace_id": toWorkspaceID,
+			"folder_id":    0,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("url_id = ?", url.ID).Delete(&model.URLTag{}).Error
+	})
 }
 
 func NewURLRepo(db *gorm.DB) *gormURLRepositoryImpl {
@@ -49,8 +149,9 @@ func NewURLRepo(db *gorm.DB) *gormURLRepositoryImpl {
 }
 
 // DeleteURLByShortCode implements URLRepository.
-func (r *gormURLRepositoryImpl) DeleteURLByShortCode(ctx context.Context, shortCode string) error {
-	result := r.db.WithContext(ctx).Delete(&model.URL{}, "short_code = ?", shortCode)
+// 软删除，移入回收站
+func (r *gormURLRepositoryImpl) DeleteURLByShortCode(ctx context.Context, workspaceID, domainID uint64, shortCode string) error {
+	result := r.db.WithContext(ctx).Delete(&model.URL{}, "workspace_id = ? AND domain_id = ? AND short_code = ?", workspaceID, domainID, shortCode)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -60,27 +161,142 @@ func (r *gormURLRepositoryImpl) DeleteURLByShortCode(ctx context.Context, shortC
 	return nil
 }
 
-// GetURLsByUserID implements URLRepository.
-func (r *gormURLRepositoryImpl) GetURLsByUserID(ctx context.Context, id int32, limit int32, offset int32) ([]*model.URL, error) {
+// ListURLs implements URLRepository.
+// 返回的总数只受过滤条件影响，与分页位置无关。after 不为空时按 keyset 分页，忽略 offset
+func (r *gormURLRepositoryImpl) ListURLs(ctx context.Context, filter URLFilter, after *URLCursor, limit, offset int) ([]*model.URL, int64, error) {
+	query := filter.apply(r.db.WithContext(ctx).Model(&model.URL{}), time.Now())
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	column, ok := urlSortColumns[filter.Sort]
+	if !ok {
+		column = "created_at"
+	}
+	order, cmp := "ASC", ">"
+	if filter.Desc {
+		order, cmp = "DESC", "<"
+	}
+	if after != nil {
+		// 排序列相同时按 ID 决定先后，保证翻页不重复、不遗漏
+		query = query.Where(column+" "+cmp+" ? OR ("+column+" = ? AND id "+cmp+" ?)", after.Value, after.Value, after.ID)
+	} else {
+		query = query.Offset(offset)
+	}
 	var urls []*model.URL
-	result := r.db.WithContext(ctx).
-		Where("user_id = ?", id).
-		Order("created_at DESC").
-		Limit(int(limit)).
-		Offset(int(offset)).
-		Find(&urls)
-	if result.Error != nil {
-		return nil, result.Error
+	err := query.
+		Preload("Tags", func(db *gorm.DB) *gorm.DB { return db.Order("name") }).
+		Order(column + " " + order).
+		Order("id " + order).
+		Limit(limit).
+		Find(&urls).Error
+	return urls, total, err
+}
+
+// URLFilter 短链接列表的查询条件，零值的字段不参与过滤
+type URLFilter struct {
+	WorkspaceIDs []uint64
+	Query        string // 在原始链接、短码和标题中模糊搜索
+	Status       string // active 未过期且未暂停，expired 已过期，disabled 已暂停
+	Custom       *bool
+	Tag          string  // 标签名称
+	FolderID     *uint64 // 0 表示不在任何文件夹中
+
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	ExpiresFrom time.Time
+	ExpiresTo   time.Time
+	MinViews    *int32 // 只比较已同步到数据库的访问量
+	MaxViews    *int32
+
+	Sort string // urlSortColumns 中的键，默认 created_at
+	Desc bool
+}
+
+// URLCursor 上一页最后一行的排序列的值和 ID
+type URLCursor struct {
+	Value any
+	ID    uint64
+}
+
+// 允许排序的列，避免把请求参数直接拼进 SQL
+var urlSortColumns = map[string]string{
+	"created_at":   "created_at",
+	"expired_at":   "expired_at",
+	"views":        "views",
+	"short_code":   "short_code",
+	"title":        "title",
+	"original_url": "original_url",
+}
+
+func (f URLFilter) apply(query *gorm.DB, now time.Time) *gorm.DB {
+	query = query.Where("workspace_id IN ?", f.WorkspaceIDs)
+	if f.Query != "" {
+		pattern := likePattern(f.Query)
+		query = query.Where("original_url LIKE ? OR short_code LIKE ? OR title LIKE ?", pattern, pattern, pattern)
+	}
+	switch f.Status {
+	case "active":
+		query = query.Where("expired_at > ? AND disabled = ?", now, false)
+	case "expired":
+		query = query.Where("expired_at <= ?", now)
+	case "disabled":
+		query = query.Where("disabled = ?", true)
+	}
+	if f.Custom != nil {
+		query = query.Where("is_custom = ?", *f.Custom)
+	}
+	if f.Tag != "" {
+		tagged := query.Session(&gorm.Session{NewDB: true}).
+			Table("url_tags").
+			Select("url_tags.url_id").
+			Joins("JOIN tags ON tags.id = url_tags.tag_id").
+			Where("tags.workspace_id IN ? AND tags.name = ?", f.WorkspaceIDs, f.Tag)
+		query = query.Where("id IN (?)", tagged)
+	}
+	if f.FolderID != nil {
+		query = query.Where("folder_id = ?", *f.FolderID)
+	}
+	if !f.CreatedFrom.IsZero() {
+		query = query.Where("created_at >= ?", f.CreatedFrom)
+	}
+	if !f.CreatedTo.IsZero() {
+		query = query.Where("created_at < ?", f.CreatedTo)
 	}
-	return urls, nil
+	if !f.ExpiresFrom.IsZero() {
+		query = query.Where("expired_at >= ?", f.ExpiresFrom)
+	}
+	if !f.ExpiresTo.IsZero() {
+		query = query.Where("expired_at < ?", f.ExpiresTo)
+	}
+	if f.MinViews != nil {
+		query = query.Where("views >= ?", *f.MinViews)
+	}
+	if f.MaxViews != nil {
+		query = query.Where("views <= ?", *f.MaxViews)
+	}
+	return query
+}
+
+// GetURLsAfterID implements URLRepository.
+// 按 ID 分页遍历，供导出使用，不受遍历期间新增或删除的短链接影响
+func (r *gormURLRepositoryImpl) GetURLsAfterID(ctx context.Context, workspaceIDs []uint64, afterID uint64, limit int) ([]*model.URL, error) {
+	var urls []*model.URL
+	err := r.db.WithContext(ctx).
+		Where("workspace_id IN ? AND id > ?", workspaceIDs, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&urls).Error
+	return urls, err
 }
 
 // UpdateURLExpiredByShortCode implements URLRepository.
-func (r *gormURLRepositoryImpl) UpdateURLExpiredByShortCode(ctx context.Context, shortCode string, expiredAt time.Time) error {
+func (r *gormURLRepositoryImpl) UpdateURLExpiredByShortCode(ctx context.Context, workspaceID, domainID uint64, shortCode string, expiredAt time.Time) error {
 	result := r.db.WithContext(ctx).
 		Model(&model.URL{}).
-		Where("short_code = ? AND expired_at > ?", shortCode, time.Now()).
-		Update("expired_at", expiredAt)
+		Where("workspace_id = ? AND domain_id = ? AND short_code = ? AND expired_at > ?", workspaceID, domainID, shortCode, time.Now()).
+		Updates(map[string]any{"expired_at": expiredAt, "reminded_at": nil}) // 新的有效期重新提醒
 	if result.Error != nil {
 		return result.Error
 	}
@@ -99,9 +315,9 @@ func (r *gormURLRepositoryImpl) CreateURL(ctx context.Context, url *model.URL) e
 
 // WARNING 找不到的时候不会返回 error，而是直接返回空指针
 // 过期的时候仍然会返回，由外部判断
-func (r *gormURLRepositoryImpl) GetURLByShortCode(ctx context.Context, code string) (*model.URL, error) {
+func (r *gormURLRepositoryImpl) GetURLByShortCode(ctx context.Context, domainID uint64, code string) (*model.URL, error) {
 	var url model.URL
-	err := r.db.Where("short_code = ?", code).
+	err := r.db.Where("domain_id = ? AND short_code = ?", domainID, code).
 		First(&url).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, nil
@@ -109,16 +325,37 @@ func (r *gormURLRepositoryImpl) GetURLByShortCode(ctx context.Context, code stri
 	return &url, err
 }
 
+// GetURLByID implements URLRepository.
+// 找不到时返回 nil, nil
+func (r *gormURLRepositoryImpl) GetURLByID(ctx context.Context, id uint64) (*model.URL, error) {
+	var url model.URL
+	err := r.db.WithContext(ctx).First(&url, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &url, err
+}
+
+// GetURLsByIDs implements URLRepository.
+// 不存在的 ID 被忽略
+func (r *gormURLRepositoryImpl) GetURLsByIDs(ctx context.Context, ids []uint64) ([]*model.URL, error) {
+	var urls []*model.URL
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&urls).Error
+	return urls, err
+}
+
 func (r *gormURLRepositoryImpl) GetAllURLs(ctx context.Context) ([]model.URL, error) {
 	var urls []model.URL
 	err := r.db.Find(&urls).Error
 	return urls, err
 }
 
+// 包括回收站中的短链接：它们的短码仍被占用
 func (r *gormURLRepositoryImpl) GetAllActiveURLs(ctx context.Context) ([]model.URL, error) {
 	var urls []model.URL
 	err := r.db.WithContext(ctx).
-		Where("expired_at > NOW()").
+		Unscoped().
+		Where("expired_at > NOW() OR deleted_at IS NOT NULL").
 		Find(&urls).
 		Error
 	return urls, err
@@ -132,26 +369,152 @@ func (r *gormURLRepositoryImpl) UpdateURL(ctx context.Context, url *model.URL) e
 }
 
 func (r *gormURLRepositoryImpl) UpsertURL(ctx context.Context, url *model.URL) error {
-	// 根据 short_code 是否存在执行插入或者更新
-	return r.db.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "short_code"}},
-		DoUpdates: clause.AssignmentColumns([]string{"original_url", "expired_at", "is_custom"}),
-	}).Create(url).Error
+	// 根据 (domain_id, short_code) 是否存在执行插入或者更新。
+	// 只有已过期的短码会走到更新，此时归属和访问量都属于新的创建者
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return upsertURLs(tx, []*model.URL{url})
+	})
+}
+
+// CreateURLs implements URLRepository.
+// 在一个事务中写入一批短链接，冲突处理同 UpsertURL
+func (r *gormURLRepositoryImpl) CreateURLs(ctx context.Context, urls []*model.URL) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return upsertURLs(tx, urls)
+	})
+}
+
+// upsertURL 按 (domain_id, short_code) 插入或覆盖已过期的短链接
+var upsertURL = clause.OnConflict{
+	Columns:   []clause.Column{{Name: "domain_id"}, {Name: "short_code"}},
+	DoUpdates: clause.AssignmentColumns([]string{"original_url", "expired_at", "is_custom", "user_id", "workspace_id", "folder_id", "views", "title", "description", "notes", "interstitial", "disabled", "disabled_reason", "disabled_at", "deleted_at", "created_at", "reminded_at"}),
+}
+
+// upsertURLs 需要在事务中调用。覆盖旧短链接时，旧的标签属于原来的工作区，
+// 先删除；文件夹和备注由 upsertURL 一并覆盖
+func upsertURLs(tx *gorm.DB, urls []*model.URL) error {
+	keys := make([][]any, len(urls))
+	for i, url := range urls {
+		url.FolderID = 0
+		url.Notes = ""
+		url.Tags = nil
+		keys[i] = []any{url.DomainID, url.ShortCode}
+	}
+	old := tx.Session(&gorm.Session{NewDB: true}).
+		Unscoped().
+		Model(&model.URL{}).
+		Select("id").
+		Where("(domain_id, short_code) IN ?", keys)
+	if err := tx.Where("url_id IN (?)", old).Delete(&model.URLTag{}).Error; err != nil {
+		return err
+	}
+	return tx.Clauses(upsertURL).Create(&urls).Error
+}
+
+// GetURLsToRemind implements URLRepository.
+// 在 (from, to] 内过期、尚未提醒过且有创建者的短链接，先过期的在前
+func (r *gormURLRepositoryImpl) GetURLsToRemind(ctx context.Context, from, to time.Time, limit int) ([]*model.URL, error) {
+	var urls []*model.URL
+	err := r.db.WithContext(ctx).
+		Where("reminded_at IS NULL AND user_id <> 0 AND expired_at > ? AND expired_at <= ?", from, to).
+		Order("expired_at").
+		Limit(limit).
+		Find(&urls).Error
+	return urls, err
+}
+
+// MarkURLReminded implements URLRepository.
+func (r *gormURLRepositoryImpl) MarkURLReminded(ctx context.Context, id uint64, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.URL{}).
+		Where("id = ?", id).
+		Update("reminded_at", at).Error
 }
 
 func (r *gormURLRepositoryImpl) DeleteURLByID(ctx context.Context, id uint) error {
 	return r.db.Delete(&model.URL{}, id).Error
 }
 
-func (r *gormURLRepositoryImpl) DeleteAllExpired(ctx context.Context) error {
-	return r.db.Where("expired_at < NOW()").Delete(&model.URL{}).Error
+// GetExpiredURLs implements URLRepository.
+// 已过期、不在回收站中的短链接，回收站中的由 PurgeTrash 处理
+func (r *gormURLRepositoryImpl) GetExpiredURLs(ctx context.Context, now time.Time, limit int) ([]*model.URL, error) {
+	var urls []*model.URL
+	err := r.db.WithContext(ctx).
+		Where("expired_at < ?", now).
+		Order("id").
+		Limit(limit).
+		Find(&urls).Error
+	return urls, err
+}
+
+// DeleteExpiredURLs implements URLRepository.
+// 物理删除，查询之后被延期的短链接不会删除
+func (r *gormURLRepositoryImpl) DeleteExpiredURLs(ctx context.Context, ids []uint64, now time.Time) error {
+	return r.db.WithContext(ctx).
+		Unscoped().
+		Where("id IN ? AND expired_at < ? AND deleted_at IS NULL", ids, now).
+		Delete(&model.URL{}).Error
+}
+
+// GetTrashedURLsByWorkspaceIDs implements URLRepository.
+// 只返回 deletedAfter 之后删除（即仍可恢复）的短链接
+func (r *gormURLRepositoryImpl) GetTrashedURLsByWorkspaceIDs(ctx context.Context, ids []uint64, deletedAfter time.Time, limit int32, offset int32) ([]*model.URL, error) {
+	var urls []*model.URL
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Where("workspace_id IN ? AND deleted_at > ?", ids, deletedAfter).
+		Order("deleted_at DESC").
+		Limit(int(limit)).
+		Offset(int(offset)).
+		Find(&urls).Error
+	return urls, err
+}
+
+// GetTrashedURL implements URLRepository.
+// 找不到时返回 nil, nil
+func (r *gormURLRepositoryImpl) GetTrashedURL(ctx context.Context, domainID uint64, shortCode string) (*model.URL, error) {
+	var url model.URL
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Where("domain_id = ? AND short_code = ? AND deleted_at IS NOT NULL", domainID, shortCode).
+		First(&url).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &url, err
+}
+
+// RestoreURL implements URLRepository.
+// 只能恢复 workspaceID 下、deletedAfter 之后删除的短链接
+func (r *gormURLRepositoryImpl) RestoreURL(ctx context.Context, workspaceID, domainID uint64, shortCode string, deletedAfter time.Time) error {
+	result := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&model.URL{}).
+		Where("workspace_id = ? AND domain_id = ? AND short_code = ? AND deleted_at > ?", workspaceID, domainID, shortCode, deletedAfter).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// PurgeTrash implements URLRepository.
+// 物理删除超过保留期的短链接，短码随之释放
+func (r *gormURLRepositoryImpl) PurgeTrash(ctx context.Context, deletedBefore time.Time) error {
+	return r.db.WithContext(ctx).
+		Unscoped().
+		Where("deleted_at < ?", deletedBefore).
+		Delete(&model.URL{}).Error
 }
 
-func (r *gormURLRepositoryImpl) ExistsInDB(ctx context.Context, shortCode string) (bool, error) {
+func (r *gormURLRepositoryImpl) ExistsInDB(ctx context.Context, domainID uint64, shortCode string) (bool, error) {
 	var cnt int64
 	if err := r.db.
 		Model(&model.URL{}).
-		Where("short_code = ?", shortCode).
+		Where("domain_id = ? AND short_code = ?", domainID, shortCode).
 		Count(&cnt).Error; err != nil {
 		return false, err
 	}
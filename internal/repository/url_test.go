@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jekyulll/url_shortener/internal/model"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// recordingDriver 记录执行的 SQL，所有语句都成功，用于检查生成的语句而不需要数据库
+type recordingDriver struct {
+	mu    sync.Mutex
+	stmts []string
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) { return &recordingConn{d: d}, nil }
+
+func (d *recordingDriver) record(query string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stmts = append(d.stmts, query)
+}
+
+type recordingConn struct{ d *recordingDriver }
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordingStmt{c, query}, nil
+}
+func (c *recordingConn) Close() error { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	c.d.record("BEGIN")
+	return c, nil
+}
+func (c *recordingConn) Commit() error {
+	c.d.record("COMMIT")
+	return nil
+}
+func (c *recordingConn) Rollback() error {
+	c.d.record("ROLLBACK")
+	return nil
+}
+
+func (c *recordingConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.d.record(query)
+	return recordingResult{}, nil
+}
+
+type recordingStmt struct {
+	c     *recordingConn
+	query string
+}
+
+func (s *recordingStmt) Close() error  { return nil }
+func (s *recordingStmt) NumInput() int { return -1 }
+func (s *recordingStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.c.d.record(s.query)
+	return recordingResult{}, nil
+}
+func (s *recordingStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.c.d.record(s.query)
+	return emptyRows{}, nil
+}
+
+type recordingResult struct{}
+
+func (recordingResult) LastInsertId() (int64, error) { return 1, nil }
+func (recordingResult) RowsAffected() (int64, error) { return 1, nil }
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string         { return nil }
+func (emptyRows) Close() error              { return nil }
+func (emptyRows) Next([]driver.Value) error { return io.EOF }
+
+func newRecordingDB(t *testing.T) (*gorm.DB, *recordingDriver) {
+	t.Helper()
+	d := &recordingDriver{}
+	name := "recording-" + t.Name()
+	sql.Register(name, d)
+	sqlDB, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}),
+		&gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, d
+}
+
+func TestUpsertURLClearsPreviousOwnerData(t *testing.T) {
+	db, d := newRecordingDB(t)
+	repo := NewURLRepo(db)
+
+	url := &model.URL{
+		UserID:      2,
+		WorkspaceID: 20,
+		DomainID:    0,
+		ShortCode:   "abc123",
+		OriginalURL: "https://example.com",
+		ExpiredAt:   time.Now().Add(time.Hour),
+		FolderID:    7,
+		Notes:       "should not be kept",
+		Tags:        []model.Tag{{ID: 1}},
+	}
+	if err := repo.UpsertURL(context.Background(), url); err != nil {
+		t.Fatal(err)
+	}
+	if url.FolderID != 0 || url.Notes != "" || url.Tags != nil {
+		t.Errorf("folder, notes and tags not reset: %+v", url)
+	}
+
+	var deleteTags, insert int = -1, -1
+	for i, stmt := range d.stmts {
+		switch {
+		case strings.HasPrefix(stmt, "DELETE FROM `url_tags`"):
+			deleteTags = i
+			if !strings.Contains(stmt, "(domain_id, short_code) IN") {
+				t.Errorf("url_tags not matched by short code: %s", stmt)
+			}
+		case strings.HasPrefix(stmt, "INSERT INTO `urls`"):
+			insert = i
+			for _, col := range []string{"`folder_id`=VALUES(`folder_id`)", "`notes`=VALUES(`notes`)", "`workspace_id`=VALUES(`workspace_id`)"} {
+				if !strings.Contains(stmt, col) {
+					t.Errorf("upsert does not overwrite %s: %s", col, stmt)
+				}
+			}
+		case strings.HasPrefix(stmt, "INSERT INTO `url_tags`"), strings.HasPrefix(stmt, "INSERT INTO `tags`"):
+			t.Errorf("old associations saved: %s", stmt)
+		}
+	}
+	if deleteTags < 0 || insert < 0 || deleteTags > insert {
+		t.Fatalf("want url_tags deleted before upsert, got %q", d.stmts)
+	}
+	if d.stmts[0] != "BEGIN" || d.stmts[len(d.stmts)-1] != "COMMIT" {
+		t.Errorf("not in one transaction: %q", d.stmts)
+	}
+}
+
+func TestCreateURLsClearsTagsForEveryCode(t *testing.T) {
+	db, d := newRecordingDB(t)
+	repo := NewURLRepo(db)
+
+	urls := []*model.URL{
+		{ShortCode: "aaaaaa", OriginalURL: "https://a.example", ExpiredAt: time.Now()},
+		{ShortCode: "bbbbbb", DomainID: 3, OriginalURL: "https://b.example", ExpiredAt: time.Now()},
+	}
+	if err := repo.CreateURLs(context.Background(), urls); err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range d.stmts {
+		if strings.HasPrefix(stmt, "DELETE FROM `url_tags`") {
+			if !strings.Contains(stmt, "IN ((?,?),(?,?))") {
+				t.Errorf("want both codes matched, got %s", stmt)
+			}
+			return
+		}
+	}
+	t.Fatalf("url_tags not cleared: %q", d.stmts)
+}
+
+func TestUpdateURLDetailsWritesInterstitial(t *testing.T) {
+	db, d := newRecordingDB(t)
+	repo := NewURLRepo(db)
+
+	// 关闭预览页时 false 同样写入
+	url := &model.URL{ID: 1, Title: "t", Interstitial: false}
+	if err := repo.UpdateURLDetails(context.Background(), url); err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range d.stmts {
+		if strings.HasPrefix(stmt, "UPDATE `urls`") {
+			if !strings.Contains(stmt, "`interstitial`=") {
+				t.Errorf("interstitial not updated: %s", stmt)
+			}
+			return
+		}
+	}
+	t.Fatalf("no update statement in %q", d.stmts)
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"github.com/jekyulll/url_shortener/internal/model"
 	"gorm.io/gorm"
@@ -11,8 +12,18 @@ import (
 type UserRepository interface {
 	CreateUser(ctx context.Context, user *model.User) error // GORM 会自动将数据库生成的自增 ID 赋值给传入的结构体对象
 	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
+	GetUserByID(ctx context.Context, id uint64) (*model.User, error)
 	UpdatePasswordByEmail(ctx context.Context, passwordHash string, email string) (uint64, error)
 	IsEmailAvailable(ctx context.Context, email string) (bool, error)
+	UpdateTOTP(ctx context.Context, id uint64, secret string, enabled bool) error
+	UpdateUserLocale(ctx context.Context, id uint64, locale string) error
+	UpdateReminderSettings(ctx context.Context, id uint64, enabled bool) error
+	IncrTokenVersion(ctx context.Context, id uint64) (int64, error)
+
+	// 管理接口
+	SearchUsers(ctx context.Context, q string, limit, offset int) ([]model.User, int64, error)
+	UpdateUserRole(ctx context.Context, id uint64, role string) error
+	UpdateUserDisabled(ctx context.Context, id uint64, disabled bool) error
 }
 
 type userRepositoryIMpl struct {
@@ -34,6 +45,17 @@ func (u *userRepositoryIMpl) GetUserByEmail(ctx context.Context, email string) (
 	return &user, err
 }
 
+// GetUserByID implements UserRepository.
+// 找不到时返回 nil, nil
+func (u *userRepositoryIMpl) GetUserByID(ctx context.Context, id uint64) (*model.User, error) {
+	var user model.User
+	err := u.db.WithContext(ctx).First(&user, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &user, err
+}
+
 // IsEmailAvailable implements UserRepository.
 func (u *userRepositoryIMpl) IsEmailAvailable(ctx context.Context, email string) (bool, error) {
 	var count int64
@@ -69,6 +91,90 @@ func (r *userRepositoryIMpl) UpdatePasswordByEmail(ctx context.Context, password
 	return user.ID, err
 }
 
+// UpdateTOTP implements UserRepository.
+func (r *userRepositoryIMpl) UpdateTOTP(ctx context.Context, id uint64, secret string, enabled bool) error {
+	return r.db.WithContext(ctx).
+		Model(&model.User{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"totp_secret":  secret,
+			"totp_enabled": enabled,
+		}).Error
+}
+
+// SearchUsers implements UserRepository.
+// q 为空时返回全部用户，否则按邮箱模糊匹配
+func (r *userRepositoryIMpl) SearchUsers(ctx context.Context, q string, limit, offset int) ([]model.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.User{})
+	if q != "" {
+		query = query.Where("email LIKE ?", likePattern(q))
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var users []model.User
+	err := query.Order("id").Limit(limit).Offset(offset).Find(&users).Error
+	return users, total, err
+}
+
+// UpdateUserLocale implements UserRepository.
+func (r *userRepositoryIMpl) UpdateUserLocale(ctx context.Context, id uint64, locale string) error {
+	return r.db.WithContext(ctx).
+		Model(&model.User{}).
+		Where("id = ?", id).
+		Update("locale", locale).Error
+}
+
+// UpdateReminderSettings implements UserRepository.
+func (r *userRepositoryIMpl) UpdateReminderSettings(ctx context.Context, id uint64, enabled bool) error {
+	return r.db.WithContext(ctx).
+		Model(&model.User{}).
+		Where("id = ?", id).
+		Update("expiry_reminders", enabled).Error
+}
+
+// IncrTokenVersion implements UserRepository.
+// 版本号加一并返回新的版本号，用户不存在时返回 gorm.ErrRecordNotFound
+func (r *userRepositoryIMpl) IncrTokenVersion(ctx context.Context, id uint64) (int64, error) {
+	var user model.User
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.User{}).
+			Where("id = ?", id).
+			Update("token_version", gorm.Expr("token_version + 1"))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Select("token_version").Where("id = ?", id).Take(&user).Error
+	})
+	return user.TokenVersion, err
+}
+
+// UpdateUserRole implements UserRepository.
+func (r *userRepositoryIMpl) UpdateUserRole(ctx context.Context, id uint64, role string) error {
+	return r.db.WithContext(ctx).
+		Model(&model.User{}).
+		Where("id = ?", id).
+		Update("role", role).Error
+}
+
+// UpdateUserDisabled implements UserRepository.
+func (r *userRepositoryIMpl) UpdateUserDisabled(ctx context.Context, id uint64, disabled bool) error {
+	return r.db.WithContext(ctx).
+		Model(&model.User{}).
+		Where("id = ?", id).
+		Update("disabled", disabled).Error
+}
+
+// likePattern 转义 LIKE 通配符后做包含匹配
+func likePattern(q string) string {
+	q = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(q)
+	return "%" + q + "%"
+}
+
 func NewUserRepo(db *gorm.DB) *userRepositoryIMpl {
 	return &userRepositoryIMpl{
 		db: db,
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jekyulll/url_shortener/internal/model"
+	"gorm.io/gorm"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *model.WebhookSubscription) error
+	GetSubscriptionsByUserID(ctx context.Context, userID uint64) ([]model.WebhookSubscription, error)
+	GetSubscriptionsByIDs(ctx context.Context, ids []uint64) ([]model.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, userID, id uint64) (*model.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, sub *model.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, userID, id uint64) error
+
+	CreateDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error
+	GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]model.WebhookDelivery, error)
+	UpdateDeliveryAttempt(ctx context.Context, delivery *model.WebhookDelivery) error
+	GetDeliveries(ctx context.Context, subscriptionID uint64, status string, limit, offset int) ([]model.WebhookDelivery, int64, error)
+	RequeueDelivery(ctx context.Context, subscriptionID, id uint64, now time.Time) error
+	DeleteDeliveries(ctx context.Context, before time.Time) error
+}
+
+type webhookRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepo(db *gorm.DB) *webhookRepositoryImpl {
+	return &webhookRepositoryImpl{
+		db: db,
+	}
+}
+
+// CreateSubscription implements WebhookRepository.
+func (r *webhookRepositoryImpl) CreateSubscription(ctx context.Context, sub *model.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Create(sub).Error
+}
+
+// GetSubscriptionsByUserID implements WebhookRepository.
+func (r *webhookRepositoryImpl) GetSubscriptionsByUserID(ctx context.Context, userID uint64) ([]model.WebhookSubscription, error) {
+	var subs []model.WebhookSubscription
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("id").
+		Find(&subs).Error
+	return subs, err
+}
+
+// GetSubscriptionsByIDs implements WebhookRepository.
+func (r *webhookRepositoryImpl) GetSubscriptionsByIDs(ctx context.Context, ids []uint64) ([]model.WebhookSubscription, error) {
+	var subs []model.WebhookSubscription
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&subs).Error
+	return subs, err
+}
+
+// GetSubscription implements WebhookRepository.
+// 不存在或不属于该用户时返回 nil, nil
+func (r *webhookRepositoryImpl) GetSubscription(ctx context.Context, userID, id uint64) (*model.WebhookSubscription, error) {
+	var sub model.WebhookSubscription
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&sub).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &sub, err
+}
+
+// UpdateSubscription implements WebhookRepository.
+func (r *webhookRepositoryImpl) UpdateSubscription(ctx context.Context, sub *model.WebhookSubscription) error {
+	return r.db.WithContext(ctx).
+		Model(sub).
+		Select("url", "events", "active").
+		Updates(sub).Error
+}
+
+// DeleteSubscription implements WebhookRepository.
+// 投递记录随外键一并删除，不属于该用户时返回 gorm.ErrRecordNotFound
+func (r *webhookRepositoryImpl) DeleteSubscription(ctx context.Context, userID, id uint64) error {
+	res := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&model.WebhookSubscription{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CreateDeliveries implements WebhookRepository.
+func (r *webhookRepositoryImpl) CreateDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Create(&deliveries).Error
+}
+
+// GetDueDeliveries implements WebhookRepository.
+// 到了投递时间的推送，先入先出
+func (r *webhookRepositoryImpl) GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", model.WebhookPending, now).
+		Order("id").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// UpdateDeliveryAttempt implements WebhookRepository.
+// 保存一次投递的结果
+func (r *webhookRepositoryImpl) UpdateDeliveryAttempt(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return r.db.WithContext(ctx).
+		Model(delivery).
+		Select("status", "attempts", "next_attempt_at", "response_status", "last_error", "delivered_at").
+		Updates(delivery).Error
+}
+
+// GetDeliveries implements WebhookRepository.
+// status 为空时不过滤，新的在前
+func (r *webhookRepositoryImpl) GetDeliveries(ctx context.Context, subscriptionID uint64, status string, limit, offset int) ([]model.WebhookDelivery, int64, error) {
+	query := r.db.WithContext(ctx).
+		Model(&model.WebhookDelivery{}).
+		Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var deliveries []model.WebhookDelivery
+	err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+// RequeueDelivery implements WebhookRepository.
+// 任意状态的推送都可以重放，不属于该订阅时返回 gorm.ErrRecordNotFound
+func (r *webhookRepositoryImpl) RequeueDelivery(ctx context.Context, subscriptionID, id uint64, now time.Time) error {
+	res := r.db.WithContext(ctx).
+		Model(&model.WebhookDelivery{}).
+		Where("id = ? AND subscription_id = ?", id, subscriptionID).
+		Updates(map[string]any{
+			"status":          model.WebhookPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteDeliveries implements WebhookRepository.
+// 删除 before 之前创建且不再投递的记录
+func (r *webhookRepositoryImpl) DeleteDeliveries(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).
+		Where("status <> ? AND created_at < ?", model.WebhookPending, before).
+		Delete(&model.WebhookDelivery{}).Error
+}
+
+var _ WebhookRepository = (*webhookRepositoryImpl)(nil)
@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jekyulll/url_shortener/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WorkspaceRepository interface {
+	CreateWorkspace(ctx context.Context, workspace *model.Workspace, ownerID uint64) error
+	GetWorkspaceByID(ctx context.Context, id uint64) (*model.Workspace, error)
+	EnsurePersonalWorkspace(ctx context.Context, userID uint64) (*model.Workspace, error)
+	UpdateWorkspaceName(ctx context.Context, id uint64, name string) error
+	DeleteWorkspace(ctx context.Context, id uint64) error
+	CountURLsByWorkspaceID(ctx context.Context, id uint64) (int64, error)
+
+	// 成员
+	GetMember(ctx context.Context, workspaceID, userID uint64) (*model.WorkspaceMember, error)
+	GetMembers(ctx context.Context, workspaceID uint64) ([]model.WorkspaceMember, error)
+	GetMembershipsByUserID(ctx context.Context, userID uint64) ([]model.WorkspaceMember, error)
+	UpdateMemberRole(ctx context.Context, workspaceID, userID uint64, role string) error
+	DeleteMember(ctx context.Context, workspaceID, userID uint64) error
+	CountOwners(ctx context.Context, workspaceID uint64) (int64, error)
+
+	// 邀请
+	CreateInvite(ctx context.Context, invite *model.WorkspaceInvite) error
+	GetInviteByHash(ctx context.Context, hash string) (*model.WorkspaceInvite, error)
+	AcceptInvite(ctx context.Context, invite *model.WorkspaceInvite, userID uint64) error
+}
+
+type workspaceRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewWorkspaceRepo(db *gorm.DB) *workspaceRepositoryImpl {
+	return &workspaceRepositoryImpl{
+		db: db,
+	}
+}
+
+// CreateWorkspace implements WorkspaceRepository.
+// 创建者成为第一个 owner
+func (r *workspaceRepositoryImpl) CreateWorkspace(ctx context.Context, workspace *model.Workspace, ownerID uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(workspace).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.WorkspaceMember{
+			WorkspaceID: workspace.ID,
+			UserID:      ownerID,
+			Role:        model.WorkspaceOwner,
+		}).Error
+	})
+}
+
+// GetWorkspaceByID implements WorkspaceRepository.
+// 找不到时返回 nil, nil
+func (r *workspaceRepositoryImpl) GetWorkspaceByID(ctx context.Context, id uint64) (*model.Workspace, error) {
+	var workspace model.Workspace
+	err := r.db.WithContext(ctx).First(&workspace, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &workspace, err
+}
+
+// EnsurePersonalWorkspace implements WorkspaceRepository.
+// 返回用户的个人工作区，不存在时创建，并发创建由 personal_user_id 的唯一索引保证只有一个
+func (r *workspaceRepositoryImpl) EnsurePersonalWorkspace(ctx context.Context, userID uint64) (*model.Workspace, error) {
+	var workspace model.Workspace
+	err := r.db.WithContext(ctx).Where("personal_user_id = ?", userID).First(&workspace).Error
+	if err == nil {
+		return &workspace, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		created := model.Workspace{Name: model.PersonalWorkspaceName, PersonalUserID: &userID}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&created)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		workspace = created
+		return tx.Create(&model.WorkspaceMember{
+			WorkspaceID: created.ID,
+			UserID:      userID,
+			Role:        model.WorkspaceOwner,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if workspace.ID == 0 {
+		// 被并发的请求抢先创建
+		err = r.db.WithContext(ctx).Where("personal_user_id = ?", userID).First(&workspace).Error
+	}
+	return &workspace, err
+}
+
+// UpdateWorkspaceName implements WorkspaceRepository.
+func (r *workspaceRepositoryImpl) UpdateWorkspaceName(ctx context.Context, id uint64, name string) error {
+	return r.db.WithContext(ctx).
+		Model(&model.Workspace{}).
+		Where("id = ?", id).
+		Update("name", name).Error
+}
+
+// DeleteWorkspace implements WorkspaceRepository.
+// 成员和邀请由外键级联删除
+func (r *workspaceRepositoryImpl) DeleteWorkspace(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Delete(&model.Workspace{}, id).Error
+}
+
+// CountURLsByWorkspaceID implements WorkspaceRepository.
+// 回收站中的短链接同样计入
+func (r *workspaceRepositoryImpl) CountURLsByWorkspaceID(ctx context.Context, id uint64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&model.URL{}).
+		Where("workspace_id = ?", id).
+		Count(&count).Error
+	return count, err
+}
+
+// GetMember implements WorkspaceRepository.
+// 不是成员时返回 nil, nil
+func (r *workspaceRepositoryImpl) GetMember(ctx context.Context, workspaceID, userID uint64) (*model.WorkspaceMember, error) {
+	var member model.WorkspaceMember
+	err := r.db.WithContext(ctx).
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &member, err
+}
+
+// GetMembers implements WorkspaceRepository.
+func (r *workspaceRepositoryImpl) GetMembers(ctx context.Context, workspaceID uint64) ([]model.WorkspaceMember, error) {
+	var members []model.WorkspaceMember
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("workspace_id = ?", workspaceID).
+		Order("created_at").
+		Find(&members).Error
+	return members, err
+}
+
+// GetMembershipsByUserID implements WorkspaceRepository.
+func (r *workspaceRepositoryImpl) GetMembershipsByUserID(ctx context.Context, userID uint64) ([]model.WorkspaceMember, error) {
+	var members []model.WorkspaceMember
+	err := r.db.WithContext(ctx).
+		Preload("Workspace").
+		Where("user_id = ?", userID).
+		Order("workspace_id").
+		Find(&members).Error
+	return members, err
+}
+
+// UpdateMemberRole implements WorkspaceRepository.
+func (r *workspaceRepositoryImpl) UpdateMemberRole(ctx context.Context, workspaceID, userID uint64, role string) error {
+	return r.db.WithContext(ctx).
+		Model(&model.WorkspaceMember{}).
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		Update("role", role).Error
+}
+
+// DeleteMember implements WorkspaceRepository.
+func (r *workspaceRepositoryImpl) DeleteMember(ctx context.Context, workspaceID, userID uint64) error {
+	return r.db.WithContext(ctx).
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		Delete(&model.WorkspaceMember{}).Error
+}
+
+// CountOwners implements WorkspaceRepository.
+func (r *workspaceRepositoryImpl) CountOwners(ctx context.Context, workspaceID uint64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.WorkspaceMember{}).
+		Where("workspace_id = ? AND role = ?", workspaceID, model.WorkspaceOwner).
+		Count(&count).Error
+	return count, err
+}
+
+// CreateInvite implements WorkspaceRepository.
+// 同一邮箱尚未接受的旧邀请作废
+func (r *workspaceRepositoryImpl) CreateInvite(ctx context.Context, invite *model.WorkspaceInvite) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Where("workspace_id = ? AND email = ? AND accepted_at IS NULL", invite.WorkspaceID, invite.Email).
+			Delete(&model.WorkspaceInvite{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(invite).Error
+	})
+}
+
+// GetInviteByHash implements WorkspaceRepository.
+// 找不到时返回 nil, nil
+func (r *workspaceRepositoryImpl) GetInviteByHash(ctx context.Context, hash string) (*model.WorkspaceInvite, error) {
+	var invite model.WorkspaceInvite
+	err := r.db.WithContext(ctx).
+		Preload("Workspace").
+		Where("token_hash = ?", hash).
+		First(&invite).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &invite, err
+}
+
+// AcceptInvite implements WorkspaceRepository.
+// 邀请只能接受一次，已是成员时保留原有角色
+func (r *workspaceRepositoryImpl) AcceptInvite(ctx context.Context, invite *model.WorkspaceInvite, userID uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.WorkspaceInvite{}).
+			Where("id = ? AND accepted_at IS NULL AND expires_at > ?", invite.ID, time.Now()).
+			Update("accepted_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.WorkspaceMember{
+			WorkspaceID: invite.WorkspaceID,
+			UserID:      userID,
+			Role:        invite.Role,
+		}).Error
+	})
+}
+
+var _ WorkspaceRepository = (*workspaceRepositoryImpl)(nil)
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/repository"
+)
+
+const defaultAdminPageSize = 20
+
+// AdminService 管理员和审核员使用的用户、短链接管理，
+// 短链接相关操作交给 URLService 处理缓存和事件推送，会话吊销复用 UserService
+type AdminService struct {
+	users    repository.UserRepository
+	urls     *URLService
+	sessions *UserService
+}
+
+func NewAdminService(users repository.UserRepository, urls *URLService, sessions *UserService) *AdminService {
+	return &AdminService{
+		users:    users,
+		urls:     urls,
+		sessions: sessions,
+	}
+}
+
+// SearchUsers implements api.AdminServicer.
+func (s *AdminService) SearchUsers(ctx context.Context, req dto.AdminSearchRequest) (*dto.AdminUsersResponse, error) {
+	limit, offset := adminPage(req)
+	users, total, err := s.users.SearchUsers(ctx, req.Q, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]dto.AdminUser, len(users))
+	for i, u := range users {
+		items[i] = dto.AdminUser{
+			ID:          u.ID,
+			Email:       u.Email,
+			Role:        u.Role,
+			Disabled:    u.Disabled,
+			TOTPEnabled: u.TOTPEnabled,
+			CreatedAt:   u.CreatedAt,
+		}
+	}
+	return &dto.AdminUsersResponse{Items: items, Total: total}, nil
+}
+
+// SetUserRole implements api.AdminServicer.
+// 角色变化后吊销该用户的全部会话，重新登录后生效
+func (s *AdminService) SetUserRole(ctx context.Context, req dto.SetUserRoleRequest) error {
+	if req.ID == uint64(req.ActorID) {
+		return ErrModifySelf
+	}
+	if err := s.ensureUser(ctx, req.ID); err != nil {
+		return err
+	}
+	if err := s.users.UpdateUserRole(ctx, req.ID, req.Role); err != nil {
+		return err
+	}
+	return s.sessions.RevokeAllSessions(ctx, int(req.ID))
+}
+
+// SetUserDisabled implements api.AdminServicer.
+// 停用时吊销全部会话，API Key 在校验时拒绝
+func (s *AdminService) SetUserDisabled(ctx context.Context, req dto.SetUserDisabledRequest) error {
+	if req.ID == uint64(req.ActorID) {
+		return ErrModifySelf
+	}
+	if err := s.ensureUser(ctx, req.ID); err != nil {
+		return err
+	}
+	if err := s.users.UpdateUserDisabled(ctx, req.ID, req.Disabled); err != nil {
+		return err
+	}
+	if !req.Disabled {
+		return nil
+	}
+	return s.sessions.RevokeAllSessions(ctx, int(req.ID))
+}
+
+// SearchURLs implements api.AdminServicer.
+func (s *AdminService) SearchURLs(ctx context.Context, req dto.AdminSearchRequest) (*dto.AdminURLsResponse, error) {
+	limit, offset := adminPage(req)
+	items, total, err := s.urls.SearchURLs(ctx, req.Q, req.UserID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.AdminURLsResponse{Items: items, Total: total}, nil
+}
+
+// ForceExpireURL implements api.AdminServicer.
+// 立即过期，之后由定时任务按正常流程清理
+func (s *AdminService) ForceExpireURL(ctx context.Context, req dto.AdminURLRequest) error {
+	return s.urls.ForceExpire(ctx, req.Domain, req.Code)
+}
+
+// TransferURL implements api.AdminServicer.
+// 短链接转入目标用户的个人工作区
+func (s *AdminService) TransferURL(ctx context.Context, req dto.TransferURLRequest) error {
+	if err := s.ensureUser(ctx, req.ToUserID); err != nil {
+		return err
+	}
+	return s.urls.Transfer(ctx, req.Domain, req.Code, req.ToUserID)
+}
+
+func (s *AdminService) ensureUser(ctx context.Context, id uint64) error {
+	user, err := s.users.GetUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func adminPage(req dto.AdminSearchRequest) (limit, offset int) {
+	limit = req.Size
+	if limit == 0 {
+		limit = defaultAdminPageSize
+	}
+	page := req.Page
+	if page == 0 {
+		page = 1
+	}
+	return limit, (page - 1) * limit
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/model"
+	"github.com/jekyulll/url_shortener/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	apiKeyPrefix       = "usk_" // 便于在日志、代码仓库中识别泄露的 key
+	apiKeyDisplayLen   = 12     // 列表中展示的明文长度
+	apiKeyTouchMinimum = time.Minute
+)
+
+type APIKeyService struct {
+	repo  repository.APIKeyRepository
+	users repository.UserRepository
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository, users repository.UserRepository) *APIKeyService {
+	return &APIKeyService{
+		repo:  repo,
+		users: users,
+	}
+}
+
+// CreateAPIKey implements api.APIKeyServicer.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, req dto.CreateAPIKeyRequest) (*dto.CreateAPIKeyResponse, error) {
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	raw := apiKeyPrefix + secret
+	key := &model.APIKey{
+		UserID:  uint64(req.UserID),
+		Name:    req.Name,
+		Prefix:  raw[:apiKeyDisplayLen],
+		KeyHash: hashToken(raw),
+		Scopes:  normalizeScopes(req.Scopes),
+	}
+	if req.ExpiresInDays != nil {
+		expiresAt := time.Now().Add(time.Duration(*req.ExpiresInDays) * 24 * time.Hour)
+		key.ExpiresAt = &expiresAt
+	}
+	if err := s.repo.CreateAPIKey(ctx, key); err != nil {
+		return nil, err
+	}
+	return &dto.CreateAPIKeyResponse{
+		APIKeyResponse: toAPIKeyDTO(key),
+		Key:            raw,
+	}, nil
+}
+
+// GetAPIKeys implements api.APIKeyServicer.
+func (s *APIKeyService) GetAPIKeys(ctx context.Context, userID int) ([]dto.APIKeyResponse, error) {
+	keys, err := s.repo.GetAPIKeysByUserID(ctx, uint64(userID))
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]dto.APIKeyResponse, len(keys))
+	for i := range keys {
+		resp[i] = toAPIKeyDTO(&keys[i])
+	}
+	return resp, nil
+}
+
+// UpdateAPIKey implements api.APIKeyServicer.
+func (s *APIKeyService) UpdateAPIKey(ctx context.Context, req dto.UpdateAPIKeyRequest) error {
+	err := s.repo.UpdateAPIKeyName(ctx, uint64(req.UserID), req.ID, req.Name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrAPIKeyNotFound
+	}
+	return err
+}
+
+// RevokeAPIKey implements api.APIKeyServicer.
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, req dto.APIKeyRequest) error {
+	err := s.repo.RevokeAPIKey(ctx, uint64(req.UserID), req.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrAPIKeyNotFound
+	}
+	return err
+}
+
+// Authenticate implements middleware.APIKeyChecker.
+// 校验 key 并检查是否具有 scope 权限，返回 key 所属用户
+func (s *APIKeyService) Authenticate(ctx context.Context, raw, scope string) (userID int, keyID uint64, err error) {
+	if !strings.HasPrefix(raw, apiKeyPrefix) {
+		return 0, 0, ErrInvalidAPIKey
+	}
+	key, err := s.repo.GetAPIKeyByHash(ctx, hashToken(raw))
+	if err != nil {
+		return 0, 0, err
+	}
+	if key == nil || !key.Usable() {
+		return 0, 0, ErrInvalidAPIKey
+	}
+	if !key.HasScope(scope) {
+		return 0, 0, ErrAPIKeyScopeDenied
+	}
+	// 账号被停用后 key 一并失效
+	user, err := s.users.GetUserByID(ctx, key.UserID)
+	if err != nil {
+		return 0, 0, err
+	}
+	if user == nil || user.Disabled {
+		return 0, 0, ErrInvalidAPIKey
+	}
+	// 限制写库频率，last_used 精确到分钟即可
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchMinimum {
+		if err := s.repo.TouchAPIKey(ctx, key.ID, now); err != nil {
+			return 0, 0, err
+		}
+	}
+	return int(key.UserID), key.ID, nil
+}
+
+// 去重并按固定顺序保存
+func normalizeScopes(scopes []string) string {
+	var out []string
+	for _, scope := range []string{model.ScopeRead, model.ScopeWrite} {
+		for _, s := range scopes {
+			if s == scope {
+				out = append(out, scope)
+				break
+			}
+		}
+	}
+	return strings.Join(out, ",")
+}
+
+func toAPIKeyDTO(k *model.APIKey) dto.APIKeyResponse {
+	return dto.APIKeyResponse{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     k.ScopeList(),
+		ExpiresAt:  k.ExpiresAt,
+		RevokedAt:  k.RevokedAt,
+		LastUsedAt: k.LastUsedAt,
+		CreatedAt:  k.CreatedAt,
+	}
+}
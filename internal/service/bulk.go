@@ -0,0 +1,416 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/model"
+	"github.com/jekyulll/url_shortener/internal/repository"
+	"github.com/jekyulll/url_shortener/pkg/ratelimit"
+)
+
+const (
+	// 同步请求的行数上限，更多的行需要创建后台任务
+	maxBulkSyncRows = 1000
+	maxBulkJobRows  = 100000
+	// 每个事务写入的行数
+	bulkBatchSize       = 100
+	bulkJobPollInterval = 5 * time.Second
+	// 每次处理任务的时间上限，剩下的行留到下次继续
+	bulkJobTimeBudget = 4 * time.Minute
+	// 已完成的任务保留多久，期间可以查询结果
+	bulkJobRetention = 7 * 24 * time.Hour
+	// 同一批连续保存失败这么多次后按失败处理，继续后面的行
+	maxBulkBatchAttempts = 5
+)
+
+// 写库失败时每行返回的错误，具体原因只记录在日志中
+const bulkSaveFailed = "failed to save, please retry"
+
+// BulkQuota 按行数限制每个用户批量创建的速度，Limiter 为 nil 时不限制
+type BulkQuota struct {
+	Limiter ratelimit.Limiter
+	Rule    ratelimit.Rule
+}
+
+// BulkService 批量创建短链接，每行的校验和短码分配复用 URLService.CreateURL 的逻辑，
+// 按批在事务中写入。行数较多时创建后台任务，由定时任务调用 ProcessJobs 处理
+type BulkService struct {
+	urls     *URLService
+	jobs     repository.BulkJobRepository
+	quota    BulkQuota
+	validate *validator.Validate
+	wake     chan struct{}
+}
+
+func NewBulkService(urls *URLService, jobs repository.BulkJobRepository, quota BulkQuota) *BulkService {
+	return &BulkService{
+		urls:     urls,
+		jobs:     jobs,
+		quota:    quota,
+		validate: validator.New(),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// CreateURLs implements api.BulkServicer.
+// 同步处理，某一行失败不影响其他行
+func (s *BulkService) CreateURLs(ctx context.Context, req dto.BulkCreateURLRequest) (*dto.BulkCreateURLResponse, error) {
+	if len(req.Items) == 0 {
+		return nil, ErrBulkEmpty
+	}
+	if len(req.Items) > maxBulkSyncRows {
+		return nil, ErrBulkTooLarge
+	}
+	target, err := s.urls.resolveTarget(ctx, dto.CreateURLRequest{
+		UserID:      req.UserID,
+		WorkspaceID: req.WorkspaceID,
+		Domain:      req.Domain,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.charge(ctx, req.UserID, len(req.Items)); err != nil {
+		return nil, err
+	}
+	resp := &dto.BulkCreateURLResponse{
+		Total: len(req.Items),
+		Items: make([]dto.BulkURLResult, 0, len(req.Items)),
+	}
+	for start := 0; start < len(req.Items); start += bulkBatchSize {
+		items := req.Items[start:min(start+bulkBatchSize, len(req.Items))]
+		results, urls := s.prepareBatch(ctx, req.UserID, target, items, start+1)
+		// 整批都没有通过校验时无需写库
+		if len(urls) > 0 {
+			if err := s.urls.repo.CreateURLs(ctx, urls); err != nil {
+				log.Printf("failed to save bulk urls: %v", err)
+				failBatch(results)
+			} else {
+				s.createdBatch(ctx, urls, target.host)
+			}
+		}
+		resp.Items = append(resp.Items, results...)
+	}
+	for _, r := range resp.Items {
+		if r.Error == "" {
+			resp.Succeeded++
+		}
+	}
+	resp.Failed = resp.Total - resp.Succeeded
+	return resp, nil
+}
+
+// CreateJob implements api.BulkServicer.
+// 创建时只检查域名和工作区权限，每行的结果通过 GetJob 查询
+func (s *BulkService) CreateJob(ctx context.Context, req dto.BulkCreateURLRequest) (*dto.BulkJob, error) {
+	if len(req.Items) == 0 {
+		return nil, ErrBulkEmpty
+	}
+	if len(req.Items) > maxBulkJobRows {
+		return nil, ErrBulkTooLarge
+	}
+	target, err := s.urls.resolveTarget(ctx, dto.CreateURLRequest{
+		UserID:      req.UserID,
+		WorkspaceID: req.WorkspaceID,
+		Domain:      req.Domain,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.charge(ctx, req.UserID, len(req.Items)); err != nil {
+		return nil, err
+	}
+	job := &model.BulkJob{
+		UserID:      uint64(req.UserID),
+		WorkspaceID: target.workspaceID,
+		Domain:      req.Domain,
+		Status:      model.BulkJobPending,
+		Total:       len(req.Items),
+	}
+	rows := make([]model.BulkJobRow, len(req.Items))
+	for i, item := range req.Items {
+		rows[i] = model.BulkJobRow{
+			Row:         i + 1,
+			OriginalURL: item.OriginalURL,
+			CustomCode:  item.CustomCode,
+			Duration:    item.Duration,
+		}
+	}
+	if err := s.jobs.CreateJob(ctx, job, rows); err != nil {
+		return nil, err
+	}
+	// 通知后台任务尽快处理，已有通知未处理时不重复发送
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	resp := toBulkJobDTO(job)
+	return &resp, nil
+}
+
+// charge 按提交的行数扣除额度，超过单次上限时返回 ErrBulkTooLarge；限流器出错时放行
+func (s *BulkService) charge(ctx context.Context, userID, rows int) error {
+	if s.quota.Limiter == nil {
+		return nil
+	}
+	rule := s.quota.Rule
+	if rows > rule.Limit {
+		return fmt.Errorf("%w: at most %d rows per %s", ErrBulkTooLarge, rule.Limit, rule.Window)
+	}
+	rule.Cost = rows
+	res, err := s.quota.Limiter.Allow(ctx, fmt.Sprintf("bulk_rows:user:%d", userID), rule)
+	if err != nil {
+		log.Printf("bulk quota: %v", err)
+		return nil
+	}
+	if !res.Allowed {
+		return &RetryAfterError{Err: ErrBulkQuota, RetryAfter: res.RetryAfter}
+	}
+	return nil
+}
+
+// GetJob implements api.BulkServicer.
+func (s *BulkService) GetJob(ctx context.Context, req dto.GetBulkJobRequest) (*dto.GetBulkJobResponse, error) {
+	job, err := s.jobs.GetJob(ctx, uint64(req.UserID), req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrBulkJobNotFound
+	}
+	size := req.Size
+	if size <= 0 {
+		size = bulkBatchSize
+	}
+	page := max(req.Page, 1)
+	rows, total, err := s.jobs.GetJobResults(ctx, job.ID, req.FailedOnly, size, (page-1)*size)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]dto.BulkURLResult, 0, len(rows))
+	for _, row := range rows {
+		// 尚未处理的行没有结果
+		if row.Row > job.Processed {
+			break
+		}
+		results = append(results, dto.BulkURLResult{
+			Row:         row.Row,
+			OriginalURL: row.OriginalURL,
+			ShortURL:    row.ShortURL,
+			Error:       row.Error,
+		})
+	}
+	return &dto.GetBulkJobResponse{
+		BulkJob:      toBulkJobDTO(job),
+		Results:      results,
+		ResultsTotal: total,
+	}, nil
+}
+
+// Wake 有新任务时可读
+func (s *BulkService) Wake() <-chan struct{} {
+	return s.wake
+}
+
+func (s *BulkService) PollInterval() time.Duration {
+	return bulkJobPollInterval
+}
+
+// ProcessJobs 按创建顺序处理未完成的任务，由后台任务调用
+func (s *BulkService) ProcessJobs(ctx context.Context) error {
+	deadline := time.Now().Add(bulkJobTimeBudget)
+	for time.Now().Before(deadline) {
+		job, err := s.jobs.GetNextJob(ctx)
+		if err != nil {
+			return err
+		}
+		if job == nil {
+			return nil
+		}
+		if err := s.processJob(ctx, job, deadline); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processJob 从上次处理到的行继续，每批的短链接、行结果和进度在同一事务中保存
+func (s *BulkService) processJob(ctx context.Context, job *model.BulkJob, deadline time.Time) error {
+	// 创建任务后权限可能已变化，失去权限时剩余的行都失败
+	target, targetErr := s.urls.resolveTarget(ctx, dto.CreateURLRequest{
+		UserID:      int(job.UserID),
+		WorkspaceID: job.WorkspaceID,
+		Domain:      job.Domain,
+	})
+	job.Status = model.BulkJobRunning
+	for time.Now().Before(deadline) {
+		rows, err := s.jobs.GetJobRows(ctx, job.ID, job.Processed, bulkBatchSize)
+		if err != nil {
+			return err
+		}
+		items := make([]dto.BulkURLItem, len(rows))
+		for i, row := range rows {
+			items[i] = dto.BulkURLItem{
+				OriginalURL: row.OriginalURL,
+				CustomCode:  row.CustomCode,
+				Duration:    row.Duration,
+			}
+		}
+		var results []dto.BulkURLResult
+		var urls []*model.URL
+		if targetErr != nil {
+			results = make([]dto.BulkURLResult, len(rows))
+			for i := range results {
+				results[i].Error = targetErr.Error()
+			}
+		} else if len(rows) > 0 {
+			results, urls = s.prepareBatch(ctx, int(job.UserID), target, items, rows[0].Row)
+		}
+		for i := range rows {
+			rows[i].ShortURL = results[i].ShortURL
+			rows[i].Error = results[i].Error
+			if results[i].Error == "" {
+				job.Succeeded++
+			} else {
+				job.Failed++
+			}
+		}
+		if len(rows) > 0 {
+			job.Processed = rows[len(rows)-1].Row
+		}
+		if len(rows) < bulkBatchSize {
+			now := time.Now()
+			job.Status = model.BulkJobDone
+			job.FinishedAt = &now
+		}
+		// 保存失败时进度不变，下次从这一批重新开始；连续失败多次后这一批按失败处理
+		attempts := job.Attempts
+		job.Attempts = 0
+		if err := s.jobs.SaveJobBatch(ctx, job, rows, urls); err != nil {
+			job.Attempts = attempts + 1
+			if job.Attempts < maxBulkBatchAttempts {
+				return s.retryBatch(ctx, job, err)
+			}
+			log.Printf("bulk job %d: giving up on the batch ending at row %d after %d attempts: %v", job.ID, job.Processed, job.Attempts, err)
+			failJobRows(job, rows)
+			urls = nil
+			job.Attempts = 0
+			if err := s.jobs.SaveJobBatch(ctx, job, rows, nil); err != nil {
+				return s.failJob(ctx, job, err)
+			}
+		}
+		s.createdBatch(ctx, urls, target.host)
+		if job.Status == model.BulkJobDone {
+			return nil
+		}
+	}
+	return nil
+}
+
+// retryBatch 记录这一批的失败次数，下次处理时排在其他任务之后
+func (s *BulkService) retryBatch(ctx context.Context, job *model.BulkJob, cause error) error {
+	if err := s.jobs.UpdateJobStatus(ctx, job); err != nil {
+		log.Printf("bulk job %d: failed to record attempt: %v", job.ID, err)
+	}
+	return cause
+}
+
+// failJob 连失败的结果也无法保存时放弃整个任务，剩余的行不再处理
+func (s *BulkService) failJob(ctx context.Context, job *model.BulkJob, cause error) error {
+	now := time.Now()
+	job.Status = model.BulkJobFailed
+	job.FinishedAt = &now
+	if err := s.jobs.UpdateJobStatus(ctx, job); err != nil {
+		log.Printf("bulk job %d: failed to mark as failed: %v", job.ID, err)
+	}
+	return cause
+}
+
+// prepareBatch 逐行校验并分配短码，返回每行的结果和待写入的短链接，
+// firstRow 为第一行的行号
+func (s *BulkService) prepareBatch(ctx context.Context, userID int, target urlTarget, items []dto.BulkURLItem, firstRow int) ([]dto.BulkURLResult, []*model.URL) {
+	results := make([]dto.BulkURLResult, len(items))
+	var urls []*model.URL
+	// 同一批中的短码尚未入库，CheckShortCode 查不到，需要单独去重
+	codes := make(map[string]bool)
+	for i, item := range items {
+		results[i] = dto.BulkURLResult{
+			Row:         firstRow + i,
+			OriginalURL: item.OriginalURL,
+		}
+		req := dto.CreateURLRequest{
+			OriginalURL: item.OriginalURL,
+			CustomeCode: item.CustomCode,
+			Duration:    item.Duration,
+			UserID:      userID,
+			WorkspaceID: target.workspaceID,
+		}
+		if err := s.validate.Struct(req); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		u, err := s.urls.prepareURL(ctx, req, target)
+		if err == nil && codes[u.ShortCode] {
+			err = ErrShortCodeTaken
+		}
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		codes[u.ShortCode] = true
+		results[i].ShortURL = s.urls.shortURL(target.host, u.ShortCode)
+		urls = append(urls, u)
+	}
+	return results, urls
+}
+
+// createdBatch 一批短链接入库后写缓存、推送事件
+func (s *BulkService) createdBatch(ctx context.Context, urls []*model.URL, host string) {
+	for _, u := range urls {
+		s.urls.created(ctx, u, host)
+	}
+}
+
+// failBatch 整批写库失败时，原本成功的行改为失败
+func failBatch(results []dto.BulkURLResult) {
+	for i := range results {
+		if results[i].Error == "" {
+			results[i].ShortURL = ""
+			results[i].Error = bulkSaveFailed
+		}
+	}
+}
+
+// failJobRows 与 failBatch 相同，同时修正任务的成功和失败计数
+func failJobRows(job *model.BulkJob, rows []model.BulkJobRow) {
+	for i := range rows {
+		if rows[i].Error == "" {
+			rows[i].ShortURL = ""
+			rows[i].Error = bulkSaveFailed
+			job.Succeeded--
+			job.Failed++
+		}
+	}
+}
+
+// PurgeJobs 删除超过保留期的已完成任务，由定时任务调用
+func (s *BulkService) PurgeJobs(ctx context.Context) error {
+	return s.jobs.DeleteJobs(ctx, time.Now().Add(-bulkJobRetention))
+}
+
+func toBulkJobDTO(job *model.BulkJob) dto.BulkJob {
+	return dto.BulkJob{
+		ID:         job.ID,
+		Status:     job.Status,
+		Total:      job.Total,
+		Processed:  job.Processed,
+		Succeeded:  job.Succeeded,
+		Failed:     job.Failed,
+		CreatedAt:  job.CreatedAt,
+		FinishedAt: job.FinishedAt,
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/jekyulll/url_shortener/internal/model"
+	"github.com/jekyulll/url_shortener/internal/repository"
+)
+
+// urlCursor 短链接列表的翻页位置，编码后作为 next_cursor 返回给客户端。
+// 带上排序方式，换了排序后旧的 cursor 不再有效
+type urlCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	ID    uint64 `json:"i"`
+}
+
+func encodeURLCursor(sort string, desc bool, last *model.URL) string {
+	c := urlCursor{Sort: sort, Desc: desc, ID: last.ID}
+	switch sort {
+	case "created_at":
+		c.Value = last.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case "expired_at":
+		c.Value = last.ExpiredAt.UTC().Format(time.RFC3339Nano)
+	case "views":
+		c.Value = strconv.FormatInt(int64(last.Views), 10)
+	case "short_code":
+		c.Value = last.ShortCode
+	case "title":
+		c.Value = last.Title
+	case "original_url":
+		c.Value = last.OriginalURL
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeURLCursor 校验 cursor 与本次请求的排序方式一致，并把值转换回列的类型
+func decodeURLCursor(s, sort string, desc bool) (*repository.URLCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c urlCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != sort || c.Desc != desc {
+		return nil, ErrInvalidCursor
+	}
+	var value any = c.Value
+	switch sort {
+	case "created_at", "expired_at":
+		value, err = time.Parse(time.RFC3339Nano, c.Value)
+	case "views":
+		value, err = strconv.ParseInt(c.Value, 10, 32)
+	}
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &repository.URLCursor{Value: value, ID: c.ID}, nil
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jekyulll/url_shortener/config"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/model"
+	"github.com/jekyulll/url_shortener/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	verifyRecordPrefix = "_url-shortener."             // TXT 记录名前缀
+	verifyValuePrefix  = "url-shortener-verification=" // TXT 记录值前缀
+)
+
+// TXTResolver 用于验证域名所有权，*net.Resolver 即实现了该接口，测试时可替换
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+type DomainService struct {
+	repo     repository.DomainRepository
+	resolver TXTResolver
+	baseHost string
+}
+
+func NewDomainService(repo repository.DomainRepository, resolver TXTResolver, cfg config.AppConfig) *DomainService {
+	return &DomainService{
+		repo:     repo,
+		resolver: resolver,
+		baseHost: baseHostOf(cfg.BaseURL),
+	}
+}
+
+// AddDomain implements api.DomainServicer.
+// 新添加的域名处于未验证状态，需要按返回的 TXT 记录配置 DNS 后调用 VerifyDomain。
+// 未验证的申请不占用域名，避免他人抢先添加后阻止真正的所有者
+func (s *DomainService) AddDomain(ctx context.Context, req dto.AddDomainRequest) (*dto.DomainResponse, error) {
+	host := normalizeHost(req.Host)
+	if host == s.baseHost {
+		return nil, ErrDomainTaken
+	}
+	exist, err := s.repo.GetVerifiedDomainByHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if exist != nil {
+		return nil, ErrDomainTaken
+	}
+	// 重复添加时返回已有的申请
+	own, err := s.repo.GetUserDomainByHost(ctx, uint64(req.UserID), host)
+	if err != nil {
+		return nil, err
+	}
+	if own != nil {
+		return toDomainDTO(own), nil
+	}
+
+	token, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	domain := &model.Domain{
+		UserID:      uint64(req.UserID),
+		Host:        host,
+		VerifyToken: token,
+	}
+	if err := s.repo.CreateDomain(ctx, domain); err != nil {
+		return nil, fmt.Errorf("create domain: %w", err)
+	}
+	return toDomainDTO(domain), nil
+}
+
+// GetDomains implements api.DomainServicer.
+func (s *DomainService) GetDomains(ctx context.Context, userID int) ([]dto.DomainResponse, error) {
+	domains, err := s.repo.GetDomainsByUserID(ctx, uint64(userID))
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]dto.DomainResponse, len(domains))
+	for i := range domains {
+		resp[i] = *toDomainDTO(&domains[i])
+	}
+	return resp, nil
+}
+
+// VerifyDomain implements api.DomainServicer.
+// 验证通过后其他用户对该域名的申请被删除
+func (s *DomainService) VerifyDomain(ctx context.Context, req dto.DomainRequest) (*dto.DomainResponse, error) {
+	domain, err := s.getOwnDomain(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if domain.Verified {
+		return toDomainDTO(domain), nil
+	}
+
+	records, err := s.resolver.LookupTXT(ctx, verifyRecordPrefix+domain.Host)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDomainVerifyFailed, err)
+	}
+	want := verifyValuePrefix + domain.VerifyToken
+	found := false
+	for _, record := range records {
+		if strings.TrimSpace(record) == want {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, ErrDomainVerifyFailed
+	}
+
+	now := time.Now()
+	err = s.repo.VerifyDomain(ctx, domain, now)
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, ErrDomainTaken
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDomainNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	domain.Verified = true
+	domain.VerifiedAt = &now
+	return toDomainDTO(domain), nil
+}
+
+// DeleteDomain implements api.DomainServicer.
+// 域名下还有短链接时不允许删除
+func (s *DomainService) DeleteDomain(ctx context.Context, req dto.DomainRequest) error {
+	domain, err := s.getOwnDomain(ctx, req)
+	if err != nil {
+		return err
+	}
+	cnt, err := s.repo.CountURLsByDomainID(ctx, domain.ID)
+	if err != nil {
+		return err
+	}
+	if cnt > 0 {
+		return ErrDomainInUse
+	}
+	return s.repo.DeleteDomain(ctx, domain.ID)
+}
+
+// 不属于当前用户的域名同样视为不存在
+func (s *DomainService) getOwnDomain(ctx context.Context, req dto.DomainRequest) (*model.Domain, error) {
+	domain, err := s.repo.GetDomainByID(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if domain == nil || domain.UserID != uint64(req.UserID) {
+		return nil, ErrDomainNotFound
+	}
+	return domain, nil
+}
+
+func toDomainDTO(d *model.Domain) *dto.DomainResponse {
+	return &dto.DomainResponse{
+		ID:         d.ID,
+		Host:       d.Host,
+		Verified:   d.Verified,
+		VerifiedAt: d.VerifiedAt,
+		TXTName:    verifyRecordPrefix + d.Host,
+		TXTValue:   verifyValuePrefix + d.VerifyToken,
+		CreatedAt:  d.CreatedAt,
+	}
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// normalizeHost 转小写并去掉端口
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+func baseHostOf(baseURL string) string {
+	u, err := neturl.Parse(baseURL)
+	if err != nil {
+		return ""
+	}
+	return normalizeHost(u.Host)
+}
+
+// domainResolver 按请求 Host 查找已验证的自定义域名，带本地缓存
+type domainResolver struct {
+	repo     repository.DomainRepository
+	hosts    *hostCache
+	baseHost string
+}
+
+func newDomainResolver(repo repository.DomainRepository, baseURL string) *domainResolver {
+	return &domainResolver{
+		repo:     repo,
+		hosts:    newHostCache(time.Minute),
+		baseHost: baseHostOf(baseURL),
+	}
+}
+
+func (r *domainResolver) isBaseHost(host string) bool {
+	return normalizeHost(host) == r.baseHost
+}
+
+// byHost 默认域名、未知或未验证的域名返回 nil
+func (r *domainResolver) byHost(ctx context.Context, host string) (*model.Domain, error) {
+	host = normalizeHost(host)
+	if host == "" || host == r.baseHost {
+		return nil, nil
+	}
+	if d, ok := r.hosts.get(host); ok {
+		return d, nil
+	}
+	d, err := r.repo.GetVerifiedDomainByHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	r.hosts.set(host, d)
+	return d, nil
+}
+
+// hostCache 缓存 Host 到自定义域名的映射（包括未命中的结果），避免每次跳转都查库
+// 域名状态变化后最多 ttl 时间生效
+type hostCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[string]hostEntry
+}
+
+type hostEntry struct {
+	domain   *model.Domain
+	expireAt time.Time
+}
+
+// 防止随意伪造的 Host 头撑爆内存
+const maxHostCacheEntries = 1024
+
+func newHostCache(ttl time.Duration) *hostCache {
+	return &hostCache{
+		ttl:     ttl,
+		entries: make(map[string]hostEntry),
+	}
+}
+
+func (c *hostCache) get(host string) (*model.Domain, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.entries[host]
+	if !ok || time.Now().After(e.expireAt) {
+		return nil, false
+	}
+	return e.domain, true
+}
+
+func (c *hostCache) set(host string, d *model.Domain) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxHostCacheEntries {
+		c.entries = make(map[string]hostEntry)
+	}
+	c.entries[host] = hostEntry{domain: d, expireAt: time.Now().Add(c.ttl)}
+}
+
+var _ TXTResolver = (*net.Resolver)(nil)
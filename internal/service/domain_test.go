@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jekyulll/url_shortener/config"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/model"
+	"gorm.io/gorm"
+)
+
+// stubTXTResolver 按记录名返回 TXT 记录，不在表中的名称解析失败
+type stubTXTResolver map[string][]string
+
+func (r stubTXTResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	records, ok := r[name]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return records, nil
+}
+
+// memDomainRepo 内存中的 DomainRepository，行为与 MySQL 实现一致
+type memDomainRepo struct {
+	domains []*model.Domain
+	urls    map[uint64]int64
+}
+
+func (r *memDomainRepo) CreateDomain(_ context.Context, domain *model.Domain) error {
+	domain.ID = uint64(len(r.domains) + 1)
+	domain.CreatedAt = time.Now()
+	r.domains = append(r.domains, domain)
+	return nil
+}
+
+func (r *memDomainRepo) GetDomainByID(_ context.Context, id uint64) (*model.Domain, error) {
+	for _, d := range r.domains {
+		if d.ID == id {
+			c := *d
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memDomainRepo) GetVerifiedDomainByHost(_ context.Context, host string) (*model.Domain, error) {
+	for _, d := range r.domains {
+		if d.Host == host && d.Verified {
+			c := *d
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memDomainRepo) GetUserDomainByHost(_ context.Context, userID uint64, host string) (*model.Domain, error) {
+	for _, d := range r.domains {
+		if d.Host == host && d.UserID == userID {
+			c := *d
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memDomainRepo) GetDomainsByIDs(context.Context, []uint64) ([]model.Domain, error) {
+	return nil, nil
+}
+
+func (r *memDomainRepo) GetDomainsByUserID(context.Context, uint64) ([]model.Domain, error) {
+	return nil, nil
+}
+
+func (r *memDomainRepo) VerifyDomain(_ context.Context, domain *model.Domain, at time.Time) error {
+	var target *model.Domain
+	for _, d := range r.domains {
+		if d.Host != domain.Host {
+			continue
+		}
+		if d.ID == domain.ID {
+			target = d
+		} else if d.Verified {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	if target == nil {
+		return gorm.ErrRecordNotFound
+	}
+	target.Verified = true
+	target.VerifiedAt = &at
+	kept := r.domains[:0]
+	for _, d := range r.domains {
+		if d.Host != domain.Host || d.Verified {
+			kept = append(kept, d)
+		}
+	}
+	r.domains = kept
+	return nil
+}
+
+func (r *memDomainRepo) DeleteDomain(_ context.Context, id uint64) error {
+	for i, d := range r.domains {
+		if d.ID == id {
+			r.domains = append(r.domains[:i], r.domains[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *memDomainRepo) CountURLsByDomainID(_ context.Context, id uint64) (int64, error) {
+	return r.urls[id], nil
+}
+
+func newTestDomainService(records stubTXTResolver) (*DomainService, *memDomainRepo) {
+	repo := &memDomainRepo{urls: make(map[uint64]int64)}
+	return NewDomainService(repo, records, config.AppConfig{BaseURL: "https://sho.rt"}), repo
+}
+
+func TestVerifyDomain(t *testing.T) {
+	ctx := context.Background()
+	records := stubTXTResolver{}
+	s, _ := newTestDomainService(records)
+
+	d, err := s.AddDomain(ctx, dto.AddDomainRequest{Host: "Links.Example.com.", UserID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Host != "links.example.com" || d.TXTName != "_url-shortener.links.example.com" {
+		t.Fatalf("unexpected domain %+v", d)
+	}
+	req := dto.DomainRequest{ID: d.ID, UserID: 1}
+
+	// 没有记录、记录不匹配时验证失败
+	if _, err := s.VerifyDomain(ctx, req); !errors.Is(err, ErrDomainVerifyFailed) {
+		t.Fatalf("missing record: got %v, want ErrDomainVerifyFailed", err)
+	}
+	records[d.TXTName] = []string{"v=spf1 -all", "url-shortener-verification=wrong"}
+	if _, err := s.VerifyDomain(ctx, req); !errors.Is(err, ErrDomainVerifyFailed) {
+		t.Fatalf("wrong record: got %v, want ErrDomainVerifyFailed", err)
+	}
+
+	// 其他用户不能验证不属于自己的申请
+	if _, err := s.VerifyDomain(ctx, dto.DomainRequest{ID: d.ID, UserID: 2}); !errors.Is(err, ErrDomainNotFound) {
+		t.Fatalf("other user: got %v, want ErrDomainNotFound", err)
+	}
+
+	records[d.TXTName] = append(records[d.TXTName], " "+d.TXTValue+" ")
+	got, err := s.VerifyDomain(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Verified || got.VerifiedAt == nil {
+		t.Fatalf("domain not verified: %+v", got)
+	}
+}
+
+func TestDomainClaims(t *testing.T) {
+	ctx := context.Background()
+	records := stubTXTResolver{}
+	s, repo := newTestDomainService(records)
+
+	// 抢先添加的未验证申请不影响其他用户添加
+	squatter, err := s.AddDomain(ctx, dto.AddDomainRequest{Host: "go.example.com", UserID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner, err := s.AddDomain(ctx, dto.AddDomainRequest{Host: "go.example.com", UserID: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner.ID == squatter.ID || owner.TXTValue == squatter.TXTValue {
+		t.Fatal("claims must have separate tokens")
+	}
+	again, err := s.AddDomain(ctx, dto.AddDomainRequest{Host: "go.example.com", UserID: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != owner.ID {
+		t.Fatalf("re-adding returned claim %d, want %d", again.ID, owner.ID)
+	}
+
+	// 另一个申请的 TXT 记录不能用来验证
+	records[owner.TXTName] = []string{owner.TXTValue}
+	if _, err := s.VerifyDomain(ctx, dto.DomainRequest{ID: squatter.ID, UserID: 1}); !errors.Is(err, ErrDomainVerifyFailed) {
+		t.Fatalf("squatter verify: got %v, want ErrDomainVerifyFailed", err)
+	}
+	if _, err := s.VerifyDomain(ctx, dto.DomainRequest{ID: owner.ID, UserID: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 验证通过后其他申请被删除，域名不能再被添加
+	if d, _ := repo.GetDomainByID(ctx, squatter.ID); d != nil {
+		t.Fatal("losing claim was not dropped")
+	}
+	if _, err := s.AddDomain(ctx, dto.AddDomainRequest{Host: "go.example.com", UserID: 3}); !errors.Is(err, ErrDomainTaken) {
+		t.Fatalf("add verified host: got %v, want ErrDomainTaken", err)
+	}
+	if _, err := s.AddDomain(ctx, dto.AddDomainRequest{Host: "sho.rt", UserID: 3}); !errors.Is(err, ErrDomainTaken) {
+		t.Fatalf("add base host: got %v, want ErrDomainTaken", err)
+	}
+}
+
+func TestDeleteDomainInUse(t *testing.T) {
+	ctx := context.Background()
+	s, repo := newTestDomainService(stubTXTResolver{})
+	d, err := s.AddDomain(ctx, dto.AddDomainRequest{Host: "x.example.com", UserID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := dto.DomainRequest{ID: d.ID, UserID: 1}
+	repo.urls[d.ID] = 1
+	if err := s.DeleteDomain(ctx, req); !errors.Is(err, ErrDomainInUse) {
+		t.Fatalf("got %v, want ErrDomainInUse", err)
+	}
+	repo.urls[d.ID] = 0
+	if err := s.DeleteDomain(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jekyulll/url_shortener/config"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/emails"
+	"github.com/jekyulll/url_shortener/internal/model"
+	"github.com/jekyulll/url_shortener/internal/repository"
+	"github.com/jekyulll/url_shortener/pkg/email"
+	"gorm.io/gorm"
+)
+
+// 未配置时的投递参数
+const (
+	defaultEmailPollInterval = 10 * time.Second
+	defaultEmailMaxAttempts  = 8
+	defaultEmailRetryDelay   = 30 * time.Second
+)
+
+const (
+	maxEmailRetryDelay = time.Hour
+	emailBatchSize     = 50
+	// 已发送的邮件保留多久，便于排查
+	sentEmailRetention = 7 * 24 * time.Hour
+	// last_error 列的长度
+	maxEmailErrorLength = 512
+)
+
+type EmailTransport interface {
+	Send(ctx context.Context, msg email.Message) error
+}
+
+// EmailOutbox 发送邮件时按模板渲染后写入 outbox 表，由后台任务调用 Deliver 投递，
+// 请求不再等待邮件服务器，邮件服务器不可用时也不会丢失
+type EmailOutbox struct {
+	repo         repository.EmailOutboxRepository
+	transport    EmailTransport
+	templates    *emails.Templates
+	pollInterval time.Duration
+	maxAttempts  int
+	retryDelay   time.Duration
+	wake         chan struct{}
+}
+
+func NewEmailOutbox(repo repository.EmailOutboxRepository, transport EmailTransport, templates *emails.Templates, cfg config.EmailConfig) *EmailOutbox {
+	o := &EmailOutbox{
+		repo:         repo,
+		transport:    transport,
+		templates:    templates,
+		pollInterval: cfg.PollInterval,
+		maxAttempts:  cfg.MaxAttempts,
+		retryDelay:   cfg.RetryDelay,
+		wake:         make(chan struct{}, 1),
+	}
+	if o.pollInterval <= 0 {
+		o.pollInterval = defaultEmailPollInterval
+	}
+	if o.maxAttempts <= 0 {
+		o.maxAttempts = defaultEmailMaxAttempts
+	}
+	if o.retryDelay <= 0 {
+		o.retryDelay = defaultEmailRetryDelay
+	}
+	return o
+}
+
+// SendTemplate implements EmailSender.
+// locale 为用户设置的语言或 Accept-Language，入队时即渲染，之后修改模板不影响已入队的邮件
+func (o *EmailOutbox) SendTemplate(email, locale, name string, data any) error {
+	msg, err := o.templates.Render(locale, name, data)
+	if err != nil {
+		return err
+	}
+	e := model.OutboxEmail{
+		Recipient:     email,
+		Subject:       msg.Subject,
+		Body:          msg.Text,
+		HTML:          msg.HTML,
+		Status:        model.EmailPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := o.repo.CreateEmail(context.Background(), &e); err != nil {
+		return fmt.Errorf("failed to queue email: %w", err)
+	}
+	// 通知后台任务尽快投递，已有通知未处理时不重复发送
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Wake 有新邮件入队时可读
+func (o *EmailOutbox) Wake() <-chan struct{} {
+	return o.wake
+}
+
+func (o *EmailOutbox) PollInterval() time.Duration {
+	return o.pollInterval
+}
+
+// Deliver 投递所有到期的邮件，由后台任务调用
+func (o *EmailOutbox) Deliver(ctx context.Context) error {
+	for {
+		emails, err := o.repo.GetDueEmails(ctx, time.Now(), emailBatchSize)
+		if err != nil {
+			return err
+		}
+		for i := range emails {
+			if err := o.deliver(ctx, &emails[i]); err != nil {
+				return err
+			}
+		}
+		if len(emails) < emailBatchSize {
+			return nil
+		}
+	}
+}
+
+// deliver 投递一封邮件并保存结果，只有保存失败时返回错误
+func (o *EmailOutbox) deliver(ctx context.Context, e *model.OutboxEmail) error {
+	err := o.transport.Send(ctx, email.Message{
+		To:      e.Recipient,
+		Subject: e.Subject,
+		Text:    e.Body,
+		HTML:    e.HTML,
+	})
+	now := time.Now()
+	e.Attempts++
+	if err == nil {
+		e.Status = model.EmailSent
+		e.SentAt = &now
+		e.LastError = ""
+	} else {
+		e.LastError = truncateError(err.Error())
+		if e.Attempts >= o.maxAttempts {
+			e.Status = model.EmailDead
+			log.Printf("email %d to %s dead after %d attempts: %v", e.ID, e.Recipient, e.Attempts, err)
+		} else {
+			e.NextAttemptAt = now.Add(backoff(o.retryDelay, e.Attempts, maxEmailRetryDelay))
+		}
+	}
+	return o.repo.UpdateEmailAttempt(ctx, e)
+}
+
+// backoff 第 n 次失败后等待 base * 2^(n-1)，最长 limit
+func backoff(base time.Duration, n int, limit time.Duration) time.Duration {
+	d := base
+	for i := 1; i < n && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+func truncateError(s string) string {
+	if len(s) <= maxEmailErrorLength {
+		return s
+	}
+	return strings.ToValidUTF8(s[:maxEmailErrorLength], "")
+}
+
+// PurgeSent 删除超过保留期的已发送邮件，由定时任务调用
+func (o *EmailOutbox) PurgeSent(ctx context.Context) error {
+	return o.repo.DeleteSentEmails(ctx, time.Now().Add(-sentEmailRetention))
+}
+
+// GetEmails implements api.EmailServicer.
+// 不返回正文，其中可能有验证码
+func (o *EmailOutbox) GetEmails(ctx context.Context, req dto.GetEmailsRequest) (*dto.GetEmailsResponse, error) {
+	size := req.Size
+	if size <= 0 {
+		size = defaultAdminPageSize
+	}
+	page := max(req.Page, 1)
+	emails, total, err := o.repo.GetEmails(ctx, req.Status, size, (page-1)*size)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]dto.OutboxEmail, len(emails))
+	for i, e := range emails {
+		items[i] = dto.OutboxEmail{
+			ID:            e.ID,
+			Recipient:     e.Recipient,
+			Subject:       e.Subject,
+			Status:        e.Status,
+			Attempts:      e.Attempts,
+			NextAttemptAt: e.NextAttemptAt,
+			LastError:     e.LastError,
+			SentAt:        e.SentAt,
+			CreatedAt:     e.CreatedAt,
+		}
+	}
+	return &dto.GetEmailsResponse{Items: items, Total: total}, nil
+}
+
+// RetryEmail implements api.EmailServicer.
+// 重新投递已放弃的邮件，重试次数清零
+func (o *EmailOutbox) RetryEmail(ctx context.Context, id uint64) error {
+	err := o.repo.RequeueEmail(ctx, id, time.Now())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrEmailNotFound
+	}
+	if err != nil {
+		return err
+	}
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+var _ EmailSender = (*EmailOutbox)(nil)
+var _ EmailTransport = (*email.SMTP)(nil)
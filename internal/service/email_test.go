@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jekyulll/url_shortener/config"
+	"github.com/jekyulll/url_shortener/internal/emails"
+	"github.com/jekyulll/url_shortener/internal/model"
+	"github.com/jekyulll/url_shortener/pkg/email"
+	"gorm.io/gorm"
+)
+
+// memOutboxRepo 内存中的 EmailOutboxRepository
+type memOutboxRepo struct {
+	emails []*model.OutboxEmail
+}
+
+func (r *memOutboxRepo) CreateEmail(_ context.Context, e *model.OutboxEmail) error {
+	e.ID = uint64(len(r.emails) + 1)
+	c := *e
+	r.emails = append(r.emails, &c)
+	return nil
+}
+
+func (r *memOutboxRepo) GetDueEmails(_ context.Context, now time.Time, limit int) ([]model.OutboxEmail, error) {
+	var due []model.OutboxEmail
+	for _, e := range r.emails {
+		if e.Status == model.EmailPending && !e.NextAttemptAt.After(now) && len(due) < limit {
+			due = append(due, *e)
+		}
+	}
+	return due, nil
+}
+
+func (r *memOutboxRepo) UpdateEmailAttempt(_ context.Context, e *model.OutboxEmail) error {
+	c := *e
+	r.emails[e.ID-1] = &c
+	return nil
+}
+
+func (r *memOutboxRepo) GetEmails(context.Context, string, int, int) ([]model.OutboxEmail, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *memOutboxRepo) RequeueEmail(_ context.Context, id uint64, now time.Time) error {
+	if id == 0 || id > uint64(len(r.emails)) || r.emails[id-1].Status != model.EmailDead {
+		return gorm.ErrRecordNotFound
+	}
+	e := r.emails[id-1]
+	e.Status, e.Attempts, e.NextAttemptAt = model.EmailPending, 0, now
+	return nil
+}
+
+func (r *memOutboxRepo) DeleteSentEmails(context.Context, time.Time) error {
+	return nil
+}
+
+// flakyTransport 前 fail 次发送失败，之后交给 email.Memory
+type flakyTransport struct {
+	fail int
+	*email.Memory
+}
+
+func (t *flakyTransport) Send(ctx context.Context, msg email.Message) error {
+	if t.fail > 0 {
+		t.fail--
+		return errors.New("451 try again later")
+	}
+	return t.Memory.Send(ctx, msg)
+}
+
+func newTestOutbox(t *testing.T, transport EmailTransport) (*EmailOutbox, *memOutboxRepo) {
+	t.Helper()
+	templates, err := emails.Load("", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &memOutboxRepo{}
+	cfg := config.EmailConfig{MaxAttempts: 3, RetryDelay: time.Minute}
+	return NewEmailOutbox(repo, transport, templates, cfg), repo
+}
+
+// makeDue 让所有待发送的邮件立即到期
+func (r *memOutboxRepo) makeDue() {
+	for _, e := range r.emails {
+		e.NextAttemptAt = time.Now()
+	}
+}
+
+func TestEmailOutboxRetry(t *testing.T) {
+	ctx := context.Background()
+	transport := &flakyTransport{fail: 1, Memory: email.NewMemory()}
+	o, repo := newTestOutbox(t, transport)
+
+	if err := o.SendTemplate("user@example.com", "en", emails.Verification, emails.CodeData{Code: "123456", Minutes: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Deliver(ctx); err != nil {
+		t.Fatal(err)
+	}
+	e := repo.emails[0]
+	if e.Status != model.EmailPending || e.Attempts != 1 || e.LastError == "" {
+		t.Fatalf("after failure: %+v", e)
+	}
+	if d := time.Until(e.NextAttemptAt); d < 50*time.Second || d > time.Minute {
+		t.Fatalf("next attempt in %v, want about 1m", d)
+	}
+
+	// 未到重试时间不投递
+	if err := o.Deliver(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.Messages()) != 0 || repo.emails[0].Attempts != 1 {
+		t.Fatal("email delivered before its retry time")
+	}
+
+	repo.makeDue()
+	if err := o.Deliver(ctx); err != nil {
+		t.Fatal(err)
+	}
+	e = repo.emails[0]
+	if e.Status != model.EmailSent || e.SentAt == nil || e.LastError != "" {
+		t.Fatalf("after retry: %+v", e)
+	}
+	msgs := transport.Messages()
+	if len(msgs) != 1 || msgs[0].To != "user@example.com" || !strings.Contains(msgs[0].Text, "123456") {
+		t.Fatalf("unexpected messages %+v", msgs)
+	}
+}
+
+func TestEmailOutboxDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	transport := &flakyTransport{fail: 3, Memory: email.NewMemory()}
+	o, repo := newTestOutbox(t, transport)
+
+	if err := o.SendTemplate("user@example.com", "en", emails.Verification, emails.CodeData{Code: "123456", Minutes: 5}); err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		repo.makeDue()
+		if err := o.Deliver(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e := repo.emails[0]
+	if e.Status != model.EmailDead || e.Attempts != 3 {
+		t.Fatalf("after max attempts: %+v", e)
+	}
+
+	// 放弃后不再自动投递
+	repo.makeDue()
+	if err := o.Deliver(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if repo.emails[0].Attempts != 3 {
+		t.Fatal("dead email was retried")
+	}
+
+	// 只能重新投递已放弃的邮件
+	if err := o.RetryEmail(ctx, 2); !errors.Is(err, ErrEmailNotFound) {
+		t.Fatalf("retry missing email: got %v, want ErrEmailNotFound", err)
+	}
+	if err := o.RetryEmail(ctx, e.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Deliver(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if e := repo.emails[0]; e.Status != model.EmailSent || e.Attempts != 1 {
+		t.Fatalf("after requeue: %+v", e)
+	}
+	if len(transport.Messages()) != 1 {
+		t.Fatal("requeued email was not sent")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for n, want := range map[int]time.Duration{1: 30 * time.Second, 2: time.Minute, 4: 4 * time.Minute, 20: time.Hour} {
+		if got := backoff(30*time.Second, n, time.Hour); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", n, got, want)
+		}
+	}
+}
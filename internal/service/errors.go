@@ -3,9 +3,102 @@ package service
 import "errors"
 
 var (
-	ErrShortCodeTaken = errors.New("short code already taken")
+	ErrShortCodeTaken    = errors.New("short code already taken")
+	ErrShortCodeReserved = errors.New("short code is reserved or not allowed")
+	ErrURLNotFound       = errors.New("no such short code")
+	ErrURLExpired        = errors.New("short code expired")
+	ErrURLDisabled       = errors.New("short link disabled")
+	ErrExtendLinkInvalid = errors.New("invalid, used or expired extend link")
+	ErrInvalidCursor     = errors.New("invalid or mismatched cursor")
+)
+
+var (
+	ErrDomainNotFound     = errors.New("no such domain or domain not verified")
+	ErrDomainTaken        = errors.New("domain already taken")
+	ErrDomainVerifyFailed = errors.New("domain verification TXT record not found")
+	ErrDomainInUse        = errors.New("domain still has short links")
 )
 
 var ErrUserNameOrPasswordFailed = errors.New("username or password failed")
+var ErrUserNotFound = errors.New("no such user")
+var ErrUserDisabled = errors.New("account disabled")
+var ErrModifySelf = errors.New("cannot change the role or status of your own account")
 var ErrEmailAleadyExist = errors.New("email already exist")
 var ErrEmailCodeNotEqual = errors.New("email code not equal")
+var ErrInvalidLocale = errors.New("invalid locale")
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrTokenRevoked        = errors.New("token revoked")
+)
+
+var (
+	ErrAPIKeyNotFound    = errors.New("no such api key")
+	ErrInvalidAPIKey     = errors.New("invalid, expired or revoked api key")
+	ErrAPIKeyScopeDenied = errors.New("api key lacks the required scope")
+)
+
+var (
+	ErrInvalidPageTemplate = errors.New("invalid page template")
+	ErrPageNotFound        = errors.New("no such landing page")
+)
+
+var (
+	ErrOIDCProviderNotFound = errors.New("no such identity provider")
+	ErrOIDCStateInvalid     = errors.New("invalid or expired login state")
+	ErrOIDCLoginFailed      = errors.New("identity provider login failed")
+	ErrOIDCEmailUnverified  = errors.New("identity provider did not return a verified email")
+	ErrOIDCEmailNotAllowed  = errors.New("email domain not allowed for this identity provider")
+)
+
+var (
+	ErrTOTPAlreadyEnabled  = errors.New("two-factor authentication already enabled")
+	ErrTOTPNotEnrolled     = errors.New("two-factor authentication not enrolled")
+	ErrTOTPNotEnabled      = errors.New("two-factor authentication not enabled")
+	ErrTOTPCodeInvalid     = errors.New("invalid two-factor code")
+	ErrMFAChallengeInvalid = errors.New("invalid or expired login challenge")
+)
+
+var (
+	ErrWorkspaceNotFound     = errors.New("no such workspace")
+	ErrWorkspaceForbidden    = errors.New("insufficient role in this workspace")
+	ErrWorkspaceNotEmpty     = errors.New("workspace still has short links")
+	ErrPersonalWorkspace     = errors.New("not allowed on a personal workspace")
+	ErrWorkspaceMemberExists = errors.New("user is already a member of this workspace")
+	ErrMemberNotFound        = errors.New("no such workspace member")
+	ErrLastOwner             = errors.New("workspace must keep at least one owner")
+	ErrInviteInvalid         = errors.New("invalid or expired invitation")
+)
+
+var (
+	ErrAccountLocked   = errors.New("too many failed attempts, account temporarily locked")
+	ErrTooManyAttempts = errors.New("too many failed attempts, try again later")
+)
+
+var ErrEmailNotFound = errors.New("no such failed email")
+
+var (
+	ErrWebhookNotFound         = errors.New("no such webhook")
+	ErrWebhookDeliveryNotFound = errors.New("no such webhook delivery")
+	ErrTooManyWebhooks         = errors.New("too many webhooks")
+	ErrWebhookURLForbidden     = errors.New("webhook url must resolve to a public address")
+)
+
+var (
+	ErrBulkEmpty       = errors.New("no rows to import")
+	ErrBulkTooLarge    = errors.New("too many rows, use async mode or split the file")
+	ErrBulkJobNotFound = errors.New("no such bulk job")
+	ErrBulkQuota       = errors.New("bulk row quota exceeded, try again later")
+)
+
+var (
+	ErrTagNotFound         = errors.New("no such tag")
+	ErrTagExists           = errors.New("tag already exists in this workspace")
+	ErrInvalidTagName      = errors.New("tag name must not be blank")
+	ErrFolderNotFound      = errors.New("no such folder")
+	ErrFolderExists        = errors.New("folder with this name already exists here")
+	ErrFolderNotEmpty      = errors.New("folder still has subfolders or short links")
+	ErrInvalidFolderName   = errors.New("folder name must not be blank")
+	ErrInvalidFolderParent = errors.New("folder cannot be moved into itself or nested too deep")
+	ErrFolderMismatch      = errors.New("short links and folder must be in the same workspace")
+)
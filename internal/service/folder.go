@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/model"
+	"github.com/jekyulll/url_shortener/internal/repository"
+)
+
+// 文件夹最多嵌套的层数，根目录下的文件夹为第 1 层
+const maxFolderDepth = 8
+
+// FolderService 管理工作区中的文件夹，以及把短链接移入文件夹
+type FolderService struct {
+	urls *URLService
+	repo repository.FolderRepository
+}
+
+func NewFolderService(urls *URLService, repo repository.FolderRepository) *FolderService {
+	return &FolderService{
+		urls: urls,
+		repo: repo,
+	}
+}
+
+// CreateFolder implements api.FolderServicer.
+// 指定了父文件夹时创建在父文件夹所在的工作区
+func (s *FolderService) CreateFolder(ctx context.Context, req dto.CreateFolderRequest) (*dto.FolderResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, ErrInvalidFolderName
+	}
+	var workspaceID uint64
+	if req.ParentID != 0 {
+		parent, err := s.getFolder(ctx, req.ParentID, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		tree, err := s.tree(ctx, parent.WorkspaceID)
+		if err != nil {
+			return nil, err
+		}
+		if tree.depth(parent.ID)+1 > maxFolderDepth {
+			return nil, ErrInvalidFolderParent
+		}
+		workspaceID = parent.WorkspaceID
+	} else {
+		id, err := s.urls.workspaces.writable(ctx, req.UserID, req.WorkspaceID)
+		if err != nil {
+			return nil, err
+		}
+		workspaceID = id
+	}
+	if err := s.checkName(ctx, workspaceID, req.ParentID, name, 0); err != nil {
+		return nil, err
+	}
+	folder := &model.Folder{
+		WorkspaceID: workspaceID,
+		ParentID:    req.ParentID,
+		Name:        name,
+	}
+	if err := s.repo.CreateFolder(ctx, folder); err != nil {
+		return nil, err
+	}
+	resp := toFolderDTO(folder)
+	return &resp, nil
+}
+
+// GetFolders implements api.FolderServicer.
+// 返回平铺的列表，按 parent_id 组装成树
+func (s *FolderService) GetFolders(ctx context.Context, req dto.GetFoldersRequest) ([]dto.FolderResponse, error) {
+	workspaceIDs, err := s.urls.workspaces.visible(ctx, req.UserID, req.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	items := []dto.FolderResponse{}
+	if len(workspaceIDs) == 0 {
+		return items, nil
+	}
+	folders, err := s.repo.GetFoldersByWorkspaceIDs(ctx, workspaceIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range folders {
+		items = append(items, toFolderDTO(&folders[i]))
+	}
+	return items, nil
+}
+
+// UpdateFolder implements api.FolderServicer.
+// 移动时连同子文件夹和其中的短链接一起移动，不能移到自己或子文件夹下
+func (s *FolderService) UpdateFolder(ctx context.Context, req dto.UpdateFolderRequest) error {
+	folder, err := s.getFolder(ctx, req.ID, req.UserID)
+	if err != nil {
+		return err
+	}
+	if req.ParentID != nil && *req.ParentID != folder.ParentID {
+		tree, err := s.tree(ctx, folder.WorkspaceID)
+		if err != nil {
+			return err
+		}
+		parentID := *req.ParentID
+		if parentID != 0 {
+			if _, ok := tree[parentID]; !ok {
+				return ErrFolderNotFound
+			}
+			if tree.isWithin(parentID, folder.ID) || tree.depth(parentID)+tree.height(folder.ID) > maxFolderDepth {
+				return ErrInvalidFolderParent
+			}
+		}
+		folder.ParentID = parentID
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return ErrInvalidFolderName
+		}
+		folder.Name = name
+	}
+	if err := s.checkName(ctx, folder.WorkspaceID, folder.ParentID, folder.Name, folder.ID); err != nil {
+		return err
+	}
+	return s.repo.UpdateFolder(ctx, folder)
+}
+
+// DeleteFolder implements api.FolderServicer.
+// 只能删除空文件夹
+func (s *FolderService) DeleteFolder(ctx context.Context, req dto.FolderRequest) error {
+	folder, err := s.getFolder(ctx, req.ID, req.UserID)
+	if err != nil {
+		return err
+	}
+	count, err := s.repo.CountFolderItems(ctx, folder.ID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrFolderNotEmpty
+	}
+	return s.repo.DeleteFolder(ctx, folder.ID)
+}
+
+// MoveURLs implements api.FolderServicer.
+// 任意一个短链接不可编辑或不在文件夹所在的工作区时整体失败
+func (s *FolderService) MoveURLs(ctx context.Context, req dto.MoveURLsRequest) (*dto.MoveURLsResponse, error) {
+	urls, err := s.urls.editableURLs(ctx, req.URLIDs, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if req.FolderID != 0 {
+		folder, err := s.getFolder(ctx, req.FolderID, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		for _, url := range urls {
+			if url.WorkspaceID != folder.WorkspaceID {
+				return nil, ErrFolderMismatch
+			}
+		}
+	}
+	ids := make([]uint64, len(urls))
+	for i, url := range urls {
+		ids[i] = url.ID
+	}
+	if err := s.repo.MoveURLs(ctx, ids, req.FolderID); err != nil {
+		return nil, err
+	}
+	return &dto.MoveURLsResponse{Updated: len(urls)}, nil
+}
+
+// getFolder 修改文件夹需要在其工作区中有 editor 及以上角色，不是成员时视为不存在
+func (s *FolderService) getFolder(ctx context.Context, id uint64, userID int) (*model.Folder, error) {
+	folder, err := s.repo.GetFolder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if folder == nil {
+		return nil, ErrFolderNotFound
+	}
+	_, err = s.urls.workspaces.require(ctx, folder.WorkspaceID, userID, model.WorkspaceEditor)
+	if errors.Is(err, ErrWorkspaceNotFound) {
+		return nil, ErrFolderNotFound
+	}
+	return folder, err
+}
+
+// checkName 同一父文件夹下不能重名，selfID 为正在修改的文件夹
+func (s *FolderService) checkName(ctx context.Context, workspaceID, parentID uint64, name string, selfID uint64) error {
+	exist, err := s.repo.GetFolderByName(ctx, workspaceID, parentID, name)
+	if err != nil {
+		return err
+	}
+	if exist != nil && exist.ID != selfID {
+		return ErrFolderExists
+	}
+	return nil
+}
+
+// tree 工作区中全部文件夹的 ID 到父文件夹 ID
+func (s *FolderService) tree(ctx context.Context, workspaceID uint64) (folderTree, error) {
+	folders, err := s.repo.GetFoldersByWorkspaceIDs(ctx, []uint64{workspaceID})
+	if err != nil {
+		return nil, err
+	}
+	tree := make(folderTree, len(folders))
+	for _, f := range folders {
+		tree[f.ID] = f.ParentID
+	}
+	return tree, nil
+}
+
+type folderTree map[uint64]uint64
+
+// depth 文件夹所在的层数，根目录下为 1
+func (t folderTree) depth(id uint64) int {
+	n := 0
+	for ; id != 0 && n <= maxFolderDepth; id = t[id] {
+		n++
+	}
+	return n
+}
+
+// height 以该文件夹为根的子树层数，没有子文件夹时为 1
+func (t folderTree) height(id uint64) int {
+	h := 1
+	for child, parent := range t {
+		if parent == id {
+			h = max(h, t.height(child)+1)
+		}
+	}
+	return h
+}
+
+// isWithin id 是否为 ancestor 本身或其子孙
+func (t folderTree) isWithin(id, ancestor uint64) bool {
+	for n := 0; id != 0 && n <= maxFolderDepth; id, n = t[id], n+1 {
+		if id == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+func toFolderDTO(f *model.Folder) dto.FolderResponse {
+	return dto.FolderResponse{
+		ID:          f.ID,
+		WorkspaceID: f.WorkspaceID,
+		ParentID:    f.ParentID,
+		Name:        f.Name,
+		CreatedAt:   f.CreatedAt,
+	}
+}
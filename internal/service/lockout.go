@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jekyulll/url_shortener/config"
+	"github.com/jekyulll/url_shortener/internal/cache"
+)
+
+// 未配置时的防暴力破解参数
+const (
+	defaultFreeAttempts    = 3
+	defaultLockoutDelay    = time.Second
+	defaultLockoutMaxDelay = 30 * time.Second
+	defaultMaxFailures     = 10
+	defaultLockoutDuration = 15 * time.Minute
+	defaultIPMaxFailures   = 50
+	defaultCodeMaxFailures = 5
+)
+
+type LoginAttemptStore interface {
+	IncrLoginFailures(ctx context.Context, key string, ttl time.Duration) (int, error)
+	ClearLoginFailures(ctx context.Context, key string) error
+	BlockLogin(ctx context.Context, key string, ttl time.Duration) error
+	UnblockLogin(ctx context.Context, key string) error
+	LoginBlockedFor(ctx context.Context, key string) (time.Duration, error)
+}
+
+// RetryAfterError 登录被限制时返回，RetryAfter 为还需等待的时间
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// loginGuard 按账号和 IP 统计连续失败次数：账号失败超过 FreeAttempts 次后每次失败都要等待，
+// 等待时间逐次翻倍，达到 MaxFailures 次锁定账号并通知用户；IP 失败达到 IPMaxFailures 次锁定该 IP
+type loginGuard struct {
+	store LoginAttemptStore
+	cfg   config.LockoutConfig
+}
+
+func newLoginGuard(store LoginAttemptStore, cfg config.LockoutConfig) *loginGuard {
+	if cfg.FreeAttempts <= 0 {
+		cfg.FreeAttempts = defaultFreeAttempts
+	}
+	if cfg.Delay <= 0 {
+		cfg.Delay = defaultLockoutDelay
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = defaultLockoutMaxDelay
+	}
+	if cfg.MaxFailures <= 0 {
+		cfg.MaxFailures = defaultMaxFailures
+	}
+	if cfg.Duration <= 0 {
+		cfg.Duration = defaultLockoutDuration
+	}
+	if cfg.IPMaxFailures <= 0 {
+		cfg.IPMaxFailures = defaultIPMaxFailures
+	}
+	if cfg.CodeMaxFailures <= 0 {
+		cfg.CodeMaxFailures = defaultCodeMaxFailures
+	}
+	return &loginGuard{store: store, cfg: cfg}
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(email)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// checkIP IP 被锁定时返回 ErrTooManyAttempts
+func (g *loginGuard) checkIP(ctx context.Context, ip string) error {
+	if ip == "" {
+		return nil
+	}
+	wait, err := g.store.LoginBlockedFor(ctx, ipKey(ip))
+	if err != nil {
+		return fmt.Errorf("failed to get login block: %v", err)
+	}
+	if wait > 0 {
+		return &RetryAfterError{Err: ErrTooManyAttempts, RetryAfter: wait}
+	}
+	return nil
+}
+
+// check 在校验密码之前调用，账号锁定或仍在等待时拒绝，即使密码正确
+func (g *loginGuard) check(ctx context.Context, email, ip string) error {
+	if err := g.checkIP(ctx, ip); err != nil {
+		return err
+	}
+	wait, err := g.store.LoginBlockedFor(ctx, accountKey(email))
+	if err != nil {
+		return fmt.Errorf("failed to get login block: %v", err)
+	}
+	if wait > 0 {
+		return &RetryAfterError{Err: ErrAccountLocked, RetryAfter: wait}
+	}
+	return nil
+}
+
+// failIP 记录一次来自该 IP 的失败
+func (g *loginGuard) failIP(ctx context.Context, ip string) error {
+	if ip == "" {
+		return nil
+	}
+	n, err := g.store.IncrLoginFailures(ctx, ipKey(ip), g.cfg.Duration)
+	if err != nil {
+		return fmt.Errorf("failed to count login failure: %v", err)
+	}
+	if n < g.cfg.IPMaxFailures {
+		return nil
+	}
+	if err := g.store.BlockLogin(ctx, ipKey(ip), g.cfg.Duration); err != nil {
+		return fmt.Errorf("failed to block login: %v", err)
+	}
+	log.Printf("login from %s locked after %d failures", ip, n)
+	return g.store.ClearLoginFailures(ctx, ipKey(ip))
+}
+
+// fail 记录一次密码错误，账号因此被锁定时返回 true
+func (g *loginGuard) fail(ctx context.Context, email, ip string) (bool, error) {
+	if err := g.failIP(ctx, ip); err != nil {
+		return false, err
+	}
+	key := accountKey(email)
+	n, err := g.store.IncrLoginFailures(ctx, key, g.cfg.Duration)
+	if err != nil {
+		return false, fmt.Errorf("failed to count login failure: %v", err)
+	}
+	if n >= g.cfg.MaxFailures {
+		if err := g.store.BlockLogin(ctx, key, g.cfg.Duration); err != nil {
+			return false, fmt.Errorf("failed to block login: %v", err)
+		}
+		// 锁定结束后重新计数
+		return true, g.store.ClearLoginFailures(ctx, key)
+	}
+	if n > g.cfg.FreeAttempts {
+		if err := g.store.BlockLogin(ctx, key, g.delay(n)); err != nil {
+			return false, fmt.Errorf("failed to block login: %v", err)
+		}
+	}
+	return false, nil
+}
+
+// delay 第 n 次失败后需要等待的时间
+func (g *loginGuard) delay(n int) time.Duration {
+	d := g.cfg.Delay
+	for i := g.cfg.FreeAttempts + 1; i < n && d < g.cfg.MaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, g.cfg.MaxDelay)
+}
+
+// reset 登录成功或通过邮箱验证码重置密码后清除账号的失败记录和锁定
+func (g *loginGuard) reset(ctx context.Context, email string) error {
+	key := accountKey(email)
+	if err := g.store.ClearLoginFailures(ctx, key); err != nil {
+		return err
+	}
+	return g.store.UnblockLogin(ctx, key)
+}
+
+var _ LoginAttemptStore = (*cache.RedisCache)(nil)
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	neturl "net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/jekyulll/url_shortener/config"
+	"github.com/jekyulll/url_shortener/internal/cache"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/model"
+	"github.com/jekyulll/url_shortener/internal/repository"
+	"golang.org/x/oauth2"
+)
+
+// 从跳转到 IdP 到回调的最长时间
+const oidcStateTTL = 10 * time.Minute
+
+type OIDCStateStore interface {
+	SetOIDCState(ctx context.Context, state string, data cache.OIDCState, ttl time.Duration) error
+	TakeOIDCState(ctx context.Context, state string) (*cache.OIDCState, error)
+}
+
+// LoginCompleter IdP 验证通过后按用户设置决定是否还需要两步验证
+type LoginCompleter interface {
+	CompleteLogin(ctx context.Context, user *model.User) (*dto.LoginResponse, error)
+}
+
+// oidcProvider 首次使用时才请求 IdP 的 discovery 文档，IdP 不可用不影响启动
+type oidcProvider struct {
+	cfg      config.OIDCConfig
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+type OIDCService struct {
+	providers  map[string]*oidcProvider
+	users      repository.UserRepository
+	identities repository.IdentityRepository
+	states     OIDCStateStore
+	logins     LoginCompleter
+}
+
+func NewOIDCService(cfgs []config.OIDCConfig, users repository.UserRepository, identities repository.IdentityRepository, states OIDCStateStore, logins LoginCompleter) *OIDCService {
+	providers := make(map[string]*oidcProvider, len(cfgs))
+	for _, cfg := range cfgs {
+		if len(cfg.Scopes) == 0 {
+			cfg.Scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+		}
+		providers[cfg.Name] = &oidcProvider{cfg: cfg}
+	}
+	return &OIDCService{
+		providers:  providers,
+		users:      users,
+		identities: identities,
+		states:     states,
+		logins:     logins,
+	}
+}
+
+// GetProviders implements api.OIDCServicer.
+func (s *OIDCService) GetProviders() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AuthURL implements api.OIDCServicer.
+// 生成跳转到 IdP 的授权地址，state、nonce 和 PKCE verifier 保存在 Redis 中，
+// state 另由调用方写入浏览器的 cookie
+func (s *OIDCService) AuthURL(ctx context.Context, name string) (*dto.OIDCAuthResponse, error) {
+	p, ok := s.providers[name]
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+	conf, _, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomToken(24)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomToken(24)
+	if err != nil {
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
+	data := cache.OIDCState{
+		Provider: name,
+		Verifier: verifier,
+		Nonce:    nonce,
+	}
+	if err := s.states.SetOIDCState(ctx, state, data, oidcStateTTL); err != nil {
+		return nil, err
+	}
+	resp := &dto.OIDCAuthResponse{
+		URL:    conf.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier), oidc.Nonce(nonce)),
+		State:  state,
+		MaxAge: oidcStateTTL,
+	}
+	if u, err := neturl.Parse(p.cfg.RedirectURL); err == nil {
+		resp.CallbackPath = u.Path
+		resp.Secure = u.Scheme == "https"
+	}
+	return resp, nil
+}
+
+// Callback implements api.OIDCServicer.
+// 用授权码换取并验证 id_token，找到或创建对应的本地用户后签发 token
+func (s *OIDCService) Callback(ctx context.Context, req dto.OIDCCallbackRequest) (*dto.LoginResponse, error) {
+	p, ok := s.providers[req.Provider]
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+	// state 必须与发起登录的浏览器 cookie 中的一致：否则攻击者可以把自己的回调地址交给受害者，
+	// 让受害者登录到攻击者的账号（登录 CSRF）。不一致时不消耗 state
+	if req.State == "" || subtle.ConstantTimeCompare([]byte(req.State), []byte(req.CookieState)) != 1 {
+		return nil, ErrOIDCStateInvalid
+	}
+	st, err := s.states.TakeOIDCState(ctx, req.State)
+	if err != nil {
+		return nil, err
+	}
+	if st == nil || st.Provider != req.Provider {
+		return nil, ErrOIDCStateInvalid
+	}
+	if req.Error != "" {
+		return nil, fmt.Errorf("%w: %s", ErrOIDCLoginFailed, req.Error)
+	}
+
+	conf, provider, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, err := conf.Exchange(ctx, req.Code, oauth2.VerifierOption(st.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: no id_token in response", ErrOIDCLoginFailed)
+	}
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: p.cfg.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+	if idToken.Nonce != st.Nonce {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+	// 部分 IdP 的 id_token 中不带邮箱，从 userinfo 获取
+	if claims.Email == "" {
+		info, err := provider.UserInfo(ctx, oauth2.StaticTokenSource(token))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+		}
+		claims.Email, claims.EmailVerified = info.Email, info.EmailVerified
+	}
+
+	user, err := s.findOrCreateUser(ctx, p.cfg, idToken.Subject, strings.ToLower(claims.Email), claims.EmailVerified)
+	if err != nil {
+		return nil, err
+	}
+	// 开启了两步验证的用户同样需要输入验证码
+	return s.logins.CompleteLogin(ctx, user)
+}
+
+// findOrCreateUser 已绑定的身份直接登录；否则按已验证的邮箱绑定到已有用户，
+// 没有同邮箱用户时自动创建（无密码，只能通过 IdP 或重置密码登录）
+func (s *OIDCService) findOrCreateUser(ctx context.Context, cfg config.OIDCConfig, subject, email string, verified bool) (*model.User, error) {
+	identity, err := s.identities.GetIdentity(ctx, cfg.Name, subject)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil {
+		user, err := s.users.GetUserByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, ErrOIDCLoginFailed
+		}
+		return user, nil
+	}
+
+	// 未验证的邮箱可能被冒用，既不绑定也不创建
+	if email == "" || !verified {
+		return nil, ErrOIDCEmailUnverified
+	}
+	if !emailDomainAllowed(email, cfg.AllowedEmailDomains) {
+		return nil, ErrOIDCEmailNotAllowed
+	}
+	identity = &model.UserIdentity{
+		Provider: cfg.Name,
+		Subject:  subject,
+		Email:    email,
+	}
+	user, err := s.users.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		identity.UserID = user.ID
+		if err := s.identities.CreateIdentity(ctx, identity); err != nil {
+			return nil, fmt.Errorf("failed to link identity: %v", err)
+		}
+		return user, nil
+	}
+	user = &model.User{Email: email}
+	if err := s.identities.CreateUserWithIdentity(ctx, user, identity); err != nil {
+		return nil, fmt.Errorf("failed to create user: %v", err)
+	}
+	return user, nil
+}
+
+func (p *oidcProvider) discover(ctx context.Context) (*oauth2.Config, *oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.provider == nil {
+		// provider 会在之后的请求中复用该 context 拉取 JWKS，不能随本次请求取消
+		provider, err := oidc.NewProvider(context.WithoutCancel(ctx), p.cfg.Issuer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: discovery: %v", ErrOIDCLoginFailed, err)
+		}
+		p.provider = provider
+	}
+	conf := &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		Endpoint:     p.provider.Endpoint(),
+		RedirectURL:  p.cfg.RedirectURL,
+		Scopes:       p.cfg.Scopes,
+	}
+	return conf, p.provider, nil
+}
+
+func emailDomainAllowed(email string, domains []string) bool {
+	if len(domains) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	for _, d := range domains {
+		if strings.EqualFold(email[at+1:], d) {
+			return true
+		}
+	}
+	return false
+}
+
+var _ OIDCStateStore = (*cache.RedisCache)(nil)
+var _ LoginCompleter = (*UserService)(nil)
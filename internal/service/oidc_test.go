@@ -0,0 +1,395 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jekyulll/url_shortener/config"
+	"github.com/jekyulll/url_shortener/internal/cache"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/model"
+	"github.com/jekyulll/url_shortener/internal/repository"
+)
+
+const testOIDCClientID = "shortener"
+
+// stubIdP 本地的 OpenID Connect 身份提供方：discovery、JWKS 和 token 端点，
+// 授权码绑定 PKCE challenge 和 nonce，只能使用一次
+type stubIdP struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]stubGrant
+}
+
+type stubGrant struct {
+	challenge string
+	claims    map[string]any
+}
+
+func newStubIdP(t *testing.T) *stubIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &stubIdP{key: key, codes: make(map[string]stubGrant)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                 idp.URL,
+			"authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint":         idp.URL + "/token",
+			"jwks_uri":               idp.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", idp.token)
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+// authorize 模拟用户在 IdP 登录并同意授权，返回回调中的 code 和 state。
+// claims 为 id_token 中的用户信息，nonce 取自授权地址
+func (idp *stubIdP) authorize(t *testing.T, authURL string, claims map[string]any) (code, state string) {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("client_id") != testOIDCClientID || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Fatalf("unexpected authorization request %s", authURL)
+	}
+	if q.Get("state") == "" || q.Get("nonce") == "" {
+		t.Fatalf("authorization request without state or nonce: %s", authURL)
+	}
+	c := map[string]any{"nonce": q.Get("nonce")}
+	for k, v := range claims {
+		c[k] = v
+	}
+	code, err = randomToken(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp.mu.Lock()
+	idp.codes[code] = stubGrant{challenge: q.Get("code_challenge"), claims: c}
+	idp.mu.Unlock()
+	return code, q.Get("state")
+}
+
+func (idp *stubIdP) token(w http.ResponseWriter, r *http.Request) {
+	idp.mu.Lock()
+	grant, ok := idp.codes[r.FormValue("code")]
+	delete(idp.codes, r.FormValue("code"))
+	idp.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+	now := time.Now()
+	claims := map[string]any{
+		"iss": idp.URL,
+		"aud": testOIDCClientID,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Minute).Unix(),
+	}
+	for k, v := range grant.claims {
+		claims[k] = v
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     idp.sign(claims),
+	})
+}
+
+// sign 生成 RS256 签名的 id_token
+func (idp *stubIdP) sign(claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, sum[:])
+	if err != nil {
+		panic(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// memOIDCStateStore 内存中的 OIDCStateStore，取出后删除
+type memOIDCStateStore map[string]cache.OIDCState
+
+func (m memOIDCStateStore) SetOIDCState(_ context.Context, state string, data cache.OIDCState, _ time.Duration) error {
+	m[state] = data
+	return nil
+}
+
+func (m memOIDCStateStore) TakeOIDCState(_ context.Context, state string) (*cache.OIDCState, error) {
+	data, ok := m[state]
+	if !ok {
+		return nil, nil
+	}
+	delete(m, state)
+	return &data, nil
+}
+
+// memUserRepo 只实现 OIDC 登录用到的方法，其他方法未实现
+type memUserRepo struct {
+	repository.UserRepository
+	users []*model.User
+}
+
+func (r *memUserRepo) GetUserByEmail(_ context.Context, email string) (*model.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memUserRepo) GetUserByID(_ context.Context, id uint64) (*model.User, error) {
+	for _, u := range r.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+type memIdentityRepo struct {
+	users      *memUserRepo
+	identities []model.UserIdentity
+}
+
+func (r *memIdentityRepo) GetIdentity(_ context.Context, provider, subject string) (*model.UserIdentity, error) {
+	for _, i := range r.identities {
+		if i.Provider == provider && i.Subject == subject {
+			return &i, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memIdentityRepo) CreateIdentity(_ context.Context, identity *model.UserIdentity) error {
+	r.identities = append(r.identities, *identity)
+	return nil
+}
+
+func (r *memIdentityRepo) CreateUserWithIdentity(ctx context.Context, user *model.User, identity *model.UserIdentity) error {
+	user.ID = uint64(len(r.users.users) + 1)
+	r.users.users = append(r.users.users, user)
+	identity.UserID = user.ID
+	return r.CreateIdentity(ctx, identity)
+}
+
+// stubLoginCompleter 记录登录的用户
+type stubLoginCompleter struct {
+	user *model.User
+}
+
+func (s *stubLoginCompleter) CompleteLogin(_ context.Context, user *model.User) (*dto.LoginResponse, error) {
+	s.user = user
+	return &dto.LoginResponse{Email: user.Email, UserID: int(user.ID)}, nil
+}
+
+type oidcTestEnv struct {
+	idp        *stubIdP
+	svc        *OIDCService
+	states     memOIDCStateStore
+	users      *memUserRepo
+	identities *memIdentityRepo
+}
+
+func newOIDCTestEnv(t *testing.T, allowedDomains ...string) *oidcTestEnv {
+	idp := newStubIdP(t)
+	users := &memUserRepo{}
+	identities := &memIdentityRepo{users: users}
+	states := memOIDCStateStore{}
+	cfg := config.OIDCConfig{
+		Name:                "idp",
+		Issuer:              idp.URL,
+		ClientID:            testOIDCClientID,
+		ClientSecret:        "secret",
+		RedirectURL:         "https://sho.rt/api/auth/oidc/idp/callback",
+		AllowedEmailDomains: allowedDomains,
+	}
+	svc := NewOIDCService([]config.OIDCConfig{cfg}, users, identities, states, &stubLoginCompleter{})
+	return &oidcTestEnv{idp: idp, svc: svc, states: states, users: users, identities: identities}
+}
+
+// login 走完一次从授权到回调的流程
+func (e *oidcTestEnv) login(t *testing.T, claims map[string]any) (*dto.LoginResponse, error) {
+	t.Helper()
+	auth, err := e.svc.AuthURL(context.Background(), "idp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state := e.idp.authorize(t, auth.URL, claims)
+	return e.svc.Callback(context.Background(), dto.OIDCCallbackRequest{Provider: "idp", Code: code, State: state, CookieState: auth.State})
+}
+
+func TestOIDCCallback(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	ctx := context.Background()
+
+	auth, err := env.svc.AuthURL(ctx, "idp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth.CallbackPath != "/api/auth/oidc/idp/callback" || !auth.Secure || auth.MaxAge <= 0 {
+		t.Fatalf("unexpected state cookie settings %+v", auth)
+	}
+	code, state := env.idp.authorize(t, auth.URL, map[string]any{"sub": "alice", "email": "Alice@Example.com", "email_verified": true})
+	if state != auth.State {
+		t.Fatalf("state in URL %q, cookie %q", state, auth.State)
+	}
+	req := dto.OIDCCallbackRequest{Provider: "idp", Code: code, State: state, CookieState: auth.State}
+	resp, err := env.svc.Callback(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Email != "alice@example.com" || len(env.users.users) != 1 || len(env.identities.identities) != 1 {
+		t.Fatalf("user not created: %+v", resp)
+	}
+
+	// state 只能使用一次
+	if _, err := env.svc.Callback(ctx, req); !errors.Is(err, ErrOIDCStateInvalid) {
+		t.Fatalf("replayed state: got %v, want ErrOIDCStateInvalid", err)
+	}
+	if _, err := env.svc.Callback(ctx, dto.OIDCCallbackRequest{Provider: "idp", Code: code, State: "forged", CookieState: "forged"}); !errors.Is(err, ErrOIDCStateInvalid) {
+		t.Fatalf("unknown state: got %v, want ErrOIDCStateInvalid", err)
+	}
+	if _, err := env.svc.Callback(ctx, dto.OIDCCallbackRequest{Provider: "other", State: state}); !errors.Is(err, ErrOIDCProviderNotFound) {
+		t.Fatalf("unknown provider: got %v, want ErrOIDCProviderNotFound", err)
+	}
+}
+
+func TestOIDCCallbackPKCE(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	ctx := context.Background()
+
+	auth, err := env.svc.AuthURL(ctx, "idp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state := env.idp.authorize(t, auth.URL, map[string]any{"sub": "alice", "email": "alice@example.com", "email_verified": true})
+	// 授权码被截获后，没有对应的 verifier 无法换取 token
+	st := env.states[state]
+	st.Verifier = "intercepted-verifier-intercepted-verifier-123"
+	env.states[state] = st
+	if _, err := env.svc.Callback(ctx, dto.OIDCCallbackRequest{Provider: "idp", Code: code, State: state, CookieState: state}); !errors.Is(err, ErrOIDCLoginFailed) {
+		t.Fatalf("wrong verifier: got %v, want ErrOIDCLoginFailed", err)
+	}
+	if len(env.users.users) != 0 {
+		t.Fatal("user created without a valid code exchange")
+	}
+}
+
+func TestOIDCCallbackLoginCSRF(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	ctx := context.Background()
+
+	// 攻击者发起登录并在 IdP 完成授权，把回调地址交给受害者，受害者的浏览器没有攻击者的 cookie
+	attacker, err := env.svc.AuthURL(ctx, "idp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state := env.idp.authorize(t, attacker.URL, map[string]any{"sub": "mallory", "email": "mallory@example.com", "email_verified": true})
+	victim, err := env.svc.AuthURL(ctx, "idp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cookie := range []string{"", victim.State} {
+		req := dto.OIDCCallbackRequest{Provider: "idp", Code: code, State: state, CookieState: cookie}
+		if _, err := env.svc.Callback(ctx, req); !errors.Is(err, ErrOIDCStateInvalid) {
+			t.Fatalf("cookie %q: got %v, want ErrOIDCStateInvalid", cookie, err)
+		}
+	}
+	if len(env.users.users) != 0 {
+		t.Fatal("user logged in without the state cookie")
+	}
+	// 不一致时不消耗 state，发起登录的浏览器仍可完成登录
+	if _, ok := env.states[state]; !ok {
+		t.Fatal("state consumed by a mismatched callback")
+	}
+}
+
+func TestOIDCCallbackNonce(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	_, err := env.login(t, map[string]any{"sub": "alice", "email": "alice@example.com", "email_verified": true, "nonce": "replayed"})
+	if !errors.Is(err, ErrOIDCStateInvalid) {
+		t.Fatalf("wrong nonce: got %v, want ErrOIDCStateInvalid", err)
+	}
+	if len(env.users.users) != 0 {
+		t.Fatal("user created from a token with the wrong nonce")
+	}
+}
+
+func TestOIDCAccountLinking(t *testing.T) {
+	env := newOIDCTestEnv(t, "example.com")
+	env.users.users = append(env.users.users, &model.User{ID: 7, Email: "bob@example.com"})
+
+	// 未验证的邮箱不绑定到已有用户
+	if _, err := env.login(t, map[string]any{"sub": "bob", "email": "bob@example.com", "email_verified": false}); !errors.Is(err, ErrOIDCEmailUnverified) {
+		t.Fatalf("unverified email: got %v, want ErrOIDCEmailUnverified", err)
+	}
+	if len(env.identities.identities) != 0 {
+		t.Fatal("identity linked from an unverified email")
+	}
+	if _, err := env.login(t, map[string]any{"sub": "eve", "email": "eve@evil.com", "email_verified": true}); !errors.Is(err, ErrOIDCEmailNotAllowed) {
+		t.Fatalf("disallowed domain: got %v, want ErrOIDCEmailNotAllowed", err)
+	}
+
+	// 已验证的邮箱绑定到同邮箱的已有用户
+	resp, err := env.login(t, map[string]any{"sub": "bob", "email": "bob@example.com", "email_verified": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.UserID != 7 || len(env.users.users) != 1 {
+		t.Fatalf("logged in as %d, want existing user 7", resp.UserID)
+	}
+	if ids := env.identities.identities; len(ids) != 1 || ids[0].UserID != 7 || ids[0].Subject != "bob" {
+		t.Fatalf("unexpected identities %+v", ids)
+	}
+
+	// 绑定后按 subject 登录，IdP 中的邮箱改变不影响
+	resp, err = env.login(t, map[string]any{"sub": "bob", "email": "robert@example.com", "email_verified": false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.UserID != 7 || len(env.identities.identities) != 1 {
+		t.Fatalf("linked login: got user %d", resp.UserID)
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/jekyulll/url_shortener/config"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/model"
+	"github.com/jekyulll/url_shortener/internal/repository"
+	"github.com/jekyulll/url_shortener/internal/web"
+	"gorm.io/gorm"
+)
+
+// LandingPageService 管理用户自定义的 404/过期/停用 落地页
+type LandingPageService struct {
+	repo       repository.LandingPageRepository
+	domainRepo repository.DomainRepository
+	domains    *domainResolver
+	templates  *pageTemplates
+}
+
+func NewLandingPageService(repo repository.LandingPageRepository, domainRepo repository.DomainRepository, cfg config.AppConfig) *LandingPageService {
+	return &LandingPageService{
+		repo:       repo,
+		domainRepo: domainRepo,
+		domains:    newDomainResolver(domainRepo, cfg.BaseURL),
+		templates:  newPageTemplates(),
+	}
+}
+
+// SetPage implements api.LandingPageServicer.
+// 保存前先用示例数据渲染一次，提前暴露模板错误
+func (s *LandingPageService) SetPage(ctx context.Context, req dto.SetLandingPageRequest) (*dto.LandingPageResponse, error) {
+	domainID, err := s.ownDomainID(ctx, req.Domain, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := web.ParsePage(req.Template)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPageTemplate, err)
+	}
+	now := time.Now()
+	sample := dto.LandingPageData{
+		Kind:      req.Kind,
+		Host:      "example.com",
+		ShortCode: "abc123",
+		ExpiredAt: &now,
+		Reason:    "example",
+	}
+	if err := tmpl.Execute(io.Discard, sample); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPageTemplate, err)
+	}
+
+	page := &model.LandingPage{
+		UserID:   uint64(req.UserID),
+		DomainID: domainID,
+		Kind:     req.Kind,
+		Template: req.Template,
+	}
+	if err := s.repo.UpsertPage(ctx, page); err != nil {
+		return nil, err
+	}
+	return &dto.LandingPageResponse{
+		Kind:      page.Kind,
+		Domain:    normalizeHost(req.Domain),
+		Template:  page.Template,
+		UpdatedAt: page.UpdatedAt,
+	}, nil
+}
+
+// GetPages implements api.LandingPageServicer.
+func (s *LandingPageService) GetPages(ctx context.Context, userID int) ([]dto.LandingPageResponse, error) {
+	pages, err := s.repo.GetPagesByUserID(ctx, uint64(userID))
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint64
+	for _, p := range pages {
+		if p.DomainID != 0 {
+			ids = append(ids, p.DomainID)
+		}
+	}
+	domains, err := s.domainRepo.GetDomainsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	hosts := make(map[uint64]string, len(domains))
+	for _, d := range domains {
+		hosts[d.ID] = d.Host
+	}
+
+	resp := make([]dto.LandingPageResponse, len(pages))
+	for i, p := range pages {
+		resp[i] = dto.LandingPageResponse{
+			Kind:      p.Kind,
+			Domain:    hosts[p.DomainID],
+			Template:  p.Template,
+			UpdatedAt: p.UpdatedAt,
+		}
+	}
+	return resp, nil
+}
+
+// DeletePage implements api.LandingPageServicer.
+func (s *LandingPageService) DeletePage(ctx context.Context, req dto.DeleteLandingPageRequest) error {
+	domainID, err := s.ownDomainID(ctx, req.Domain, req.UserID)
+	if err != nil {
+		return err
+	}
+	err = s.repo.DeletePage(ctx, uint64(req.UserID), domainID, req.Kind)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrPageNotFound
+	}
+	return err
+}
+
+// FindPage implements api.LandingPager.
+// 查找顺序：请求域名的模板 -> 短链接所有者的模板，都没有时返回 nil，由调用方使用内置页面
+func (s *LandingPageService) FindPage(ctx context.Context, kind, host string, ownerID uint64) (*template.Template, error) {
+	d, err := s.domains.byHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var page *model.LandingPage
+	if d != nil {
+		if page, err = s.repo.GetDomainPage(ctx, d.ID, kind); err != nil {
+			return nil, err
+		}
+	}
+	if page == nil && ownerID != 0 {
+		if page, err = s.repo.GetPage(ctx, ownerID, 0, kind); err != nil {
+			return nil, err
+		}
+	}
+	if page == nil {
+		return nil, nil
+	}
+	return s.templates.parse(page)
+}
+
+// pageTemplates 缓存解析后的模板，避免每次访问都重新解析。
+// 按 ID 保存，模板内容变化（包括其他实例修改）时重新解析
+type pageTemplates struct {
+	mu      sync.Mutex
+	entries map[uint64]pageTemplate
+}
+
+type pageTemplate struct {
+	src  string
+	tmpl *template.Template
+}
+
+// 缓存的模板数上限，超出时清空重建
+const maxPageTemplates = 1024
+
+func newPageTemplates() *pageTemplates {
+	return &pageTemplates{entries: make(map[uint64]pageTemplate)}
+}
+
+func (c *pageTemplates) parse(page *model.LandingPage) (*template.Template, error) {
+	c.mu.Lock()
+	e, ok := c.entries[page.ID]
+	c.mu.Unlock()
+	if ok && e.src == page.Template {
+		return e.tmpl, nil
+	}
+	tmpl, err := web.ParsePage(page.Template)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxPageTemplates {
+		c.entries = make(map[uint64]pageTemplate)
+	}
+	c.entries[page.ID] = pageTemplate{src: page.Template, tmpl: tmpl}
+	return tmpl, nil
+}
+
+// ownDomainID 空域名表示用户级别的模板；否则必须是本人已验证的域名
+func (s *LandingPageService) ownDomainID(ctx context.Context, host string, userID int) (uint64, error) {
+	if host == "" {
+		return 0, nil
+	}
+	d, err := s.domains.byHost(ctx, host)
+	if err != nil {
+		return 0, err
+	}
+	if d == nil || d.UserID != uint64(userID) {
+		return 0, ErrDomainNotFound
+	}
+	return d.ID, nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/jekyulll/url_shortener/internal/model"
+)
+
+func TestPageTemplatesParseOnce(t *testing.T) {
+	c := newPageTemplates()
+	page := &model.LandingPage{ID: 1, Template: "<h1>{{.ShortCode}}</h1>"}
+	first, err := c.parse(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := c.parse(&model.LandingPage{ID: 1, Template: page.Template})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != first {
+		t.Error("unchanged template parsed again")
+	}
+
+	// 模板修改后重新解析
+	page.Template = "<h1>{{.Host}}</h1>"
+	changed, err := c.parse(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed == first {
+		t.Error("changed template served from cache")
+	}
+
+	// 解析失败不缓存，也不影响已有的结果
+	if _, err := c.parse(&model.LandingPage{ID: 2, Template: "{{.Host"}); err == nil {
+		t.Error("expected a parse error")
+	}
+	if _, ok := c.entries[2]; ok {
+		t.Error("invalid template cached")
+	}
+}
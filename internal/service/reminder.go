@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jekyulll/url_shortener/config"
+	"github.com/jekyulll/url_shortener/internal/cache"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/emails"
+	"github.com/jekyulll/url_shortener/internal/model"
+	"github.com/jekyulll/url_shortener/internal/repository"
+)
+
+// 未配置时的提醒参数
+const (
+	defaultReminderInterval = time.Hour
+	defaultReminderWindow   = 72 * time.Hour
+)
+
+const reminderBatchSize = 100
+
+type ExtendTokenStore interface {
+	SetExtendToken(ctx context.Context, hash string, token cache.ExtendToken, ttl time.Duration) error
+	GetExtendToken(ctx context.Context, hash string) (*cache.ExtendToken, error)
+	DelExtendToken(ctx context.Context, hash string) (bool, error)
+}
+
+// ReminderService 在短链接过期前提醒创建者，提醒中带一键延期的链接，
+// 延期复用 URLService.UpdateURLDuration 的权限检查和缓存处理
+type ReminderService struct {
+	urls     *URLService
+	users    repository.UserRepository
+	mailer   EmailSender
+	tokens   ExtendTokenStore
+	interval time.Duration
+	window   time.Duration
+	extendBy time.Duration
+	baseURL  string
+}
+
+func NewReminderService(urls *URLService, users repository.UserRepository, mailer EmailSender, tokens ExtendTokenStore, cfg config.ReminderConfig, app config.AppConfig) *ReminderService {
+	s := &ReminderService{
+		urls:     urls,
+		users:    users,
+		mailer:   mailer,
+		tokens:   tokens,
+		interval: cfg.Interval,
+		window:   cfg.Window,
+		extendBy: cfg.ExtendBy,
+		baseURL:  app.BaseURL,
+	}
+	if s.interval <= 0 {
+		s.interval = defaultReminderInterval
+	}
+	if s.window <= 0 {
+		s.window = defaultReminderWindow
+	}
+	if s.extendBy <= 0 {
+		s.extendBy = urls.defaultDuration
+	}
+	return s
+}
+
+func (s *ReminderService) Interval() time.Duration {
+	return s.interval
+}
+
+// SendReminders 提醒即将在 window 内过期的短链接，每个有效期只提醒一次，由定时任务调用
+func (s *ReminderService) SendReminders(ctx context.Context) error {
+	users := make(map[uint64]*model.User) // 同一用户的多个短链接只查一次
+	for {
+		now := time.Now()
+		urls, err := s.urls.repo.GetURLsToRemind(ctx, now, now.Add(s.window), reminderBatchSize)
+		if err != nil {
+			return err
+		}
+		hosts, err := s.urls.domainHosts(ctx, urls)
+		if err != nil {
+			return err
+		}
+		for _, url := range urls {
+			user, ok := users[url.UserID]
+			if !ok {
+				if user, err = s.users.GetUserByID(ctx, url.UserID); err != nil {
+					return err
+				}
+				users[url.UserID] = user
+			}
+			if user != nil && !user.Disabled && user.ExpiryReminders {
+				if err := s.remind(ctx, user, url, s.urls.shortURL(hosts[url.DomainID], url.ShortCode)); err != nil {
+					return err
+				}
+			}
+			// 关闭了提醒的用户同样标记，避免每次重复扫描
+			if err := s.urls.repo.MarkURLReminded(ctx, url.ID, now); err != nil {
+				return err
+			}
+		}
+		if len(urls) < reminderBatchSize {
+			return nil
+		}
+	}
+}
+
+// remind 生成延期令牌，发送邮件，并通知订阅了 link.expiring 的 webhook
+func (s *ReminderService) remind(ctx context.Context, user *model.User, url *model.URL, shortURL string) error {
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	// 令牌在短链接过期时失效
+	extend := cache.ExtendToken{
+		URLID:     url.ID,
+		UserID:    int(user.ID),
+		CreatedAt: url.CreatedAt.Unix(),
+	}
+	if err := s.tokens.SetExtendToken(ctx, hashToken(token), extend, time.Until(url.ExpiredAt)); err != nil {
+		return fmt.Errorf("failed to set extend token: %w", err)
+	}
+	extendURL := s.baseURL + "/api/urls/extend?token=" + token
+
+	data := emails.LinkExpiringData{
+		ShortURL:    shortURL,
+		OriginalURL: url.OriginalURL,
+		ExpiresAt:   url.ExpiredAt,
+		ExtendURL:   extendURL,
+	}
+	if err := s.mailer.SendTemplate(user.Email, user.Locale, emails.LinkExpiring, data); err != nil {
+		return fmt.Errorf("failed to send reminder: %w", err)
+	}
+	// 经 webhook 投递队列推送，请求体带签名，失败后重试，不阻塞提醒任务
+	link := webhookLink(url, shortURL)
+	link.ExtendURL = extendURL
+	s.urls.events.Publish(ctx, url.UserID, model.EventLinkExpiring, link)
+	return nil
+}
+
+// GetExtend implements api.ReminderServicer.
+// 展示延期确认页，不消耗令牌：邮件客户端预取链接时不会误触发延期
+func (s *ReminderService) GetExtend(ctx context.Context, token string) (*dto.ExtendURLResponse, error) {
+	_, url, err := s.extendTarget(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return s.extendResponse(ctx, url)
+}
+
+// Extend implements api.ReminderServicer.
+// 令牌只能使用一次，从当前过期时间起延长 extendBy。
+// 先删除令牌防止并发重复延期，延期失败时放回，用户可以再次点击
+func (s *ReminderService) Extend(ctx context.Context, token string) (*dto.ExtendURLResponse, error) {
+	extend, url, err := s.extendTarget(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.extendResponse(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := s.urls.domainHosts(ctx, []*model.URL{url})
+	if err != nil {
+		return nil, err
+	}
+	hash := hashToken(token)
+	ok, err := s.tokens.DelExtendToken(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrExtendLinkInvalid
+	}
+	err = s.urls.UpdateURLDuration(ctx, dto.UpdateURLDurationReq{
+		Code:      url.ShortCode,
+		Domain:    hosts[url.DomainID],
+		ExpiredAt: resp.NewExpiredAt,
+		UserID:    extend.UserID,
+	})
+	if err != nil {
+		if err := s.tokens.SetExtendToken(context.WithoutCancel(ctx), hash, *extend, time.Until(url.ExpiredAt)); err != nil {
+			log.Printf("failed to restore extend token for url %d: %v", url.ID, err)
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// extendTarget 校验令牌并找到对应的短链接，短码过期后被重新创建时令牌失效
+func (s *ReminderService) extendTarget(ctx context.Context, token string) (*cache.ExtendToken, *model.URL, error) {
+	extend, err := s.tokens.GetExtendToken(ctx, hashToken(token))
+	if err != nil {
+		return nil, nil, err
+	}
+	if extend == nil {
+		return nil, nil, ErrExtendLinkInvalid
+	}
+	url, err := s.urls.repo.GetURLByID(ctx, extend.URLID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if url == nil || url.CreatedAt.Unix() != extend.CreatedAt || url.IsExpired() {
+		return nil, nil, ErrExtendLinkInvalid
+	}
+	return extend, url, nil
+}
+
+func (s *ReminderService) extendResponse(ctx context.Context, url *model.URL) (*dto.ExtendURLResponse, error) {
+	hosts, err := s.urls.domainHosts(ctx, []*model.URL{url})
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ExtendURLResponse{
+		ShortURL:     s.urls.shortURL(hosts[url.DomainID], url.ShortCode),
+		OriginalURL:  url.OriginalURL,
+		ExpiredAt:    url.ExpiredAt,
+		NewExpiredAt: url.ExpiredAt.Add(s.extendBy),
+	}, nil
+}
+
+// GetSettings implements api.ReminderServicer.
+func (s *ReminderService) GetSettings(ctx context.Context, userID int) (*dto.ReminderSettings, error) {
+	user, err := s.users.GetUserByID(ctx, uint64(userID))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return &dto.ReminderSettings{ExpiryReminders: user.ExpiryReminders}, nil
+}
+
+// UpdateSettings implements api.ReminderServicer.
+func (s *ReminderService) UpdateSettings(ctx context.Context, req dto.ReminderSettings) (*dto.ReminderSettings, error) {
+	if err := s.users.UpdateReminderSettings(ctx, uint64(req.UserID), req.ExpiryReminders); err != nil {
+		return nil, err
+	}
+	return &dto.ReminderSettings{ExpiryReminders: req.ExpiryReminders}, nil
+}
+
+var _ ExtendTokenStore = (*cache.RedisCache)(nil)
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/model"
+	"github.com/jekyulll/url_shortener/internal/repository"
+)
+
+// TagService 管理工作区中的标签，以及短链接与标签的关联
+type TagService struct {
+	urls *URLService
+	repo repository.TagRepository
+}
+
+func NewTagService(urls *URLService, repo repository.TagRepository) *TagService {
+	return &TagService{
+		urls: urls,
+		repo: repo,
+	}
+}
+
+// CreateTag implements api.TagServicer.
+// 需要在工作区中有 editor 及以上角色
+func (s *TagService) CreateTag(ctx context.Context, req dto.CreateTagRequest) (*dto.TagResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, ErrInvalidTagName
+	}
+	workspaceID, err := s.urls.workspaces.writable(ctx, req.UserID, req.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	exist, err := s.repo.GetTagByName(ctx, workspaceID, name)
+	if err != nil {
+		return nil, err
+	}
+	if exist != nil {
+		return nil, ErrTagExists
+	}
+	tag := &model.Tag{
+		WorkspaceID: workspaceID,
+		Name:        name,
+		Color:       req.Color,
+	}
+	if err := s.repo.CreateTag(ctx, tag); err != nil {
+		return nil, err
+	}
+	resp := toTagDTO(tag)
+	return &resp, nil
+}
+
+// GetTags implements api.TagServicer.
+// 未指定工作区时返回所在全部工作区的标签
+func (s *TagService) GetTags(ctx context.Context, req dto.GetTagsRequest) ([]dto.TagResponse, error) {
+	workspaceIDs, err := s.urls.workspaces.visible(ctx, req.UserID, req.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	items := []dto.TagResponse{}
+	if len(workspaceIDs) == 0 {
+		return items, nil
+	}
+	tags, err := s.repo.GetTagsByWorkspaceIDs(ctx, workspaceIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tags {
+		items = append(items, toTagDTO(&tags[i]))
+	}
+	return items, nil
+}
+
+// UpdateTag implements api.TagServicer.
+// 重命名后已打上该标签的短链接随之改变
+func (s *TagService) UpdateTag(ctx context.Context, req dto.UpdateTagRequest) error {
+	tag, err := s.getTag(ctx, req.ID, req.UserID)
+	if err != nil {
+		return err
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return ErrInvalidTagName
+		}
+		exist, err := s.repo.GetTagByName(ctx, tag.WorkspaceID, name)
+		if err != nil {
+			return err
+		}
+		if exist != nil && exist.ID != tag.ID {
+			return ErrTagExists
+		}
+		tag.Name = name
+	}
+	if req.Color != nil {
+		tag.Color = *req.Color
+	}
+	return s.repo.UpdateTag(ctx, tag)
+}
+
+// DeleteTag implements api.TagServicer.
+// 短链接本身不受影响
+func (s *TagService) DeleteTag(ctx context.Context, req dto.TagRequest) error {
+	tag, err := s.getTag(ctx, req.ID, req.UserID)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteTag(ctx, tag.ID)
+}
+
+// TagURLs implements api.TagServicer.
+// 短链接可以来自多个工作区，标签按名称在各自的工作区中查找，添加时不存在的自动创建。
+// 任意一个短链接不可编辑时整体失败
+func (s *TagService) TagURLs(ctx context.Context, req dto.TagURLsRequest) (*dto.TagURLsResponse, error) {
+	add, remove := normalizeTagNames(req.Add), normalizeTagNames(req.Remove)
+	urls, err := s.urls.editableURLs(ctx, req.URLIDs, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(add) == 0 && len(remove) == 0 {
+		return &dto.TagURLsResponse{}, nil
+	}
+
+	byWorkspace := make(map[uint64][]uint64)
+	for _, url := range urls {
+		byWorkspace[url.WorkspaceID] = append(byWorkspace[url.WorkspaceID], url.ID)
+	}
+	for workspaceID, urlIDs := range byWorkspace {
+		if len(add) > 0 {
+			tags, err := s.repo.EnsureTags(ctx, workspaceID, add)
+			if err != nil {
+				return nil, err
+			}
+			if err := s.repo.AddURLTags(ctx, urlIDs, tagIDs(tags)); err != nil {
+				return nil, err
+			}
+		}
+		if len(remove) > 0 {
+			tags, err := s.repo.GetTagsByNames(ctx, workspaceID, remove)
+			if err != nil {
+				return nil, err
+			}
+			if len(tags) == 0 {
+				continue
+			}
+			if err := s.repo.RemoveURLTags(ctx, urlIDs, tagIDs(tags)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &dto.TagURLsResponse{Updated: len(urls)}, nil
+}
+
+// getTag 修改标签需要在其工作区中有 editor 及以上角色，不是成员时视为不存在
+func (s *TagService) getTag(ctx context.Context, id uint64, userID int) (*model.Tag, error) {
+	tag, err := s.repo.GetTag(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if tag == nil {
+		return nil, ErrTagNotFound
+	}
+	_, err = s.urls.workspaces.require(ctx, tag.WorkspaceID, userID, model.WorkspaceEditor)
+	if errors.Is(err, ErrWorkspaceNotFound) {
+		return nil, ErrTagNotFound
+	}
+	return tag, err
+}
+
+// normalizeTagNames 去掉首尾空白、空名称和重复的名称
+func normalizeTagNames(names []string) []string {
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name != "" && !slices.Contains(out, name) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+func tagIDs(tags []model.Tag) []uint64 {
+	ids := make([]uint64, len(tags))
+	for i, tag := range tags {
+		ids[i] = tag.ID
+	}
+	return ids
+}
+
+func toTagDTO(t *model.Tag) dto.TagResponse {
+	return dto.TagResponse{
+		ID:          t.ID,
+		WorkspaceID: t.WorkspaceID,
+		Name:        t.Name,
+		Color:       t.Color,
+		CreatedAt:   t.CreatedAt,
+	}
+}
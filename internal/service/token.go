@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jekyulll/url_shortener/internal/cache"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/emails"
+	"github.com/jekyulll/url_shortener/internal/model"
+	"gorm.io/gorm"
+)
+
+const (
+	// 未配置时 refresh token 的有效期
+	defaultRefreshDuration = 30 * 24 * time.Hour
+	// 密码验证通过后输入两步验证码的时限
+	mfaChallengeTTL = 5 * time.Minute
+	// token 版本在 Redis 中的缓存时间，以数据库为准
+	tokenVersionTTL = time.Hour
+)
+
+type TokenStore interface {
+	SetRefreshToken(ctx context.Context, hash string, session cache.RefreshSession, ttl time.Duration) error
+	TakeRefreshToken(ctx context.Context, hash string, usedTTL time.Duration) (*cache.RefreshSession, int, error)
+	DelRefreshToken(ctx context.Context, hash string) error
+	GetTokenVersion(ctx context.Context, userID int) (int64, bool, error)
+	SetTokenVersion(ctx context.Context, userID int, version int64, ttl time.Duration) error
+	AddTokenVersion(ctx context.Context, userID int, version int64, ttl time.Duration) error
+	DenyToken(ctx context.Context, tokenID string, ttl time.Duration) error
+	IsTokenDenied(ctx context.Context, tokenID string) (bool, error)
+	SetMFAChallenge(ctx context.Context, hash string, userID int, email string, ttl time.Duration) error
+}
+
+// Refresh 用 refresh token 换取新的 access token 和 refresh token，旧的 refresh token 随即作废。
+// 已轮换过的 refresh token 再次出现说明可能被盗用，吊销该用户的全部会话
+func (s *UserService) Refresh(ctx context.Context, req dto.RefreshRequest) (*dto.LoginResponse, error) {
+	hash := hashToken(req.RefreshToken)
+	session, reusedBy, err := s.tokens.TakeRefreshToken(ctx, hash, s.refreshDuration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %v", err)
+	}
+	if session == nil {
+		if reusedBy != 0 {
+			if err := s.RevokeAllSessions(ctx, reusedBy); err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	version, err := s.tokenVersion(ctx, session.UserID)
+	if errors.Is(err, ErrTokenRevoked) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if version != session.Version {
+		return nil, ErrInvalidRefreshToken
+	}
+	// 重新读取用户，角色变化和停用即时生效
+	user, err := s.repo.GetUserByID(ctx, uint64(session.UserID))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	return s.IssueTokens(ctx, user)
+}
+
+// Logout 吊销当前 access token，可选地吊销对应的 refresh token 或该用户的全部会话
+func (s *UserService) Logout(ctx context.Context, req dto.LogoutRequest) error {
+	if err := s.tokens.DenyToken(ctx, req.TokenID, time.Until(req.TokenExpiresAt)); err != nil {
+		return err
+	}
+	if req.RefreshToken != "" {
+		if err := s.tokens.DelRefreshToken(ctx, hashToken(req.RefreshToken)); err != nil {
+			return err
+		}
+	}
+	if req.All {
+		return s.RevokeAllSessions(ctx, req.UserID)
+	}
+	return nil
+}
+
+// RevokeAllSessions 使该用户已签发的 access token 和 refresh token 全部失效。
+// 版本号保存在数据库中，Redis 被清空后已吊销的 token 不会重新生效
+func (s *UserService) RevokeAllSessions(ctx context.Context, userID int) error {
+	version, err := s.repo.IncrTokenVersion(ctx, uint64(userID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.tokens.SetTokenVersion(ctx, userID, version, tokenVersionTTL)
+}
+
+// tokenVersion 先查缓存，没有时从数据库加载。
+// 用户已删除时返回 ErrTokenRevoked，已停用时返回 ErrUserDisabled
+func (s *UserService) tokenVersion(ctx context.Context, userID int) (int64, error) {
+	version, ok, err := s.tokens.GetTokenVersion(ctx, userID)
+	if err != nil || ok {
+		return version, err
+	}
+	user, err := s.repo.GetUserByID(ctx, uint64(userID))
+	if err != nil {
+		return 0, err
+	}
+	if user == nil {
+		return 0, ErrTokenRevoked
+	}
+	if user.Disabled {
+		return 0, ErrUserDisabled
+	}
+	if err := s.tokens.AddTokenVersion(ctx, userID, user.TokenVersion, tokenVersionTTL); err != nil {
+		return 0, err
+	}
+	return user.TokenVersion, nil
+}
+
+// CheckToken implements middleware.TokenChecker.
+// 停用用户时版本号同时增加，缓存命中时也不会放行停用用户的 token
+func (s *UserService) CheckToken(ctx context.Context, userID int, version int64, tokenID string) error {
+	current, err := s.tokenVersion(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if version != current {
+		return ErrTokenRevoked
+	}
+	denied, err := s.tokens.IsTokenDenied(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	if denied {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// IssueTokens 按用户当前的 token 版本签发一对新 token，OIDC 等其他登录方式也由此签发。
+// 被停用的用户一律拒绝
+func (s *UserService) IssueTokens(ctx context.Context, user *model.User) (*dto.LoginResponse, error) {
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+	email, userID := user.Email, int(user.ID)
+	version, err := s.tokenVersion(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token version: %w", err)
+	}
+	accessToken, err := s.jwter.Generate(email, userID, version, user.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %v", err)
+	}
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	session := cache.RefreshSession{
+		UserID:  userID,
+		Email:   email,
+		Version: version,
+	}
+	if err := s.tokens.SetRefreshToken(ctx, hashToken(refreshToken), session, s.refreshDuration); err != nil {
+		return nil, fmt.Errorf("failed to save refresh token: %v", err)
+	}
+	return &dto.LoginResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.jwter.Duration().Seconds()),
+		Email:        email,
+		UserID:       userID,
+	}, nil
+}
+
+// CompleteLogin 第一步验证（密码、重置密码的验证码或 IdP）通过后由此继续，
+// 所有登录方式共用：开启了两步验证时返回挑战，否则签发 token
+func (s *UserService) CompleteLogin(ctx context.Context, user *model.User) (*dto.LoginResponse, error) {
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+	if user.TOTPEnabled {
+		return s.mfaChallenge(ctx, user.Email, int(user.ID))
+	}
+	return s.FinishLogin(ctx, user)
+}
+
+// CheckLogin 账号或 IP 被锁定、仍在等待时拒绝，两步验证同样先检查
+func (s *UserService) CheckLogin(ctx context.Context, email, ip string) error {
+	return s.guard.check(ctx, email, ip)
+}
+
+// FailLogin 记录一次密码或两步验证码错误，账号因此被锁定时通知用户并返回 ErrAccountLocked。
+// user 为空表示邮箱不存在，只计数不通知
+func (s *UserService) FailLogin(ctx context.Context, email, ip string, user *model.User) error {
+	locked, err := s.guard.fail(ctx, email, ip)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return nil
+	}
+	if user != nil {
+		go s.sendSecurityAlert(user, emails.SecurityAlertData{
+			Event:    emails.EventAccountLocked,
+			IP:       ip,
+			Duration: s.guard.cfg.Duration,
+		})
+	}
+	return &RetryAfterError{Err: ErrAccountLocked, RetryAfter: s.guard.cfg.Duration}
+}
+
+// FinishLogin 全部验证通过，清除失败记录后签发 token
+func (s *UserService) FinishLogin(ctx context.Context, user *model.User) (*dto.LoginResponse, error) {
+	if err := s.guard.reset(ctx, user.Email); err != nil {
+		return nil, fmt.Errorf("failed to reset login failures: %v", err)
+	}
+	return s.IssueTokens(ctx, user)
+}
+
+// mfaChallenge 密码验证已通过但开启了两步验证，先返回挑战 token 代替 access token
+func (s *UserService) mfaChallenge(ctx context.Context, email string, userID int) (*dto.LoginResponse, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokens.SetMFAChallenge(ctx, hashToken(token), userID, email, mfaChallengeTTL); err != nil {
+		return nil, fmt.Errorf("failed to save mfa challenge: %v", err)
+	}
+	return &dto.LoginResponse{
+		Email:          email,
+		UserID:         userID,
+		MFARequired:    true,
+		ChallengeToken: token,
+	}, nil
+}
+
+// refresh token 只保存哈希，Redis 泄露也无法直接使用
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+var _ TokenStore = (*cache.RedisCache)(nil)
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jekyulll/url_shortener/internal/model"
+	"gorm.io/gorm"
+)
+
+// memTokenStore 内存中的 TokenStore，只实现 token 版本和吊销
+type memTokenStore struct {
+	TokenStore
+	versions map[int]int64
+	denied   map[string]bool
+}
+
+func newMemTokenStore() *memTokenStore {
+	return &memTokenStore{versions: make(map[int]int64), denied: make(map[string]bool)}
+}
+
+func (m *memTokenStore) GetTokenVersion(_ context.Context, userID int) (int64, bool, error) {
+	v, ok := m.versions[userID]
+	return v, ok, nil
+}
+
+func (m *memTokenStore) SetTokenVersion(_ context.Context, userID int, version int64, _ time.Duration) error {
+	m.versions[userID] = version
+	return nil
+}
+
+func (m *memTokenStore) AddTokenVersion(_ context.Context, userID int, version int64, _ time.Duration) error {
+	if _, ok := m.versions[userID]; !ok {
+		m.versions[userID] = version
+	}
+	return nil
+}
+
+func (m *memTokenStore) IsTokenDenied(_ context.Context, tokenID string) (bool, error) {
+	return m.denied[tokenID], nil
+}
+
+func (r *memUserRepo) IncrTokenVersion(_ context.Context, id uint64) (int64, error) {
+	for _, u := range r.users {
+		if u.ID == id {
+			u.TokenVersion++
+			return u.TokenVersion, nil
+		}
+	}
+	return 0, gorm.ErrRecordNotFound
+}
+
+func TestCheckTokenAfterCacheFlush(t *testing.T) {
+	ctx := context.Background()
+	users := &memUserRepo{users: []*model.User{{ID: 1, Email: "alice@example.com"}}}
+	tokens := newMemTokenStore()
+	s := &UserService{repo: users, tokens: tokens}
+
+	if err := s.CheckToken(ctx, 1, 0, "a"); err != nil {
+		t.Fatalf("fresh token rejected: %v", err)
+	}
+	if err := s.RevokeAllSessions(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CheckToken(ctx, 1, 0, "a"); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("revoked token: got %v, want ErrTokenRevoked", err)
+	}
+
+	// Redis 被清空后从数据库加载版本号，已吊销的 token 仍然无效
+	tokens.versions = make(map[int]int64)
+	if err := s.CheckToken(ctx, 1, 0, "a"); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("revoked token after flush: got %v, want ErrTokenRevoked", err)
+	}
+	if err := s.CheckToken(ctx, 1, 1, "b"); err != nil {
+		t.Fatalf("current token rejected after flush: %v", err)
+	}
+
+	// 已删除的用户吊销会话不报错
+	if err := s.RevokeAllSessions(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckTokenDisabledUser(t *testing.T) {
+	ctx := context.Background()
+	users := &memUserRepo{users: []*model.User{{ID: 1, Email: "alice@example.com", Disabled: true}}}
+	s := &UserService{repo: users, tokens: newMemTokenStore()}
+
+	if err := s.CheckToken(ctx, 1, 0, "a"); !errors.Is(err, ErrUserDisabled) {
+		t.Fatalf("disabled user: got %v, want ErrUserDisabled", err)
+	}
+	if err := s.CheckToken(ctx, 2, 0, "a"); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("deleted user: got %v, want ErrTokenRevoked", err)
+	}
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jekyulll/url_shortener/config"
+	"github.com/jekyulll/url_shortener/internal/cache"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/model"
+	"github.com/jekyulll/url_shortener/internal/repository"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	totpPeriod        = 30
+	totpSkew          = 1                // 允许前后各一个周期的时钟偏差
+	totpUsedTTL       = 90 * time.Second // 覆盖 skew 范围内验证码的有效期
+	recoveryCodeCount = 10
+	maxMFAFailures    = 5 // 同一个挑战连续输错后作废，需重新输入密码
+)
+
+type TOTPStore interface {
+	GetMFAChallenge(ctx context.Context, hash string) (*cache.MFAChallenge, error)
+	IncrMFAChallengeFailures(ctx context.Context, hash string) (int, error)
+	DelMFAChallenge(ctx context.Context, hash string) error
+	MarkTOTPUsed(ctx context.Context, userID int, code string, ttl time.Duration) (bool, error)
+}
+
+// TwoFactorLogin 两步验证码输错与密码输错一样计入账号锁定，通过后才清除失败记录
+type TwoFactorLogin interface {
+	CheckLogin(ctx context.Context, email, ip string) error
+	FailLogin(ctx context.Context, email, ip string, user *model.User) error
+	FinishLogin(ctx context.Context, user *model.User) (*dto.LoginResponse, error)
+}
+
+type TOTPService struct {
+	users      repository.UserRepository
+	codes      repository.RecoveryCodeRepository
+	store      TOTPStore
+	logins     TwoFactorLogin
+	qr         QRCoder
+	issuerName string // 显示在验证器 App 中的名称
+}
+
+func NewTOTPService(users repository.UserRepository, codes repository.RecoveryCodeRepository, store TOTPStore, logins TwoFactorLogin, qr QRCoder, cfg config.AppConfig) *TOTPService {
+	issuerName := baseHostOf(cfg.BaseURL)
+	if issuerName == "" {
+		issuerName = "url_shortener"
+	}
+	return &TOTPService{
+		users:      users,
+		codes:      codes,
+		store:      store,
+		logins:     logins,
+		qr:         qr,
+		issuerName: issuerName,
+	}
+}
+
+// Enroll implements api.TOTPServicer.
+// 生成新的密钥，调用 Activate 验证通过后才会开启
+func (s *TOTPService) Enroll(ctx context.Context, userID int) (*dto.TOTPEnrollResponse, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      s.issuerName,
+		AccountName: user.Email,
+		Period:      totpPeriod,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.users.UpdateTOTP(ctx, user.ID, key.Secret(), false); err != nil {
+		return nil, err
+	}
+	png, err := s.qr.PNG(key.URL(), s.qr.Defaults())
+	if err != nil {
+		return nil, err
+	}
+	return &dto.TOTPEnrollResponse{
+		Secret:          key.Secret(),
+		ProvisioningURI: key.URL(),
+		QR:              "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// Activate implements api.TOTPServicer.
+// 验证码正确则开启两步验证，并返回恢复码
+func (s *TOTPService) Activate(ctx context.Context, req dto.TOTPCodeRequest) (*dto.RecoveryCodesResponse, error) {
+	user, err := s.getUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTOTPNotEnrolled
+	}
+	ok, err := s.checkTOTP(ctx, user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTOTPCodeInvalid
+	}
+	if err := s.users.UpdateTOTP(ctx, user.ID, user.TOTPSecret, true); err != nil {
+		return nil, err
+	}
+	return s.resetRecoveryCodes(ctx, user.ID)
+}
+
+// Disable implements api.TOTPServicer.
+// 需要当前的验证码或恢复码
+func (s *TOTPService) Disable(ctx context.Context, req dto.TOTPCodeRequest) error {
+	user, err := s.getUser(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrTOTPNotEnabled
+	}
+	ok, err := s.verifySecondFactor(ctx, user, req.Code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTOTPCodeInvalid
+	}
+	if err := s.users.UpdateTOTP(ctx, user.ID, "", false); err != nil {
+		return err
+	}
+	return s.codes.DeleteRecoveryCodes(ctx, user.ID)
+}
+
+// RegenerateRecoveryCodes implements api.TOTPServicer.
+// 旧的恢复码全部作废
+func (s *TOTPService) RegenerateRecoveryCodes(ctx context.Context, req dto.TOTPCodeRequest) (*dto.RecoveryCodesResponse, error) {
+	user, err := s.getUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrTOTPNotEnabled
+	}
+	ok, err := s.checkTOTP(ctx, user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTOTPCodeInvalid
+	}
+	return s.resetRecoveryCodes(ctx, user.ID)
+}
+
+// LoginTwoFactor implements api.TOTPServicer.
+// 登录第二步：用挑战 token 和验证码（或恢复码）换取 access token
+func (s *TOTPService) LoginTwoFactor(ctx context.Context, req dto.LoginTwoFactorRequest) (*dto.LoginResponse, error) {
+	hash := hashToken(req.ChallengeToken)
+	challenge, err := s.store.GetMFAChallenge(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if challenge == nil || challenge.Failures >= maxMFAFailures {
+		return nil, ErrMFAChallengeInvalid
+	}
+	user, err := s.getUser(ctx, challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// 换新的挑战 token 不能绕过账号锁定
+	if err := s.logins.CheckLogin(ctx, user.Email, req.IP); err != nil {
+		return nil, err
+	}
+	ok, err := s.verifySecondFactor(ctx, user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		failures, err := s.store.IncrMFAChallengeFailures(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		lockErr := s.logins.FailLogin(ctx, user.Email, req.IP, user)
+		if failures >= maxMFAFailures || lockErr != nil {
+			if err := s.store.DelMFAChallenge(ctx, hash); err != nil {
+				return nil, err
+			}
+		}
+		if lockErr != nil {
+			return nil, lockErr
+		}
+		return nil, ErrTOTPCodeInvalid
+	}
+	if err := s.store.DelMFAChallenge(ctx, hash); err != nil {
+		return nil, err
+	}
+	return s.logins.FinishLogin(ctx, user)
+}
+
+// verifySecondFactor 6 位数字按 TOTP 验证，否则按恢复码验证
+func (s *TOTPService) verifySecondFactor(ctx context.Context, user *model.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == 6 && strings.Trim(code, "0123456789") == "" {
+		return s.checkTOTP(ctx, user, code)
+	}
+	return s.codes.UseRecoveryCode(ctx, user.ID, hashToken(normalizeRecoveryCode(code)))
+}
+
+// checkTOTP 验证通过的码在有效期内不能再次使用
+func (s *TOTPService) checkTOTP(ctx context.Context, user *model.User, code string) (bool, error) {
+	ok, err := totp.ValidateCustom(code, user.TOTPSecret, time.Now(), totp.ValidateOpts{
+		Period:    totpPeriod,
+		Skew:      totpSkew,
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	})
+	if err != nil || !ok {
+		return false, nil
+	}
+	return s.store.MarkTOTPUsed(ctx, int(user.ID), code, totpUsedTTL)
+}
+
+func (s *TOTPService) resetRecoveryCodes(ctx context.Context, userID uint64) (*dto.RecoveryCodesResponse, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw, err := randomHex(5)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashToken(raw)
+	}
+	if err := s.codes.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to save recovery codes: %v", err)
+	}
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (s *TOTPService) getUser(ctx context.Context, userID int) (*model.User, error) {
+	user, err := s.users.GetUserByID(ctx, uint64(userID))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNameOrPasswordFailed
+	}
+	return user, nil
+}
+
+// 恢复码忽略大小写、空格和连字符
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+var _ TOTPStore = (*cache.RedisCache)(nil)
+var _ TwoFactorLogin = (*UserService)(nil)
@@ -2,9 +2,12 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"log"
+	neturl "net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -14,10 +17,18 @@ import (
 	"github.com/jekyulll/url_shortener/internal/model"
 	"github.com/jekyulll/url_shortener/internal/repository"
 	"github.com/jekyulll/url_shortener/pkg/filter"
+	"github.com/jekyulll/url_shortener/pkg/qrcode"
 	"github.com/jekyulll/url_shortener/pkg/shortcode"
 	"gorm.io/gorm"
 )
 
+const (
+	// 每批清理的过期短链接数量
+	expiredBatchSize = 500
+	// 导出时每次从数据库读取的数量
+	exportBatchSize = 500
+)
+
 type CodeStatus int
 
 const (
@@ -26,130 +37,672 @@ const (
 	CodeInUse                       // 存在且有效
 )
 
+// key 均由 model.URLKey 生成
 type URLCacher interface {
 	SetURL(ctx context.Context, url model.URL) error
-	GetURL(ctx context.Context, shortCode string) (*model.URL, error) // TODO 返回 string
-	DelURL(ctx context.Context, shortCode string) error
-	IncreViews(ctx context.Context, shortCode string) error
+	GetURL(ctx context.Context, key string) (*model.URL, error) // TODO 返回 string
+	DelURL(ctx context.Context, key string) error
+	IncreViews(ctx context.Context, key string) error
 	ScanViews(ctx context.Context, cursor uint64, batchSize int64) (keys []string, nextCursor uint64, err error)
-	GetViews(ctx context.Context, shortCode string) (int, error)
-	DelViews(ctx context.Context, shortCode string) error
+	GetViews(ctx context.Context, key string) (int, error)
+	DelViews(ctx context.Context, key string) error
 }
 
 type ShortCodeGenerator interface {
-	GenerateShortCode() string
+	GenerateShortCode() (string, error)
+	Blocked(code string) bool
+}
+
+type QRCoder interface {
+	Defaults() qrcode.Options
+	PNG(content string, opt qrcode.Options) ([]byte, error)
+	SVG(content string, opt qrcode.Options) ([]byte, error)
 }
 
 type URLService struct {
 	repo               repository.URLRepository
+	domainRepo         repository.DomainRepository
+	domains            *domainResolver
+	workspaces         *workspaceAccess
 	filter             filter.BloomFilter
 	shortCodeGenerator ShortCodeGenerator
 	defaultDuration    time.Duration
+	trashRetention     time.Duration
 	cache              URLCacher
+	qr                 QRCoder
+	events             LinkEventPublisher
 	bashURL            string
+	scheme             string
 }
 
-func NewURLService(repo repository.URLRepository, filter filter.BloomFilter, generator ShortCodeGenerator, cache URLCacher, cfg config.AppConfig) *URLService {
+func NewURLService(repo repository.URLRepository, domainRepo repository.DomainRepository, workspaceRepo repository.WorkspaceRepository, filter filter.BloomFilter, generator ShortCodeGenerator, cache URLCacher, qr QRCoder, events LinkEventPublisher, cfg config.AppConfig) *URLService {
 	// 启动时加载所有有效短码到过滤器
 	if urls, err := repo.GetAllActiveURLs(context.Background()); err == nil {
 		for _, url := range urls {
-			filter.Add(url.ShortCode)
+			filter.Add(url.Key())
 		}
 	}
+	trashRetention := cfg.TrashRetention
+	if trashRetention <= 0 {
+		trashRetention = 30 * 24 * time.Hour
+	}
+	scheme := "http"
+	if u, err := neturl.Parse(cfg.BaseURL); err == nil && u.Scheme != "" {
+		scheme = u.Scheme
+	}
 	return &URLService{
 		repo:               repo,
+		domainRepo:         domainRepo,
+		domains:            newDomainResolver(domainRepo, cfg.BaseURL),
+		workspaces:         newWorkspaceAccess(workspaceRepo),
 		filter:             filter,
 		shortCodeGenerator: generator,
 		cache:              cache,
+		qr:                 qr,
+		events:             events,
 		defaultDuration:    cfg.DefaultDuration,
+		trashRetention:     trashRetention,
 		bashURL:            cfg.BaseURL,
+		scheme:             scheme,
 	}
 }
 
 // GetURLs implements api.URLServicer.
+// 未指定工作区时返回用户所在全部工作区的短链接。
+// 访问量的过滤和排序只看已同步到数据库的部分，返回的 views 包含缓存中尚未同步的访问
 func (s *URLService) GetURLs(ctx context.Context, req dto.GetURLsRequest) (*dto.GetURLsResponse, error) {
-	rows, err := s.repo.GetURLsByUserID(ctx, int32(req.UserID), int32(req.Size), int32(req.Page-1))
+	workspaceIDs, err := s.workspaces.visible(ctx, req.UserID, req.WorkspaceID)
 	if err != nil {
 		return nil, err
 	}
-	items := make([]dto.FullURL, len(rows))
-	total := 0
-
-	for i := range rows {
-		// TODO 检查 total
-		row := rows[i]
-		views, err := s.cache.GetViews(ctx, row.ShortCode)
+	if len(workspaceIDs) == 0 {
+		return &dto.GetURLsResponse{Items: []dto.FullURL{}}, nil
+	}
+	filter := repository.URLFilter{
+		WorkspaceIDs: workspaceIDs,
+		Query:        req.Q,
+		Status:       req.Status,
+		Custom:       req.Custom,
+		Tag:          strings.TrimSpace(req.Tag),
+		FolderID:     req.FolderID,
+		CreatedFrom:  req.CreatedFrom,
+		CreatedTo:    req.CreatedTo,
+		ExpiresFrom:  req.ExpiresFrom,
+		ExpiresTo:    req.ExpiresTo,
+		MinViews:     req.MinViews,
+		MaxViews:     req.MaxViews,
+		Sort:         req.Sort,
+		Desc:         req.Order != "asc",
+	}
+	if filter.Sort == "" {
+		filter.Sort = "created_at"
+	}
+	var after *repository.URLCursor
+	if req.Cursor != "" {
+		if after, err = decodeURLCursor(req.Cursor, filter.Sort, filter.Desc); err != nil {
+			return nil, err
+		}
+	}
+	// 多取一条判断是否还有下一页
+	offset := int((req.Page - 1) * req.Size)
+	rows, total, err := s.repo.ListURLs(ctx, filter, after, int(req.Size)+1, offset)
+	if err != nil {
+		return nil, err
+	}
+	resp := dto.GetURLsResponse{Total: total}
+	if len(rows) > int(req.Size) {
+		rows = rows[:req.Size]
+		resp.NextCursor = encodeURLCursor(filter.Sort, filter.Desc, rows[len(rows)-1])
+	}
+	hosts, err := s.domainHosts(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+	resp.Items = make([]dto.FullURL, len(rows))
+	for i, row := range rows {
+		views, err := s.views(ctx, row)
 		if err != nil {
 			return nil, err
 		}
-		row.Views += int32(views)
-		items[i] = dto.FullURL{
+		resp.Items[i] = dto.FullURL{
 			ID:          int(row.ID),
 			OriginalURL: row.OriginalURL,
-			ShortURL:    fmt.Sprintf("%s%s", s.bashURL, row.ShortCode),
+			ShortURL:    s.shortURL(hosts[row.DomainID], row.ShortCode),
+			Title:       row.Title,
+			CreatedAt:   row.CreatedAt,
 			ExpiredAt:   row.ExpiredAt,
 			IsCustom:    row.IsCustom,
-			Views:       uint(row.Views),
+			Views:       uint(views),
+			WorkspaceID: row.WorkspaceID,
+			FolderID:    row.FolderID,
+			Tags:        tagNames(row.Tags),
+			Notes:       row.Notes,
+
+			Disabled:       row.Disabled,
+			DisabledReason: row.DisabledReason,
 		}
-		total = int(row.Views)
-	}
-	resp := dto.GetURLsResponse{
-		Items: items,
-		Total: total,
 	}
 	return &resp, nil
 }
 
-// DefaultURL implements api.URLServicer.
-func (s *URLService) DefaultURL(ctx context.Context) error {
-	// panic("unimplemented")
-	// TODO 函数签名需要修改，默认导航到一个404页面
-	return nil
+// views 数据库中的访问量加上缓存中尚未同步的部分
+func (s *URLService) views(ctx context.Context, url *model.URL) (int32, error) {
+	pending, err := s.cache.GetViews(ctx, url.Key())
+	if err != nil {
+		return 0, err
+	}
+	return url.Views + int32(pending), nil
+}
+
+func tagNames(tags []model.Tag) []string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return names
 }
 
 // DeleteURL implements api.URLServicer.
-func (s *URLService) DeleteURL(ctx context.Context, shortCode string) error {
-	if err := s.repo.DeleteURLByShortCode(ctx, shortCode); err != nil {
+// 移入回收站，尚未同步的访问量保留，恢复后统计不丢失。
+// 需要 editor 及以上角色，其他工作区的短链接视为不存在
+func (s *URLService) DeleteURL(ctx context.Context, req dto.DeleteURLRequest) error {
+	domainID, err := s.resolveDomainID(ctx, req.Domain)
+	if err != nil {
 		return err
 	}
-	if err := s.cache.DelURL(ctx, shortCode); err != nil {
+	url, err := s.repo.GetURLByShortCode(ctx, domainID, req.Code)
+	if err != nil {
 		return err
 	}
-	if err := s.cache.DelViews(ctx, shortCode); err != nil {
+	if err := s.checkEditable(ctx, url, req.UserID); err != nil {
 		return err
 	}
+	err = s.repo.DeleteURLByShortCode(ctx, url.WorkspaceID, domainID, req.Code)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrURLNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.cache.DelURL(ctx, model.URLKey(domainID, req.Code)); err != nil {
+		return err
+	}
+	s.emit(ctx, model.EventLinkDeleted, url)
 	return nil
 }
 
+// ExportURLs implements api.URLServicer.
+// 按 ID 分批读取用户可见的短链接，逐行交给 write，不在内存中保留全部结果。
+// 工作区和访问量的处理同 GetURLs，write 返回错误时停止导出
+func (s *URLService) ExportURLs(ctx context.Context, req dto.ExportURLsRequest, write func(dto.ExportedURL) error) error {
+	workspaceIDs, err := s.workspaces.visible(ctx, req.UserID, req.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	if len(workspaceIDs) == 0 {
+		return nil
+	}
+	var afterID uint64
+	for {
+		rows, err := s.repo.GetURLsAfterID(ctx, workspaceIDs, afterID, exportBatchSize)
+		if err != nil {
+			return err
+		}
+		hosts, err := s.domainHosts(ctx, rows)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			views, err := s.views(ctx, row)
+			if err != nil {
+				return err
+			}
+			err = write(dto.ExportedURL{
+				ID:             row.ID,
+				ShortURL:       s.shortURL(hosts[row.DomainID], row.ShortCode),
+				ShortCode:      row.ShortCode,
+				OriginalURL:    row.OriginalURL,
+				Title:          row.Title,
+				WorkspaceID:    row.WorkspaceID,
+				IsCustom:       row.IsCustom,
+				Views:          views,
+				Disabled:       row.Disabled,
+				DisabledReason: row.DisabledReason,
+				CreatedAt:      row.CreatedAt,
+				ExpiredAt:      row.ExpiredAt,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if len(rows) < exportBatchSize {
+			return nil
+		}
+		afterID = rows[len(rows)-1].ID
+	}
+}
+
+// GetTrash implements api.URLServicer.
+// 列出回收站中仍可恢复的短链接，工作区和访问量的处理同 GetURLs
+func (s *URLService) GetTrash(ctx context.Context, req dto.GetURLsRequest) (*dto.GetTrashResponse, error) {
+	workspaceIDs, err := s.workspaces.visible(ctx, req.UserID, req.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(workspaceIDs) == 0 {
+		return &dto.GetTrashResponse{Items: []dto.TrashedURL{}}, nil
+	}
+	offset := (req.Page - 1) * req.Size
+	rows, err := s.repo.GetTrashedURLsByWorkspaceIDs(ctx, workspaceIDs, s.restorableSince(), int32(req.Size), int32(offset))
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := s.domainHosts(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]dto.TrashedURL, len(rows))
+	for i, row := range rows {
+		views, err := s.views(ctx, row)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = dto.TrashedURL{
+			FullURL: dto.FullURL{
+				ID:             int(row.ID),
+				OriginalURL:    row.OriginalURL,
+				ShortURL:       s.shortURL(hosts[row.DomainID], row.ShortCode),
+				Title:          row.Title,
+				CreatedAt:      row.CreatedAt,
+				ExpiredAt:      row.ExpiredAt,
+				IsCustom:       row.IsCustom,
+				Views:          uint(views),
+				WorkspaceID:    row.WorkspaceID,
+				Disabled:       row.Disabled,
+				DisabledReason: row.DisabledReason,
+			},
+			DeletedAt: row.DeletedAt.Time,
+			PurgeAt:   row.DeletedAt.Time.Add(s.trashRetention),
+		}
+	}
+	return &dto.GetTrashResponse{Items: items}, nil
+}
+
+// RestoreURL implements api.URLServicer.
+// 超过保留期或其他工作区的短链接视为不存在
+func (s *URLService) RestoreURL(ctx context.Context, req dto.RestoreURLRequest) error {
+	domainID, err := s.resolveDomainID(ctx, req.Domain)
+	if err != nil {
+		return err
+	}
+	url, err := s.repo.GetTrashedURL(ctx, domainID, req.Code)
+	if err != nil {
+		return err
+	}
+	if err := s.checkEditable(ctx, url, req.UserID); err != nil {
+		return err
+	}
+	err = s.repo.RestoreURL(ctx, url.WorkspaceID, domainID, req.Code, s.restorableSince())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrURLNotFound
+	}
+	if err != nil {
+		return err
+	}
+	s.emit(ctx, model.EventLinkUpdated, url)
+	return nil
+}
+
+// PurgeTrash 彻底删除超过保留期的短链接，由定时任务调用
+func (s *URLService) PurgeTrash(ctx context.Context) error {
+	return s.repo.PurgeTrash(ctx, s.restorableSince())
+}
+
+// 在此时间之后删除的短链接仍可恢复
+func (s *URLService) restorableSince() time.Time {
+	return time.Now().Add(-s.trashRetention)
+}
+
 // IncreViews implements api.URLServicer.
-func (s *URLService) IncreViews(ctx context.Context, shortCode string) error {
-	return s.cache.IncreViews(ctx, shortCode)
+// 按采样比例推送 link.clicked
+func (s *URLService) IncreViews(ctx context.Context, url *dto.URL) error {
+	if err := s.cache.IncreViews(ctx, model.URLKey(url.DomainID, url.ShortCode)); err != nil {
+		return err
+	}
+	if s.events.SampleClick() {
+		s.emit(ctx, model.EventLinkClicked, &model.URL{
+			ID:          url.ID,
+			UserID:      url.UserID,
+			WorkspaceID: url.WorkspaceID,
+			DomainID:    url.DomainID,
+			OriginalURL: url.OriginalURL,
+			ShortCode:   url.ShortCode,
+			ExpiredAt:   url.ExpiredAt,
+			CreatedAt:   url.CreatedAt,
+		})
+	}
+	return nil
 }
 
 // UpdateURLDuration implements api.URLServicer.
+// 已过期或其他工作区的短链接视为不存在
 func (s *URLService) UpdateURLDuration(ctx context.Context, req dto.UpdateURLDurationReq) error {
-	// FIXME 是否会导致缓存里的持续时间还在？
-	// 因此此处删除缓存，旁路缓存模式
-	if err := s.cache.DelURL(ctx, req.Code); err != nil {
+	domainID, err := s.resolveDomainID(ctx, req.Domain)
+	if err != nil {
+		return err
+	}
+	url, err := s.repo.GetURLByShortCode(ctx, domainID, req.Code)
+	if err != nil {
+		return err
+	}
+	if err := s.checkEditable(ctx, url, req.UserID); err != nil {
+		return err
+	}
+	err = s.repo.UpdateURLExpiredByShortCode(ctx, url.WorkspaceID, domainID, req.Code, req.ExpiredAt)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrURLNotFound
+	}
+	if err != nil {
+		return err
+	}
+	// 旁路缓存：删除后下次访问从数据库加载新的过期时间
+	if err := s.cache.DelURL(ctx, model.URLKey(domainID, req.Code)); err != nil {
 		return fmt.Errorf("failed to delete cache: %v", err.Error())
 	}
-	return s.repo.UpdateURLExpiredByShortCode(ctx, req.Code, req.ExpiredAt)
+	url.ExpiredAt = req.ExpiredAt
+	s.emit(ctx, model.EventLinkUpdated, url)
+	return nil
+}
+
+// PauseURL implements api.URLServicer.
+// 暂停后访问会展示停用页，统计数据与短码保留
+func (s *URLService) PauseURL(ctx context.Context, req dto.PauseURLRequest) error {
+	return s.setURLDisabled(ctx, req, true)
+}
+
+// ResumeURL implements api.URLServicer.
+func (s *URLService) ResumeURL(ctx context.Context, req dto.PauseURLRequest) error {
+	req.Reason = ""
+	return s.setURLDisabled(ctx, req, false)
+}
+
+func (s *URLService) setURLDisabled(ctx context.Context, req dto.PauseURLRequest, disabled bool) error {
+	domainID, err := s.resolveDomainID(ctx, req.Domain)
+	if err != nil {
+		return err
+	}
+	url, err := s.repo.GetURLByShortCode(ctx, domainID, req.Code)
+	if err != nil {
+		return err
+	}
+	if err := s.checkEditable(ctx, url, req.UserID); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateURLDisabled(ctx, url.WorkspaceID, domainID, req.Code, disabled, req.Reason); err != nil {
+		return err
+	}
+	// 旁路缓存：删除后下次访问从数据库加载最新状态
+	if err := s.cache.DelURL(ctx, url.Key()); err != nil {
+		return err
+	}
+	url.Disabled = disabled
+	url.DisabledReason = req.Reason
+	s.emit(ctx, model.EventLinkUpdated, url)
+	return nil
+}
+
+// SearchURLs 管理员按短码或原始链接搜索所有用户的短链接，userID 为 0 时不限用户
+func (s *URLService) SearchURLs(ctx context.Context, q string, userID uint64, limit, offset int) ([]dto.AdminURL, int64, error) {
+	rows, total, err := s.repo.SearchURLs(ctx, q, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	hosts, err := s.domainHosts(ctx, rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	items := make([]dto.AdminURL, len(rows))
+	for i, row := range rows {
+		items[i] = dto.AdminURL{
+			FullURL: dto.FullURL{
+				ID:             int(row.ID),
+				OriginalURL:    row.OriginalURL,
+				ShortURL:       s.shortURL(hosts[row.DomainID], row.ShortCode),
+				Title:          row.Title,
+				CreatedAt:      row.CreatedAt,
+				ExpiredAt:      row.ExpiredAt,
+				IsCustom:       row.IsCustom,
+				Views:          uint(row.Views),
+				Disabled:       row.Disabled,
+				DisabledReason: row.DisabledReason,
+			},
+			UserID: row.UserID,
+		}
+	}
+	return items, total, nil
+}
+
+// ForceExpire 管理员强制过期，不检查工作区权限。推送 link.updated，
+// 之后由定时任务按正常流程删除并推送 link.expired
+func (s *URLService) ForceExpire(ctx context.Context, host, shortCode string) error {
+	url, err := s.getAnyURL(ctx, host, shortCode)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	err = s.repo.UpdateURLExpiredByShortCode(ctx, url.WorkspaceID, url.DomainID, url.ShortCode, now)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrURLExpired
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.cache.DelURL(ctx, url.Key()); err != nil {
+		return err
+	}
+	url.ExpiredAt = now
+	s.emit(ctx, model.EventLinkUpdated, url)
+	return nil
+}
+
+// Transfer 管理员把短链接转入目标用户的个人工作区，原创建者和新创建者都会收到 link.updated
+func (s *URLService) Transfer(ctx context.Context, host, shortCode string, toUserID uint64) error {
+	url, err := s.getAnyURL(ctx, host, shortCode)
+	if err != nil {
+		return err
+	}
+	workspaceID, err := s.workspaces.personal(ctx, toUserID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.TransferURL(ctx, url.DomainID, url.ShortCode, toUserID, workspaceID); err != nil {
+		return fmt.Errorf("failed to transfer url: %v", err)
+	}
+	if err := s.cache.DelURL(ctx, url.Key()); err != nil {
+		return err
+	}
+	previous := url.UserID
+	url.UserID = toUserID
+	if url.WorkspaceID != workspaceID {
+		url.WorkspaceID = workspaceID
+		url.FolderID = 0
+	}
+	s.emit(ctx, model.EventLinkUpdated, url)
+	if previous != toUserID {
+		notice := *url
+		notice.UserID = previous
+		s.emit(ctx, model.EventLinkUpdated, &notice)
+	}
+	return nil
+}
+
+// getAnyURL 不检查工作区权限，供管理员使用
+func (s *URLService) getAnyURL(ctx context.Context, host, shortCode string) (*model.URL, error) {
+	domainID, err := s.resolveDomainID(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	url, err := s.repo.GetURLByShortCode(ctx, domainID, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	if url == nil {
+		return nil, ErrURLNotFound
+	}
+	return url, nil
+}
+
+// UpdateURLDetails implements api.URLServicer.
+// 标题和描述展示在预览页上，修改后删除缓存
+func (s *URLService) UpdateURLDetails(ctx context.Context, req dto.UpdateURLDetailsRequest) error {
+	domainID, err := s.resolveDomainID(ctx, req.Domain)
+	if err != nil {
+		return err
+	}
+	url, err := s.repo.GetURLByShortCode(ctx, domainID, req.Code)
+	if err != nil {
+		return err
+	}
+	if err := s.checkEditable(ctx, url, req.UserID); err != nil {
+		return err
+	}
+	if req.Title != nil {
+		url.Title = *req.Title
+	}
+	if req.Description != nil {
+		url.Description = *req.Description
+	}
+	if req.Notes != nil {
+		url.Notes = *req.Notes
+	}
+	if req.Interstitial != nil {
+		url.Interstitial = *req.Interstitial
+	}
+	if err := s.repo.UpdateURLDetails(ctx, url); err != nil {
+		return err
+	}
+	if err := s.cache.DelURL(ctx, url.Key()); err != nil {
+		return err
+	}
+	s.emit(ctx, model.EventLinkUpdated, url)
+	return nil
+}
+
+// editableURLs 按 ID 批量取出短链接，任意一个不存在或没有 editor 角色时整体失败
+func (s *URLService) editableURLs(ctx context.Context, ids []uint64, userID int) ([]*model.URL, error) {
+	ids = slices.Compact(slices.Sorted(slices.Values(ids)))
+	urls, err := s.repo.GetURLsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(urls) != len(ids) {
+		return nil, ErrURLNotFound
+	}
+	checked := make(map[uint64]bool)
+	for _, url := range urls {
+		if checked[url.WorkspaceID] {
+			continue
+		}
+		if err := s.checkEditable(ctx, url, userID); err != nil {
+			return nil, err
+		}
+		checked[url.WorkspaceID] = true
+	}
+	return urls, nil
+}
+
+// checkEditable 修改短链接需要在其工作区中有 editor 及以上角色，
+// 不是成员时视为不存在，不暴露其他工作区的短码
+func (s *URLService) checkEditable(ctx context.Context, url *model.URL, userID int) error {
+	if url == nil {
+		return ErrURLNotFound
+	}
+	_, err := s.workspaces.require(ctx, url.WorkspaceID, userID, model.WorkspaceEditor)
+	if errors.Is(err, ErrWorkspaceNotFound) {
+		return ErrURLNotFound
+	}
+	return err
 }
 
 // 如出错返回 err，如短链接已存在，返回预定义错误 ErrShortCodeTaken
 func (s *URLService) CreateURL(ctx context.Context, req dto.CreateURLRequest) (*dto.CreateURLResponse, error) {
-	var expiredAt time.Time
+	// 0. 确定域名和工作区
+	target, err := s.resolveTarget(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// 1-4. 分配短码，组装要入库的 URL 实体
+	u, err := s.prepareURL(ctx, req, target)
+	if err != nil {
+		return nil, err
+	}
+	// 5. 写库
+	if err := s.repo.UpsertURL(ctx, u); err != nil {
+		return nil, fmt.Errorf("create url record: %w", err)
+	}
+	// 6. 写缓存，推送事件
+	s.created(ctx, u, target.host)
+	// 7. 返回给上层
+	resp := &dto.CreateURLResponse{
+		ShortUrl: s.shortURL(target.host, u.ShortCode),
+		//ExpiredAt: u.ExpiredAt,
+	}
+	if req.QR {
+		data, err := s.qr.PNG(resp.ShortUrl, s.qr.Defaults())
+		if err != nil {
+			return nil, fmt.Errorf("generate qrcode: %w", err)
+		}
+		resp.QR = "data:image/png;base64," + base64.StdEncoding.EncodeToString(data)
+	}
+	return resp, nil
+}
+
+// urlTarget 新建短链接所属的域名和工作区
+type urlTarget struct {
+	domainID    uint64
+	host        string // 默认域名时为空
+	workspaceID uint64
+}
+
+// resolveTarget 检查用户能否在指定的域名和工作区下创建短链接
+func (s *URLService) resolveTarget(ctx context.Context, req dto.CreateURLRequest) (urlTarget, error) {
+	var target urlTarget
+	// 指定了自定义域名时，必须是本人已验证的域名
+	if req.Domain != "" && !s.domains.isBaseHost(req.Domain) {
+		d, err := s.domains.byHost(ctx, req.Domain)
+		if err != nil {
+			return target, err
+		}
+		if d == nil || d.UserID != uint64(req.UserID) {
+			return target, ErrDomainNotFound
+		}
+		target.domainID, target.host = d.ID, d.Host
+	}
+	// 不指定工作区时放入个人工作区
+	workspaceID, err := s.workspaces.writable(ctx, req.UserID, req.WorkspaceID)
+	if err != nil {
+		return target, err
+	}
+	target.workspaceID = workspaceID
+	return target, nil
+}
+
+// prepareURL 分配短码并组装待入库的短链接，短码会先写入布隆过滤器
+func (s *URLService) prepareURL(ctx context.Context, req dto.CreateURLRequest, target urlTarget) (*model.URL, error) {
 	// 1. 决定要用的短码：优先用用户自己的，其次自动生成
 	code := req.CustomeCode
 	var err error
 	if code == "" {
-		code, err = s.getShortCode(ctx, 0)
+		code, err = s.getShortCode(ctx, target.domainID, 0)
 		if err != nil {
 			return nil, err
 		}
 	} else {
 		// 用户定制，先验证它是否可用
-		status, err := s.CheckShortCode(ctx, code)
+		if s.shortCodeGenerator.Blocked(code) {
+			return nil, ErrShortCodeReserved
+		}
+		status, err := s.CheckShortCode(ctx, target.domainID, code)
 		if err != nil {
 			return nil, fmt.Errorf("check custom shortcode: %w", err)
 		}
@@ -158,19 +711,19 @@ func (s *URLService) CreateURL(ctx context.Context, req dto.CreateURLRequest) (*
 		}
 	}
 	// 2. 写入布隆过滤器
-	s.filter.Add(code)
+	s.filter.Add(model.URLKey(target.domainID, code))
 	// 3. 组装要入库的 URL 实体
-	if req.Duration == nil {
-		expiredAt = time.Now().Add(s.defaultDuration)
-	} else {
-		expiredAt = time.Now().Add(time.Hour * time.Duration(*req.Duration))
-	}
 	u := &model.URL{
 		OriginalURL: req.OriginalURL,
 		ShortCode:   code,
 		IsCustom:    req.CustomeCode != "",
 		UserID:      uint64(req.UserID),
-		ExpiredAt:   expiredAt,
+		WorkspaceID: target.workspaceID,
+		DomainID:    target.domainID,
+
+		Title:        req.Title,
+		Description:  req.Description,
+		Interstitial: req.Interstitial,
 	}
 	// 4. 处理过期时间：不传的话使用默认有效期
 	if req.Duration == nil {
@@ -178,43 +731,112 @@ func (s *URLService) CreateURL(ctx context.Context, req dto.CreateURLRequest) (*
 	} else {
 		u.ExpiredAt = time.Now().Add(time.Duration(*req.Duration) * time.Hour)
 	}
-	// 5. 写库
-	if err := s.repo.UpsertURL(ctx, u); err != nil {
-		return nil, fmt.Errorf("create url record: %w", err)
-	}
-	// 6. 异步写缓存
+	return u, nil
+}
+
+// created 短链接入库后异步写缓存，并推送 link.created
+func (s *URLService) created(ctx context.Context, u *model.URL, host string) {
 	go func() {
 		if err := s.cache.SetURL(context.Background(), *u); err != nil {
 			log.Printf("failed to set cache: %v", err)
 		}
 	}()
-	// 6. 返回给上层
-	return &dto.CreateURLResponse{
-		ShortUrl: s.bashURL + "/" + u.ShortCode,
-		//ExpiredAt: u.ExpiredAt,
-	}, nil
+	s.events.Publish(ctx, u.UserID, model.EventLinkCreated, webhookLink(u, s.shortURL(host, u.ShortCode)))
+}
+
+// GetQRCode implements api.URLServicer.
+// 未指定的选项使用配置中的默认值
+func (s *URLService) GetQRCode(ctx context.Context, req dto.QRCodeRequest) (*dto.QRCodeResponse, error) {
+	domainID, err := s.resolveDomainID(ctx, req.Domain)
+	if err != nil {
+		return nil, err
+	}
+	url, err := s.getURL(ctx, domainID, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if url == nil {
+		return nil, ErrURLNotFound
+	}
+	// 与跳转一致：已暂停或已过期的短链接扫码后打不开，不再生成二维码
+	if url.Disabled {
+		return nil, ErrURLDisabled
+	}
+	if url.Expired {
+		return nil, ErrURLExpired
+	}
+
+	opt := s.qr.Defaults()
+	if req.Size > 0 {
+		opt.Size = req.Size
+	}
+	if req.Level != "" {
+		opt.Level = req.Level
+	}
+	if req.Margin != nil {
+		opt.Margin = *req.Margin
+	}
+	if req.Foreground != "" {
+		opt.Foreground = req.Foreground
+	}
+	if req.Background != "" {
+		opt.Background = req.Background
+	}
+
+	host := ""
+	if domainID != 0 {
+		host = normalizeHost(req.Domain)
+	}
+	content := s.shortURL(host, url.ShortCode)
+	if req.Format == "svg" {
+		data, err := s.qr.SVG(content, opt)
+		if err != nil {
+			return nil, err
+		}
+		return &dto.QRCodeResponse{ContentType: "image/svg+xml", Data: data}, nil
+	}
+	data, err := s.qr.PNG(content, opt)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.QRCodeResponse{ContentType: "image/png", Data: data}, nil
 }
 
-func (s *URLService) GetURL(ctx context.Context, shortCode string) (string, error) {
+// GetURL 按请求的 Host 与短码查找，Host 不是已验证的自定义域名时按默认域名处理
+// 找不到时返回 nil；已过期但尚未清理的短链接会返回，Expired 为 true；已暂停的短链接 Disabled 为 true
+func (s *URLService) GetURL(ctx context.Context, host, shortCode string) (*dto.URL, error) {
+	var domainID uint64
+	d, err := s.domains.byHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if d != nil {
+		domainID = d.ID
+	}
+	return s.getURL(ctx, domainID, shortCode)
+}
+
+func (s *URLService) getURL(ctx context.Context, domainID uint64, shortCode string) (*dto.URL, error) {
+	key := model.URLKey(domainID, shortCode)
 	// 1. 查找布隆过滤器
-	if !s.filter.Exists(shortCode) { // 布隆过滤器中不存在，则一定不存在。
-		return "", nil
+	if !s.filter.Exists(key) { // 布隆过滤器中不存在，则一定不存在。
+		return nil, nil
 	}
 	// 2. 访问缓存
-	url, err := s.cache.GetURL(ctx, shortCode)
+	url, err := s.cache.GetURL(ctx, key)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if url != nil {
-		return url.OriginalURL, nil
+		return toURLDTO(url), nil
 	}
 	// 3. 缓存中不存在，访问数据库
-	url, err = s.repo.GetURLByShortCode(ctx, shortCode)
+	url, err = s.repo.GetURLByShortCode(ctx, domainID, shortCode)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if url == nil { // 不是查询出错，而是数据库没该数据
-		return "", nil
+		return nil, nil
 	}
 	// 4. 存入缓存
 	go func() {
@@ -222,16 +844,39 @@ func (s *URLService) GetURL(ctx context.Context, shortCode string) (string, erro
 			log.Printf("failed to set cache: %v", err)
 		}
 	}()
-	return url.OriginalURL, nil
+	return toURLDTO(url), nil
+}
+
+func toURLDTO(url *model.URL) *dto.URL {
+	return &dto.URL{
+		ID:           url.ID,
+		WorkspaceID:  url.WorkspaceID,
+		OriginalURL:  url.OriginalURL,
+		ShortCode:    url.ShortCode,
+		DomainID:     url.DomainID,
+		UserID:       url.UserID,
+		Title:        url.Title,
+		Description:  url.Description,
+		Interstitial: url.Interstitial,
+		CreatedAt:    url.CreatedAt,
+		ExpiredAt:    url.ExpiredAt,
+		Expired:      url.IsExpired(),
+
+		Disabled:       url.Disabled,
+		DisabledReason: url.DisabledReason,
+	}
 }
 
 // @pragma n:重试次数
-func (s *URLService) getShortCode(ctx context.Context, n int) (string, error) {
+func (s *URLService) getShortCode(ctx context.Context, domainID uint64, n int) (string, error) {
 	if n > 5 {
 		return "", errors.New("retry too many times")
 	}
-	code := s.shortCodeGenerator.GenerateShortCode()
-	status, err := s.CheckShortCode(ctx, code)
+	code, err := s.shortCodeGenerator.GenerateShortCode()
+	if err != nil {
+		return "", err
+	}
+	status, err := s.CheckShortCode(ctx, domainID, code)
 	if err != nil {
 		return "", err
 	}
@@ -239,11 +884,38 @@ func (s *URLService) getShortCode(ctx context.Context, n int) (string, error) {
 		return code, nil
 	}
 	// 递归调用
-	return s.getShortCode(ctx, n+1)
+	return s.getShortCode(ctx, domainID, n+1)
 }
 
+// DeleteAllExpired 删除已过期的短链接并推送 link.expired，由定时任务调用
 func (s *URLService) DeleteAllExpired(ctx context.Context) error {
-	return s.repo.DeleteAllExpired(ctx)
+	for {
+		now := time.Now()
+		urls, err := s.repo.GetExpiredURLs(ctx, now, expiredBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(urls) == 0 {
+			return nil
+		}
+		ids := make([]uint64, len(urls))
+		for i, url := range urls {
+			ids[i] = url.ID
+		}
+		if err := s.repo.DeleteExpiredURLs(ctx, ids, now); err != nil {
+			return err
+		}
+		hosts, err := s.domainHosts(ctx, urls)
+		if err != nil {
+			return err
+		}
+		for _, url := range urls {
+			s.events.Publish(ctx, url.UserID, model.EventLinkExpired, webhookLink(url, s.shortURL(hosts[url.DomainID], url.ShortCode)))
+		}
+		if len(urls) < expiredBatchSize {
+			return nil
+		}
+	}
 }
 
 // TODO 短链接会过期，需要定时重建布隆过滤器（扫描整个数据库，很麻烦）
@@ -251,18 +923,30 @@ func (s *URLService) DeleteAllExpired(ctx context.Context) error {
 // CheckShortCode NEW: 从 repo 层提到 service, 与布隆过滤器的判断整合
 // TODO 增加缓存的逻辑
 // 如果存在于数据库、但是过期了，也视为合法 —— 生成新链接，写入数据库覆盖之前的
-func (s *URLService) CheckShortCode(ctx context.Context, shortCode string) (CodeStatus, error) {
-	if !s.filter.Exists(shortCode) { // 布隆过滤器中不存在，则一定不存在。
+// 短码空间按域名隔离
+func (s *URLService) CheckShortCode(ctx context.Context, domainID uint64, shortCode string) (CodeStatus, error) {
+	if !s.filter.Exists(model.URLKey(domainID, shortCode)) { // 布隆过滤器中不存在，则一定不存在。
 		return CodeAvailable, nil
 	}
 	// 布隆过滤器中存在，仍然可能不存在。判断是否在数据库中
-	url, err := s.repo.GetURLByShortCode(ctx, shortCode)
+	url, err := s.repo.GetURLByShortCode(ctx, domainID, shortCode)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return CodeAvailable, nil // 不存在
 	}
 	if err != nil {
 		return CodeInUse, err
 	}
+	if url == nil {
+		// 回收站中、仍可恢复的短码保留给原主人
+		trashed, err := s.repo.GetTrashedURL(ctx, domainID, shortCode)
+		if err != nil {
+			return CodeInUse, err
+		}
+		if trashed != nil && trashed.DeletedAt.Time.After(s.restorableSince()) {
+			return CodeInUse, nil
+		}
+		return CodeAvailable, nil // 布隆过滤器误判，或回收站中已超过保留期
+	}
 	if url.IsExpired() {
 		return CodeExpired, nil
 	}
@@ -293,9 +977,9 @@ func (s *URLService) SyncViewsToDB(ctx context.Context) error {
 				return err
 			}
 
-			shortCode := strings.Split(key, ":")[1]
+			domainID, shortCode := model.ParseURLKey(key)
 
-			if err := s.repo.UpdateViewsByShortCode(ctx, shortCode, int32(views)); err != nil {
+			if err := s.repo.UpdateViewsByShortCode(ctx, domainID, shortCode, int32(views)); err != nil {
 				return err
 			}
 		}
@@ -309,4 +993,74 @@ func (s *URLService) SyncViewsToDB(ctx context.Context) error {
 }
 
 var _ URLCacher = (*cache.RedisCache)(nil)
-var _ ShortCodeGenerator = (*shortcode.RandomShortCodeGeneratorImpl)(nil)
+var _ ShortCodeGenerator = (*shortcode.Generator)(nil)
+var _ QRCoder = (*qrcode.Generator)(nil)
+
+// shortURL 拼接完整短链接，host 为空时使用默认域名
+func (s *URLService) shortURL(host, code string) string {
+	if host == "" {
+		return s.bashURL + "/" + code
+	}
+	return s.scheme + "://" + host + "/" + code
+}
+
+// resolveDomainID 供管理接口使用：空或默认域名返回 0，其余必须是已验证的自定义域名
+func (s *URLService) resolveDomainID(ctx context.Context, host string) (uint64, error) {
+	if host == "" || s.domains.isBaseHost(host) {
+		return 0, nil
+	}
+	d, err := s.domains.byHost(ctx, host)
+	if err != nil {
+		return 0, err
+	}
+	if d == nil {
+		return 0, ErrDomainNotFound
+	}
+	return d.ID, nil
+}
+
+// emit 推送短链接事件，查询域名失败时放弃本次推送
+func (s *URLService) emit(ctx context.Context, event string, url *model.URL) {
+	hosts, err := s.domainHosts(ctx, []*model.URL{url})
+	if err != nil {
+		log.Printf("failed to publish %s: %v", event, err)
+		return
+	}
+	s.events.Publish(ctx, url.UserID, event, webhookLink(url, s.shortURL(hosts[url.DomainID], url.ShortCode)))
+}
+
+func webhookLink(url *model.URL, shortURL string) dto.WebhookLink {
+	return dto.WebhookLink{
+		ID:          url.ID,
+		ShortURL:    shortURL,
+		ShortCode:   url.ShortCode,
+		OriginalURL: url.OriginalURL,
+		WorkspaceID: url.WorkspaceID,
+		ExpiredAt:   url.ExpiredAt,
+		Disabled:    url.Disabled,
+		Views:       url.Views,
+		CreatedAt:   url.CreatedAt,
+	}
+}
+
+// domainHosts 查询一批短链接所属自定义域名的 host
+func (s *URLService) domainHosts(ctx context.Context, urls []*model.URL) (map[uint64]string, error) {
+	var ids []uint64
+	for _, u := range urls {
+		if u.DomainID != 0 {
+			ids = append(ids, u.DomainID)
+		}
+	}
+	hosts := make(map[uint64]string)
+	if len(ids) == 0 {
+		return hosts, nil
+	}
+	domains, err := s.domainRepo.GetDomainsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range domains {
+		hosts[d.ID] = d.Host
+	}
+	return hosts, nil
+}
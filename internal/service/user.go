@@ -3,15 +3,20 @@ package service
 import (
 	"context"
 	"fmt"
+	"log"
+	"time"
+
+	"github.com/jekyulll/url_shortener/config"
 
 	"github.com/jekyulll/url_shortener/internal/cache"
 	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/emails"
 	"github.com/jekyulll/url_shortener/internal/model"
 	"github.com/jekyulll/url_shortener/internal/repository"
-	"github.com/jekyulll/url_shortener/pkg/email"
 	"github.com/jekyulll/url_shortener/pkg/hasher"
 	"github.com/jekyulll/url_shortener/pkg/jwt"
 	"github.com/jekyulll/url_shortener/pkg/randnum"
+	"golang.org/x/text/language"
 )
 
 type PasswordHasher interface {
@@ -20,39 +25,51 @@ type PasswordHasher interface {
 }
 
 type UserCacher interface {
-	GetEmailCode(ctx context.Context, email string) (string, error)
 	SetEmailCode(ctx context.Context, email, emailCode string) error
+	CheckEmailCode(ctx context.Context, email, emailCode string, maxFailures int) (bool, error)
 }
 
+// EmailSender 按模板发送邮件，locale 为用户设置的语言或 Accept-Language，name 和 data 见 emails 包
 type EmailSender interface {
-	Send(email, emailCode string) error
+	SendTemplate(email, locale, name string, data any) error
 }
 
 type NumberRandomer interface {
-	Generate() string
+	Generate() (string, error)
 }
 
 type JWTer interface {
-	Generate(email string, userID int) (string, error)
+	Generate(email string, userID int, version int64, role string) (string, error)
+	Duration() time.Duration
 }
 
 type UserService struct {
-	repo           repository.UserRepository
-	passwordHasher PasswordHasher
-	jwter          JWTer
-	userCacher     UserCacher
-	emailSender    EmailSender
-	numberRandomer NumberRandomer
+	repo            repository.UserRepository
+	passwordHasher  PasswordHasher
+	jwter           JWTer
+	userCacher      UserCacher
+	emailSender     EmailSender
+	numberRandomer  NumberRandomer
+	tokens          TokenStore
+	guard           *loginGuard
+	refreshDuration time.Duration
 }
 
-func NewUserService(repo repository.UserRepository, p PasswordHasher, j JWTer, u UserCacher, e EmailSender, n NumberRandomer) *UserService {
+func NewUserService(repo repository.UserRepository, p PasswordHasher, j JWTer, u UserCacher, e EmailSender, n NumberRandomer, t TokenStore, l LoginAttemptStore, cfg config.JWTConfig, lockout config.LockoutConfig) *UserService {
+	refreshDuration := cfg.RefreshDuration
+	if refreshDuration <= 0 {
+		refreshDuration = defaultRefreshDuration
+	}
 	return &UserService{
-		repo:           repo,
-		passwordHasher: p,
-		jwter:          j,
-		userCacher:     u,
-		emailSender:    e,
-		numberRandomer: n,
+		repo:            repo,
+		passwordHasher:  p,
+		jwter:           j,
+		userCacher:      u,
+		emailSender:     e,
+		numberRandomer:  n,
+		tokens:          t,
+		guard:           newLoginGuard(l, lockout),
+		refreshDuration: refreshDuration,
 	}
 }
 
@@ -71,12 +88,8 @@ func (s *UserService) IsEmailAvailable(ctx context.Context, email string) error
 // Register implements api.UserService.
 func (s *UserService) Register(ctx context.Context, req dto.RegisterReqeust) (*dto.LoginResponse, error) {
 	// 判断验证码
-	emailCode, err := s.userCacher.GetEmailCode(ctx, req.Email)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get emailCode from cache: %v", err)
-	}
-	if emailCode != req.EmailCode {
-		return nil, ErrEmailCodeNotEqual
+	if err := s.checkEmailCode(ctx, req.Email, req.EmailCode, req.IP); err != nil {
+		return nil, err
 	}
 	// hash
 	hash, err := s.passwordHasher.HashPassword(req.Password)
@@ -86,32 +99,21 @@ func (s *UserService) Register(ctx context.Context, req dto.RegisterReqeust) (*d
 	user := model.User{
 		Email:        req.Email,
 		PasswordHash: hash,
+		Locale:       normalizeLocale(req.Locale),
 	}
 	// 写入
 	if err := s.repo.CreateUser(ctx, &user); err != nil {
 		return nil, fmt.Errorf("failed to create user: %v", err)
 	}
-	// access token
-	accessToken, err := s.jwter.Generate(user.Email, int(user.ID))
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate access token: %v", err)
-	}
-	return &dto.LoginResponse{
-		AccessToken: accessToken,
-		Email:       user.Email,
-		UserID:      int(user.ID),
-	}, nil
+	// access token + refresh token
+	return s.IssueTokens(ctx, &user)
 }
 
 // ResetPassword implements api.UserService.
 func (s *UserService) ResetPassword(ctx context.Context, req dto.ForgetPasswordReqeust) (*dto.LoginResponse, error) {
-	emailCode, err := s.userCacher.GetEmailCode(ctx, req.Email)
-	if err != nil {
+	if err := s.checkEmailCode(ctx, req.Email, req.EmailCode, req.IP); err != nil {
 		return nil, err
 	}
-	if emailCode != req.EmailCode {
-		return nil, ErrEmailCodeNotEqual
-	}
 	hash, err := s.passwordHasher.HashPassword(req.Password)
 	if err != nil {
 		return nil, err
@@ -121,53 +123,119 @@ func (s *UserService) ResetPassword(ctx context.Context, req dto.ForgetPasswordR
 	if err != nil {
 		return nil, err
 	}
-	accessToken, err := s.jwter.Generate(req.Email, int(id))
+	// 密码已重置，旧密码签发的会话全部作废
+	if err := s.RevokeAllSessions(ctx, int(id)); err != nil {
+		return nil, fmt.Errorf("failed to revoke sessions: %v", err)
+	}
+	// 验证码证明了邮箱的所有权，解除因密码错误导致的锁定
+	if err := s.guard.reset(ctx, req.Email); err != nil {
+		return nil, fmt.Errorf("failed to reset login failures: %v", err)
+	}
+	user, err := s.repo.GetUserByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	return &dto.LoginResponse{
-		AccessToken: accessToken,
-		Email:       req.Email,
-		UserID:      int(id),
-	}, nil
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	s.sendSecurityAlert(user, emails.SecurityAlertData{Event: emails.EventPasswordChanged, IP: req.IP})
+	// 重置密码不能绕过两步验证
+	return s.CompleteLogin(ctx, user)
 }
 
 // SendEmailCode implements api.UserService.
-func (s *UserService) SendEmailCode(ctx context.Context, email string) error {
-	emailCode := s.numberRandomer.Generate()
-	if err := s.emailSender.Send(email, emailCode); err != nil {
+// 已注册的邮箱发送重置密码邮件，使用用户设置的语言，否则发送注册验证码
+func (s *UserService) SendEmailCode(ctx context.Context, req dto.SendCodeRequest) error {
+	emailCode, err := s.numberRandomer.Generate()
+	if err != nil {
+		return fmt.Errorf("failed to generate emailCode: %v", err)
+	}
+	user, err := s.repo.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		return fmt.Errorf("failed to get user by email: %v", err)
+	}
+	name, locale := emails.Verification, req.Locale
+	if user != nil {
+		name = emails.PasswordReset
+		if user.Locale != "" {
+			locale = user.Locale
+		}
+	}
+	data := emails.CodeData{Code: emailCode, Minutes: int(cache.EmailCodeDuration.Minutes())}
+	if err := s.emailSender.SendTemplate(req.Email, locale, name, data); err != nil {
 		return fmt.Errorf("failed to send email: %v", err)
 	}
-	if err := s.userCacher.SetEmailCode(ctx, email, emailCode); err != nil {
+	if err := s.userCacher.SetEmailCode(ctx, req.Email, emailCode); err != nil {
 		return fmt.Errorf("failed to set email to cache: %v", err)
 	}
 	return nil
 }
 
 func (s *UserService) Login(ctx context.Context, req dto.LoginRequest) (*dto.LoginResponse, error) {
+	if err := s.guard.check(ctx, req.Email, req.IP); err != nil {
+		return nil, err
+	}
 	user, err := s.repo.GetUserByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by email: %v", err)
 	}
 
-	if !s.passwordHasher.ComparePassword(user.PasswordHash, req.Password) {
+	// 不存在的邮箱同样计数和锁定，避免从响应中区分账号是否存在
+	if user == nil || !s.passwordHasher.ComparePassword(user.PasswordHash, req.Password) {
+		if err := s.FailLogin(ctx, req.Email, req.IP, user); err != nil {
+			return nil, err
+		}
 		return nil, ErrUserNameOrPasswordFailed
 	}
+	// 失败记录在整个登录（包括两步验证）完成后才清除
+	return s.CompleteLogin(ctx, user)
+}
 
-	accessToken, err := s.jwter.Generate(user.Email, int(user.ID))
+// checkEmailCode 验证码只能使用一次，输错的次数计入 IP 的失败次数
+func (s *UserService) checkEmailCode(ctx context.Context, email, emailCode, ip string) error {
+	if err := s.guard.checkIP(ctx, ip); err != nil {
+		return err
+	}
+	ok, err := s.userCacher.CheckEmailCode(ctx, email, emailCode, s.guard.cfg.CodeMaxFailures)
 	if err != nil {
-		return nil, fmt.Errorf("failed to genrate access token: %v", err)
+		return fmt.Errorf("failed to check emailCode: %v", err)
+	}
+	if !ok {
+		if err := s.guard.failIP(ctx, ip); err != nil {
+			return err
+		}
+		return ErrEmailCodeNotEqual
 	}
+	return nil
+}
+
+// SetLocale implements api.UserServicer.
+func (s *UserService) SetLocale(ctx context.Context, req dto.SetLocaleRequest) error {
+	locale := normalizeLocale(req.Locale)
+	if locale == "" {
+		return ErrInvalidLocale
+	}
+	return s.repo.UpdateUserLocale(ctx, uint64(req.UserID), locale)
+}
 
-	return &dto.LoginResponse{
-		AccessToken: accessToken,
-		Email:       user.Email,
-		UserID:      int(user.ID),
-	}, nil
+// sendSecurityAlert 发送账号安全提醒，失败时只记录日志
+func (s *UserService) sendSecurityAlert(user *model.User, data emails.SecurityAlertData) {
+	data.Time = time.Now()
+	if err := s.emailSender.SendTemplate(user.Email, user.Locale, emails.SecurityAlert, data); err != nil {
+		log.Printf("failed to send %s alert to %s: %v", data.Event, user.Email, err)
+	}
+}
+
+// normalizeLocale 取语言标签或 Accept-Language 中的第一个语言，无法解析时返回空
+func normalizeLocale(s string) string {
+	tags, _, err := language.ParseAcceptLanguage(s)
+	if err != nil || len(tags) == 0 {
+		return ""
+	}
+	return tags[0].String()
 }
 
 var _ PasswordHasher = (*hasher.PasswordHash)(nil)
 var _ UserCacher = (*cache.RedisCache)(nil)
-var _ EmailSender = (*email.EmailSend)(nil)
 var _ NumberRandomer = (*randnum.RandNum)(nil)
 var _ JWTer = (*jwt.JWT)(nil)
@@ -0,0 +1,515 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jekyulll/url_shortener/config"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/model"
+	"github.com/jekyulll/url_shortener/internal/repository"
+	"gorm.io/gorm"
+)
+
+// 未配置时的投递参数
+const (
+	defaultWebhookPollInterval = 10 * time.Second
+	defaultWebhookMaxAttempts  = 8
+	defaultWebhookRetryDelay   = 30 * time.Second
+	defaultWebhookTimeout      = 10 * time.Second
+)
+
+const (
+	webhookSecretPrefix  = "whsec_"
+	webhookEventPrefix   = "evt_"
+	maxWebhooksPerUser   = 10
+	maxWebhookRetryDelay = time.Hour
+	webhookBatchSize     = 50
+	// 投递记录保留多久，期间可以查看和重放
+	webhookDeliveryRetention = 7 * 24 * time.Hour
+	// 读取响应体的上限，内容不保存
+	maxWebhookResponseSize = 64 << 10
+)
+
+// 可订阅的事件，按此顺序保存
+var webhookEvents = []string{
+	model.EventLinkCreated,
+	model.EventLinkUpdated,
+	model.EventLinkDeleted,
+	model.EventLinkExpired,
+	model.EventLinkClicked,
+	model.EventLinkExpiring,
+}
+
+// IPResolver 检查 webhook 地址时解析域名，*net.Resolver 即实现了该接口，测试时可替换
+type IPResolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// LinkEventPublisher 短链接发生变化时通知创建者订阅的 webhook
+type LinkEventPublisher interface {
+	// Publish 推送失败只记录日志，不影响调用方
+	Publish(ctx context.Context, userID uint64, event string, link dto.WebhookLink)
+	// SampleClick 按配置的比例决定本次访问是否推送 link.clicked
+	SampleClick() bool
+}
+
+// WebhookService 管理 webhook 订阅。事件发生时写入投递表，由后台任务调用 Deliver 推送，
+// 请求带 HMAC-SHA256 签名，失败后按指数退避重试，投递记录可查看和重放
+type WebhookService struct {
+	repo            repository.WebhookRepository
+	resolver        IPResolver
+	client          *http.Client
+	pollInterval    time.Duration
+	maxAttempts     int
+	retryDelay      time.Duration
+	clickSampleRate float64
+	wake            chan struct{}
+}
+
+func NewWebhookService(repo repository.WebhookRepository, resolver IPResolver, cfg config.WebhookConfig) *WebhookService {
+	s := &WebhookService{
+		repo:            repo,
+		resolver:        resolver,
+		pollInterval:    cfg.PollInterval,
+		maxAttempts:     cfg.MaxAttempts,
+		retryDelay:      cfg.RetryDelay,
+		clickSampleRate: cfg.ClickSampleRate,
+		wake:            make(chan struct{}, 1),
+	}
+	if s.pollInterval <= 0 {
+		s.pollInterval = defaultWebhookPollInterval
+	}
+	if s.maxAttempts <= 0 {
+		s.maxAttempts = defaultWebhookMaxAttempts
+	}
+	if s.retryDelay <= 0 {
+		s.retryDelay = defaultWebhookRetryDelay
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	s.client = newWebhookClient(timeout)
+	return s
+}
+
+// CreateWebhook implements api.WebhookServicer.
+func (s *WebhookService) CreateWebhook(ctx context.Context, req dto.CreateWebhookRequest) (*dto.CreateWebhookResponse, error) {
+	subs, err := s.repo.GetSubscriptionsByUserID(ctx, uint64(req.UserID))
+	if err != nil {
+		return nil, err
+	}
+	if len(subs) >= maxWebhooksPerUser {
+		return nil, ErrTooManyWebhooks
+	}
+	if err := checkWebhookURL(ctx, s.resolver, req.URL); err != nil {
+		return nil, err
+	}
+	secret, err := randomToken(24)
+	if err != nil {
+		return nil, err
+	}
+	sub := &model.WebhookSubscription{
+		UserID: uint64(req.UserID),
+		URL:    req.URL,
+		Secret: webhookSecretPrefix + secret,
+		Events: normalizeEvents(req.Events),
+		Active: true,
+	}
+	if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return &dto.CreateWebhookResponse{
+		WebhookResponse: toWebhookDTO(sub),
+		Secret:          sub.Secret,
+	}, nil
+}
+
+// GetWebhooks implements api.WebhookServicer.
+func (s *WebhookService) GetWebhooks(ctx context.Context, userID int) ([]dto.WebhookResponse, error) {
+	subs, err := s.repo.GetSubscriptionsByUserID(ctx, uint64(userID))
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]dto.WebhookResponse, len(subs))
+	for i := range subs {
+		resp[i] = toWebhookDTO(&subs[i])
+	}
+	return resp, nil
+}
+
+// UpdateWebhook implements api.WebhookServicer.
+func (s *WebhookService) UpdateWebhook(ctx context.Context, req dto.UpdateWebhookRequest) error {
+	sub, err := s.subscription(ctx, req.UserID, req.ID)
+	if err != nil {
+		return err
+	}
+	if req.URL != nil {
+		if err := checkWebhookURL(ctx, s.resolver, *req.URL); err != nil {
+			return err
+		}
+		sub.URL = *req.URL
+	}
+	if len(req.Events) > 0 {
+		sub.Events = normalizeEvents(req.Events)
+	}
+	if req.Active != nil {
+		sub.Active = *req.Active
+	}
+	return s.repo.UpdateSubscription(ctx, sub)
+}
+
+// DeleteWebhook implements api.WebhookServicer.
+func (s *WebhookService) DeleteWebhook(ctx context.Context, req dto.WebhookRequest) error {
+	err := s.repo.DeleteSubscription(ctx, uint64(req.UserID), req.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrWebhookNotFound
+	}
+	return err
+}
+
+// GetDeliveries implements api.WebhookServicer.
+func (s *WebhookService) GetDeliveries(ctx context.Context, req dto.GetWebhookDeliveriesRequest) (*dto.GetWebhookDeliveriesResponse, error) {
+	sub, err := s.subscription(ctx, req.UserID, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	size := req.Size
+	if size <= 0 {
+		size = defaultAdminPageSize
+	}
+	page := max(req.Page, 1)
+	deliveries, total, err := s.repo.GetDeliveries(ctx, sub.ID, req.Status, size, (page-1)*size)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]dto.WebhookDelivery, len(deliveries))
+	for i, d := range deliveries {
+		items[i] = dto.WebhookDelivery{
+			ID:             d.ID,
+			Event:          d.Event,
+			Payload:        d.Payload,
+			Status:         d.Status,
+			Attempts:       d.Attempts,
+			NextAttemptAt:  d.NextAttemptAt,
+			ResponseStatus: d.ResponseStatus,
+			LastError:      d.LastError,
+			DeliveredAt:    d.DeliveredAt,
+			CreatedAt:      d.CreatedAt,
+		}
+	}
+	return &dto.GetWebhookDeliveriesResponse{Items: items, Total: total}, nil
+}
+
+// ReplayDelivery implements api.WebhookServicer.
+// 按原请求体重新推送，已成功的也可以重放，重试次数清零
+func (s *WebhookService) ReplayDelivery(ctx context.Context, req dto.WebhookRequest, deliveryID uint64) error {
+	sub, err := s.subscription(ctx, req.UserID, req.ID)
+	if err != nil {
+		return err
+	}
+	err = s.repo.RequeueDelivery(ctx, sub.ID, deliveryID, time.Now())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return err
+	}
+	s.notify()
+	return nil
+}
+
+// subscription 不存在或不属于该用户时返回 ErrWebhookNotFound
+func (s *WebhookService) subscription(ctx context.Context, userID int, id uint64) (*model.WebhookSubscription, error) {
+	sub, err := s.repo.GetSubscription(ctx, uint64(userID), id)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, ErrWebhookNotFound
+	}
+	return sub, nil
+}
+
+// Publish implements LinkEventPublisher.
+func (s *WebhookService) Publish(ctx context.Context, userID uint64, event string, link dto.WebhookLink) {
+	if err := s.publish(ctx, userID, event, link); err != nil {
+		log.Printf("failed to publish %s for user %d: %v", event, userID, err)
+	}
+}
+
+// publish 为每个订阅了该事件的 webhook 写入一条投递，请求体在此时生成
+func (s *WebhookService) publish(ctx context.Context, userID uint64, event string, link dto.WebhookLink) error {
+	subs, err := s.repo.GetSubscriptionsByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	var deliveries []model.WebhookDelivery
+	for _, sub := range subs {
+		if sub.Active && sub.Subscribed(event) {
+			deliveries = append(deliveries, model.WebhookDelivery{SubscriptionID: sub.ID})
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	id, err := randomToken(16)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	payload, err := json.Marshal(dto.WebhookEvent{
+		ID:        webhookEventPrefix + id,
+		Event:     event,
+		CreatedAt: now,
+		Data:      link,
+	})
+	if err != nil {
+		return err
+	}
+	for i := range deliveries {
+		deliveries[i].Event = event
+		deliveries[i].Payload = string(payload)
+		deliveries[i].Status = model.WebhookPending
+		deliveries[i].NextAttemptAt = now
+	}
+	if err := s.repo.CreateDeliveries(ctx, deliveries); err != nil {
+		return err
+	}
+	s.notify()
+	return nil
+}
+
+// SampleClick implements LinkEventPublisher.
+func (s *WebhookService) SampleClick() bool {
+	return s.clickSampleRate > 0 && rand.Float64() < s.clickSampleRate
+}
+
+// notify 通知后台任务尽快投递，已有通知未处理时不重复发送
+func (s *WebhookService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Wake 有新的投递时可读
+func (s *WebhookService) Wake() <-chan struct{} {
+	return s.wake
+}
+
+func (s *WebhookService) PollInterval() time.Duration {
+	return s.pollInterval
+}
+
+// Deliver 推送所有到期的投递，由后台任务调用
+func (s *WebhookService) Deliver(ctx context.Context) error {
+	for {
+		deliveries, err := s.repo.GetDueDeliveries(ctx, time.Now(), webhookBatchSize)
+		if err != nil {
+			return err
+		}
+		ids := make([]uint64, len(deliveries))
+		for i, d := range deliveries {
+			ids[i] = d.SubscriptionID
+		}
+		subs := make(map[uint64]*model.WebhookSubscription)
+		if len(ids) > 0 {
+			rows, err := s.repo.GetSubscriptionsByIDs(ctx, ids)
+			if err != nil {
+				return err
+			}
+			for i := range rows {
+				subs[rows[i].ID] = &rows[i]
+			}
+		}
+		for i := range deliveries {
+			if err := s.deliver(ctx, &deliveries[i], subs[deliveries[i].SubscriptionID]); err != nil {
+				return err
+			}
+		}
+		if len(deliveries) < webhookBatchSize {
+			return nil
+		}
+	}
+}
+
+// deliver 推送一次并保存结果，只有保存失败时返回错误
+func (s *WebhookService) deliver(ctx context.Context, d *model.WebhookDelivery, sub *model.WebhookSubscription) error {
+	now := time.Now()
+	// 停用的订阅不再推送，重新启用后可以重放
+	if sub == nil || !sub.Active {
+		d.Status = model.WebhookDead
+		d.LastError = "webhook disabled"
+		return s.repo.UpdateDeliveryAttempt(ctx, d)
+	}
+	status, err := s.post(ctx, sub, d)
+	d.Attempts++
+	d.ResponseStatus = status
+	if err == nil {
+		d.Status = model.WebhookDelivered
+		d.DeliveredAt = &now
+		d.LastError = ""
+	} else {
+		d.LastError = truncateError(err.Error())
+		if d.Attempts >= s.maxAttempts {
+			d.Status = model.WebhookDead
+			log.Printf("webhook delivery %d to %s dead after %d attempts: %v", d.ID, sub.URL, d.Attempts, err)
+		} else {
+			d.NextAttemptAt = now.Add(backoff(s.retryDelay, d.Attempts, maxWebhookRetryDelay))
+		}
+	}
+	return s.repo.UpdateDeliveryAttempt(ctx, d)
+}
+
+// post 发送请求，返回响应的状态码，非 2xx 视为失败
+func (s *WebhookService) post(ctx context.Context, sub *model.WebhookSubscription, d *model.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, strings.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "url-shortener-webhook")
+	req.Header.Set("X-Webhook-Event", d.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(d.ID, 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+signWebhook(sub.Secret, timestamp, d.Payload))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// 读完响应体以复用连接
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookResponseSize))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// signWebhook 对 "时间戳.请求体" 做 HMAC-SHA256，接收方应同时检查时间戳以防重放
+func signWebhook(secret, timestamp, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newWebhookClient 推送 webhook 用的客户端。重定向视为失败，避免签名后的请求被转发到其他地址；
+// 连接时再检查一次对方地址，防止域名在订阅后被解析到内网（DNS rebinding）
+func newWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: webhookDialControl}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // 经过代理时检查到的只是代理的地址
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// webhookDialControl 在建立连接前检查解析后的地址
+func webhookDialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !publicAddr(ip) {
+		return fmt.Errorf("%w: %s", ErrWebhookURLForbidden, ip)
+	}
+	return nil
+}
+
+// checkWebhookURL 创建或修改订阅时检查，域名的任一地址不是公网地址即拒绝
+func checkWebhookURL(ctx context.Context, resolver IPResolver, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	host := u.Hostname()
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if !publicAddr(ip) {
+			return ErrWebhookURLForbidden
+		}
+		return nil
+	}
+	ips, err := resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(ips) == 0 {
+		return fmt.Errorf("%w: cannot resolve %s", ErrWebhookURLForbidden, host)
+	}
+	for _, ip := range ips {
+		if !publicAddr(ip) {
+			return ErrWebhookURLForbidden
+		}
+	}
+	return nil
+}
+
+// publicAddr 本机、链路本地、内网（RFC 1918、ULA）、组播和未指定地址都不允许
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsValid() &&
+		!ip.IsLoopback() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsPrivate() &&
+		!ip.IsUnspecified()
+}
+
+// PurgeDeliveries 删除超过保留期的投递记录，由定时任务调用
+func (s *WebhookService) PurgeDeliveries(ctx context.Context) error {
+	return s.repo.DeleteDeliveries(ctx, time.Now().Add(-webhookDeliveryRetention))
+}
+
+// 去重并按固定顺序保存
+func normalizeEvents(events []string) string {
+	var out []string
+	for _, event := range webhookEvents {
+		for _, e := range events {
+			if e == event {
+				out = append(out, event)
+				break
+			}
+		}
+	}
+	return strings.Join(out, ",")
+}
+
+func toWebhookDTO(sub *model.WebhookSubscription) dto.WebhookResponse {
+	return dto.WebhookResponse{
+		ID:        sub.ID,
+		URL:       sub.URL,
+		Events:    sub.EventList(),
+		Active:    sub.Active,
+		CreatedAt: sub.CreatedAt,
+	}
+}
+
+var _ LinkEventPublisher = (*WebhookService)(nil)
+var _ IPResolver = (*net.Resolver)(nil)
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+// stubIPResolver 按表返回解析结果，不在表中的域名解析失败
+type stubIPResolver map[string][]string
+
+func (r stubIPResolver) LookupNetIP(_ context.Context, _, host string) ([]netip.Addr, error) {
+	addrs, ok := r[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	ips := make([]netip.Addr, len(addrs))
+	for i, a := range addrs {
+		ips[i] = netip.MustParseAddr(a)
+	}
+	return ips, nil
+}
+
+func TestCheckWebhookURL(t *testing.T) {
+	resolver := stubIPResolver{
+		"hooks.example.com":    {"93.184.216.34"},
+		"internal.example.com": {"10.0.0.5"},
+		"mixed.example.com":    {"93.184.216.34", "192.168.1.10"},
+		"metadata.example.com": {"169.254.169.254"},
+	}
+	tests := []struct {
+		url string
+		ok  bool
+	}{
+		{"https://hooks.example.com/path", true},
+		{"https://93.184.216.34/hook", true},
+		{"http://127.0.0.1:8080/hook", false},
+		{"http://[::1]/hook", false},
+		{"http://[::ffff:127.0.0.1]/hook", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://172.16.0.1/hook", false},
+		{"http://[fd00::1]/hook", false},
+		{"http://0.0.0.0/hook", false},
+		{"https://internal.example.com/hook", false},
+		{"https://mixed.example.com/hook", false},
+		{"https://metadata.example.com/hook", false},
+		{"https://unknown.example.com/hook", false},
+	}
+	for _, tt := range tests {
+		err := checkWebhookURL(context.Background(), resolver, tt.url)
+		if tt.ok && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.url, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrWebhookURLForbidden) {
+			t.Errorf("%s: got %v, want ErrWebhookURLForbidden", tt.url, err)
+		}
+	}
+}
+
+func TestWebhookDialControl(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1:443", "10.1.2.3:80", "[::1]:443", "169.254.169.254:80"} {
+		if err := webhookDialControl("tcp", addr, nil); !errors.Is(err, ErrWebhookURLForbidden) {
+			t.Errorf("%s: got %v, want ErrWebhookURLForbidden", addr, err)
+		}
+	}
+	if err := webhookDialControl("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("public address rejected: %v", err)
+	}
+}
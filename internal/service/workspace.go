@@ -0,0 +1,401 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jekyulll/url_shortener/config"
+	"github.com/jekyulll/url_shortener/internal/dto"
+	"github.com/jekyulll/url_shortener/internal/emails"
+	"github.com/jekyulll/url_shortener/internal/model"
+	"github.com/jekyulll/url_shortener/internal/repository"
+	"gorm.io/gorm"
+)
+
+// 邀请的有效期
+const inviteTTL = 7 * 24 * time.Hour
+
+type WorkspaceService struct {
+	repo    repository.WorkspaceRepository
+	access  *workspaceAccess
+	users   repository.UserRepository
+	mailer  EmailSender
+	baseURL string
+}
+
+func NewWorkspaceService(repo repository.WorkspaceRepository, users repository.UserRepository, mailer EmailSender, cfg config.AppConfig) *WorkspaceService {
+	return &WorkspaceService{
+		repo:    repo,
+		access:  newWorkspaceAccess(repo),
+		users:   users,
+		mailer:  mailer,
+		baseURL: cfg.BaseURL,
+	}
+}
+
+// CreateWorkspace implements api.WorkspaceServicer.
+// 创建团队工作区，创建者为 owner
+func (s *WorkspaceService) CreateWorkspace(ctx context.Context, req dto.CreateWorkspaceRequest) (*dto.WorkspaceResponse, error) {
+	workspace := &model.Workspace{Name: req.Name}
+	if err := s.repo.CreateWorkspace(ctx, workspace, uint64(req.UserID)); err != nil {
+		return nil, fmt.Errorf("create workspace: %w", err)
+	}
+	resp := toWorkspaceDTO(workspace, model.WorkspaceOwner)
+	return &resp, nil
+}
+
+// GetWorkspaces implements api.WorkspaceServicer.
+// 个人工作区总在其中，没有时自动创建
+func (s *WorkspaceService) GetWorkspaces(ctx context.Context, userID int) ([]dto.WorkspaceResponse, error) {
+	if _, err := s.repo.EnsurePersonalWorkspace(ctx, uint64(userID)); err != nil {
+		return nil, err
+	}
+	members, err := s.repo.GetMembershipsByUserID(ctx, uint64(userID))
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]dto.WorkspaceResponse, 0, len(members))
+	for _, m := range members {
+		if m.Workspace != nil {
+			resp = append(resp, toWorkspaceDTO(m.Workspace, m.Role))
+		}
+	}
+	return resp, nil
+}
+
+// GetWorkspace implements api.WorkspaceServicer.
+// 所有成员均可查看成员列表
+func (s *WorkspaceService) GetWorkspace(ctx context.Context, req dto.WorkspaceRequest) (*dto.WorkspaceDetailResponse, error) {
+	workspace, role, err := s.getWorkspace(ctx, req.ID, req.UserID, model.WorkspaceViewer)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.repo.GetMembers(ctx, workspace.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &dto.WorkspaceDetailResponse{
+		WorkspaceResponse: toWorkspaceDTO(workspace, role),
+		Members:           make([]dto.WorkspaceMemberResponse, len(members)),
+	}
+	for i, m := range members {
+		resp.Members[i] = dto.WorkspaceMemberResponse{
+			UserID:   m.UserID,
+			Role:     m.Role,
+			JoinedAt: m.CreatedAt,
+		}
+		if m.User != nil {
+			resp.Members[i].Email = m.User.Email
+		}
+	}
+	return resp, nil
+}
+
+// UpdateWorkspace implements api.WorkspaceServicer.
+func (s *WorkspaceService) UpdateWorkspace(ctx context.Context, req dto.UpdateWorkspaceRequest) (*dto.WorkspaceResponse, error) {
+	workspace, role, err := s.getWorkspace(ctx, req.ID, req.UserID, model.WorkspaceOwner)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateWorkspaceName(ctx, workspace.ID, req.Name); err != nil {
+		return nil, err
+	}
+	workspace.Name = req.Name
+	resp := toWorkspaceDTO(workspace, role)
+	return &resp, nil
+}
+
+// DeleteWorkspace implements api.WorkspaceServicer.
+// 个人工作区不能删除，还有短链接（包括回收站中的）时不允许删除
+func (s *WorkspaceService) DeleteWorkspace(ctx context.Context, req dto.WorkspaceRequest) error {
+	workspace, _, err := s.getWorkspace(ctx, req.ID, req.UserID, model.WorkspaceOwner)
+	if err != nil {
+		return err
+	}
+	if workspace.Personal() {
+		return ErrPersonalWorkspace
+	}
+	cnt, err := s.repo.CountURLsByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		return err
+	}
+	if cnt > 0 {
+		return ErrWorkspaceNotEmpty
+	}
+	return s.repo.DeleteWorkspace(ctx, workspace.ID)
+}
+
+// InviteMember implements api.WorkspaceServicer.
+// 邀请邮件中带有一次性 token，受邀人用该邮箱登录后接受
+func (s *WorkspaceService) InviteMember(ctx context.Context, req dto.InviteMemberRequest) (*dto.InviteResponse, error) {
+	workspace, _, err := s.getWorkspace(ctx, req.ID, req.UserID, model.WorkspaceOwner)
+	if err != nil {
+		return nil, err
+	}
+	if workspace.Personal() {
+		return nil, ErrPersonalWorkspace
+	}
+	addr := strings.ToLower(strings.TrimSpace(req.Email))
+	invitee, err := s.users.GetUserByEmail(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	if invitee != nil {
+		member, err := s.repo.GetMember(ctx, workspace.ID, invitee.ID)
+		if err != nil {
+			return nil, err
+		}
+		if member != nil {
+			return nil, ErrWorkspaceMemberExists
+		}
+	}
+	inviter, err := s.users.GetUserByID(ctx, uint64(req.UserID))
+	if err != nil {
+		return nil, err
+	}
+	if inviter == nil {
+		return nil, ErrUserNotFound
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	invite := &model.WorkspaceInvite{
+		WorkspaceID: workspace.ID,
+		Email:       addr,
+		Role:        req.Role,
+		TokenHash:   hashToken(token),
+		InvitedBy:   inviter.ID,
+		ExpiresAt:   time.Now().Add(inviteTTL),
+	}
+	if err := s.repo.CreateInvite(ctx, invite); err != nil {
+		return nil, fmt.Errorf("create invite: %w", err)
+	}
+
+	// 受邀人已注册时使用其语言，否则使用邀请人的
+	locale := inviter.Locale
+	if invitee != nil && invitee.Locale != "" {
+		locale = invitee.Locale
+	}
+	data := emails.WorkspaceInviteData{
+		Inviter:   inviter.Email,
+		Workspace: workspace.Name,
+		Role:      req.Role,
+		BaseURL:   s.baseURL,
+		Token:     token,
+		ExpiresAt: invite.ExpiresAt,
+	}
+	if err := s.mailer.SendTemplate(addr, locale, emails.WorkspaceInvite, data); err != nil {
+		return nil, fmt.Errorf("send invite email: %w", err)
+	}
+	return &dto.InviteResponse{
+		ID:        invite.ID,
+		Email:     invite.Email,
+		Role:      invite.Role,
+		ExpiresAt: invite.ExpiresAt,
+	}, nil
+}
+
+// AcceptInvite implements api.WorkspaceServicer.
+// 只有受邀邮箱对应的账号可以接受
+func (s *WorkspaceService) AcceptInvite(ctx context.Context, req dto.AcceptInviteRequest) (*dto.WorkspaceResponse, error) {
+	invite, err := s.repo.GetInviteByHash(ctx, hashToken(req.Token))
+	if err != nil {
+		return nil, err
+	}
+	if invite == nil || !invite.Pending() || invite.Workspace == nil {
+		return nil, ErrInviteInvalid
+	}
+	user, err := s.users.GetUserByID(ctx, uint64(req.UserID))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !strings.EqualFold(user.Email, invite.Email) {
+		return nil, ErrInviteInvalid
+	}
+	err = s.repo.AcceptInvite(ctx, invite, user.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInviteInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	role, err := s.access.role(ctx, invite.WorkspaceID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	resp := toWorkspaceDTO(invite.Workspace, role)
+	return &resp, nil
+}
+
+// UpdateMember implements api.WorkspaceServicer.
+// 修改成员角色，工作区至少保留一个 owner
+func (s *WorkspaceService) UpdateMember(ctx context.Context, req dto.MemberRequest) error {
+	workspace, _, err := s.getWorkspace(ctx, req.ID, req.UserID, model.WorkspaceOwner)
+	if err != nil {
+		return err
+	}
+	member, err := s.getMember(ctx, workspace.ID, req.MemberID)
+	if err != nil {
+		return err
+	}
+	if member.Role == req.Role {
+		return nil
+	}
+	if err := s.keepOwner(ctx, member); err != nil {
+		return err
+	}
+	return s.repo.UpdateMemberRole(ctx, workspace.ID, member.UserID, req.Role)
+}
+
+// RemoveMember implements api.WorkspaceServicer.
+// owner 可以移除任何成员，其他成员只能移除自己（退出工作区）。
+// 成员创建的短链接仍留在工作区中
+func (s *WorkspaceService) RemoveMember(ctx context.Context, req dto.MemberRequest) error {
+	minRole := model.WorkspaceOwner
+	if req.MemberID == uint64(req.UserID) {
+		minRole = model.WorkspaceViewer
+	}
+	workspace, _, err := s.getWorkspace(ctx, req.ID, req.UserID, minRole)
+	if err != nil {
+		return err
+	}
+	member, err := s.getMember(ctx, workspace.ID, req.MemberID)
+	if err != nil {
+		return err
+	}
+	if err := s.keepOwner(ctx, member); err != nil {
+		return err
+	}
+	return s.repo.DeleteMember(ctx, workspace.ID, member.UserID)
+}
+
+// getWorkspace 要求当前用户在工作区中的角色不低于 min，同时返回该角色
+func (s *WorkspaceService) getWorkspace(ctx context.Context, id uint64, userID int, min string) (*model.Workspace, string, error) {
+	role, err := s.access.require(ctx, id, userID, min)
+	if err != nil {
+		return nil, "", err
+	}
+	workspace, err := s.repo.GetWorkspaceByID(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if workspace == nil {
+		return nil, "", ErrWorkspaceNotFound
+	}
+	return workspace, role, nil
+}
+
+func (s *WorkspaceService) getMember(ctx context.Context, workspaceID, userID uint64) (*model.WorkspaceMember, error) {
+	member, err := s.repo.GetMember(ctx, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrMemberNotFound
+	}
+	return member, nil
+}
+
+// keepOwner 该成员是最后一个 owner 时不允许降级或移除
+func (s *WorkspaceService) keepOwner(ctx context.Context, member *model.WorkspaceMember) error {
+	if member.Role != model.WorkspaceOwner {
+		return nil
+	}
+	cnt, err := s.repo.CountOwners(ctx, member.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	if cnt <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+func toWorkspaceDTO(w *model.Workspace, role string) dto.WorkspaceResponse {
+	return dto.WorkspaceResponse{
+		ID:        w.ID,
+		Name:      w.Name,
+		Personal:  w.Personal(),
+		Role:      role,
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+// workspaceAccess 检查用户在工作区中的角色，URLService 与 WorkspaceService 共用
+type workspaceAccess struct {
+	repo repository.WorkspaceRepository
+}
+
+func newWorkspaceAccess(repo repository.WorkspaceRepository) *workspaceAccess {
+	return &workspaceAccess{
+		repo: repo,
+	}
+}
+
+// role 不是成员时返回空串
+func (a *workspaceAccess) role(ctx context.Context, workspaceID uint64, userID int) (string, error) {
+	member, err := a.repo.GetMember(ctx, workspaceID, uint64(userID))
+	if err != nil || member == nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// require 不是成员时返回 ErrWorkspaceNotFound，不暴露工作区是否存在；角色不足时返回 ErrWorkspaceForbidden
+func (a *workspaceAccess) require(ctx context.Context, workspaceID uint64, userID int, min string) (string, error) {
+	role, err := a.role(ctx, workspaceID, userID)
+	if err != nil {
+		return "", err
+	}
+	if role == "" {
+		return "", ErrWorkspaceNotFound
+	}
+	if !model.WorkspaceRoleAtLeast(role, min) {
+		return "", ErrWorkspaceForbidden
+	}
+	return role, nil
+}
+
+// personal 用户的个人工作区，没有时自动创建
+func (a *workspaceAccess) personal(ctx context.Context, userID uint64) (uint64, error) {
+	workspace, err := a.repo.EnsurePersonalWorkspace(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	return workspace.ID, nil
+}
+
+// writable 用户要写入的工作区：不指定时为个人工作区，团队工作区需要 editor 及以上角色
+func (a *workspaceAccess) writable(ctx context.Context, userID int, workspaceID uint64) (uint64, error) {
+	if workspaceID == 0 {
+		return a.personal(ctx, uint64(userID))
+	}
+	if _, err := a.require(ctx, workspaceID, userID, model.WorkspaceEditor); err != nil {
+		return 0, err
+	}
+	return workspaceID, nil
+}
+
+// visible 用户可以查看的工作区：指定了 workspaceID 时只有该工作区，否则为所在的全部工作区
+func (a *workspaceAccess) visible(ctx context.Context, userID int, workspaceID uint64) ([]uint64, error) {
+	if workspaceID != 0 {
+		if _, err := a.require(ctx, workspaceID, userID, model.WorkspaceViewer); err != nil {
+			return nil, err
+		}
+		return []uint64{workspaceID}, nil
+	}
+	members, err := a.repo.GetMembershipsByUserID(ctx, uint64(userID))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint64, len(members))
+	for i, m := range members {
+		ids[i] = m.WorkspaceID
+	}
+	return ids, nil
+}
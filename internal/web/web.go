@@ -0,0 +1,52 @@
+package web
+
+import (
+	"embed"
+	"html/template"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var funcs = template.FuncMap{
+	"date": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04")
+	},
+}
+
+// LoadTemplates 解析内置的 HTML 模板，供 gin 的 c.HTML 使用
+// dir 不为空时，目录下的同名 .html 文件会覆盖内置模板
+func LoadTemplates(dir string) (*template.Template, error) {
+	tmpl, err := template.New("").Funcs(funcs).ParseFS(templateFS, "templates/*.html")
+	if err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		return tmpl, nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tmpl.New(filepath.Base(file)).Parse(string(src)); err != nil {
+			return nil, err
+		}
+	}
+	return tmpl, nil
+}
+
+// ParsePage 解析用户自定义的落地页模板，可使用与内置模板相同的函数
+func ParsePage(src string) (*template.Template, error) {
+	return template.New(PageTemplateName).Funcs(funcs).Parse(src)
+}
+
+// 自定义落地页模板的名字，渲染时使用
+const PageTemplateName = "page"
@@ -1,40 +1,62 @@
 package email
 
 import (
+	"context"
 	"fmt"
-	"net/smtp"
+	"strings"
 
 	"github.com/jekyulll/url_shortener/config"
 	mail "github.com/jordan-wright/email"
 )
 
-type EmailSend struct {
-	addr    string
-	myEail  string
-	subject string
-	auth    smtp.Auth
+const (
+	TransportSMTP   = "smtp"
+	TransportFile   = "file"   // 写入 maildir 目录，开发环境查看邮件用
+	TransportMemory = "memory" // 保存在内存中，测试用
+)
+
+// Message 一封邮件，HTML 不为空时与纯文本一起作为 multipart/alternative 发送
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Transport 实际投递邮件的方式
+type Transport interface {
+	Send(ctx context.Context, msg Message) error
 }
 
-func NewEmailSend(cfg config.EmailConfig) (*EmailSend, error) {
-	emailSend := &EmailSend{
-		addr:    fmt.Sprintf("%s:%s", cfg.HostAddress, cfg.HostPort),
-		auth:    smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.HostAddress),
-		myEail:  cfg.Username,
-		subject: cfg.Subject,
+// NewTransport 按配置创建投递方式，不会在启动时连接邮件服务器
+func NewTransport(cfg config.EmailConfig) (Transport, error) {
+	switch strings.ToLower(cfg.Transport) {
+	case "", TransportSMTP:
+		return NewSMTP(cfg), nil
+	case TransportFile:
+		return NewFile(cfg.Dir, from(cfg))
+	case TransportMemory:
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown email transport %q", cfg.Transport)
 	}
-	if err := emailSend.Send(cfg.TestMail, "test email"); err != nil {
-		fmt.Printf("send email failed: %v\n", err)
-		return nil, err
+}
+
+func from(cfg config.EmailConfig) string {
+	if cfg.From != "" {
+		return cfg.From
 	}
-	return emailSend, nil
+	return cfg.Username
 }
 
-func (e *EmailSend) Send(email string, emailCode string) error {
+func newMail(from string, msg Message) *mail.Email {
 	instance := mail.NewEmail()
-	instance.From = e.myEail
-	instance.To = []string{email}
-	instance.Subject = e.subject
-	instance.Text = []byte(fmt.Sprintf("Your Verification code is: %s", emailCode))
-
-	return instance.Send(e.addr, e.auth)
+	instance.From = from
+	instance.To = []string{msg.To}
+	instance.Subject = msg.Subject
+	instance.Text = []byte(msg.Text)
+	if msg.HTML != "" {
+		instance.HTML = []byte(msg.HTML)
+	}
+	return instance
 }
@@ -0,0 +1,49 @@
+package email
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// File 按 maildir 格式把邮件写入目录：先写 tmp/，完成后移到 new/，可直接用邮件客户端打开
+type File struct {
+	dir  string
+	from string
+}
+
+func NewFile(dir, from string) (*File, error) {
+	if dir == "" {
+		dir = "maildir"
+	}
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return nil, fmt.Errorf("create maildir: %w", err)
+		}
+	}
+	return &File{dir: dir, from: from}, nil
+}
+
+// Send implements Transport.
+func (f *File) Send(ctx context.Context, msg Message) error {
+	data, err := newMail(f.from, msg).Bytes()
+	if err != nil {
+		return err
+	}
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d.%s.url_shortener", time.Now().UnixNano(), hex.EncodeToString(suffix))
+	tmp := filepath.Join(f.dir, "tmp", name)
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(f.dir, "new", name))
+}
+
+var _ Transport = (*File)(nil)
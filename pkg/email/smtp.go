@@ -0,0 +1,107 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+
+	"github.com/jekyulll/url_shortener/config"
+)
+
+// 未配置时连接并发送一封邮件的超时时间
+const defaultSMTPTimeout = 30 * time.Second
+
+type SMTP struct {
+	addr    string
+	host    string
+	from    string
+	auth    smtp.Auth
+	timeout time.Duration
+}
+
+func NewSMTP(cfg config.EmailConfig) *SMTP {
+	s := &SMTP{
+		addr:    net.JoinHostPort(cfg.HostAddress, cfg.HostPort),
+		host:    cfg.HostAddress,
+		auth:    smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.HostAddress),
+		from:    from(cfg),
+		timeout: cfg.Timeout,
+	}
+	if s.timeout <= 0 {
+		s.timeout = defaultSMTPTimeout
+	}
+	return s
+}
+
+// Send implements Transport.
+// 与 smtp.SendMail 的流程相同，但整个会话受 ctx 和 timeout 限制，服务器无响应时不会一直阻塞
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	m := newMail(s.from, msg)
+	raw, err := m.Bytes()
+	if err != nil {
+		return err
+	}
+	sender, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return fmt.Errorf("invalid from address: %w", err)
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid to address: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	// ctx 提前取消时中断正在进行的读写
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+	if ok, _ := c.Extension("AUTH"); ok {
+		if err := c.Auth(s.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(sender.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+var _ Transport = (*SMTP)(nil)
@@ -0,0 +1,142 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jekyulll/url_shortener/config"
+)
+
+// fakeSMTP 最简单的 SMTP 服务器，不支持 STARTTLS 和 AUTH，收到的命令和正文写入 got
+func fakeSMTP(t *testing.T, ln net.Listener, got chan<- string) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+	reply("220 fake ESMTP")
+	var log strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Errorf("fake smtp: %v", err)
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		log.WriteString(cmd + "\n")
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"):
+			reply("250 fake")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				log.WriteString(line)
+			}
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			got <- log.String()
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	return ln
+}
+
+func newTestSMTP(ln net.Listener, timeout time.Duration) *SMTP {
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	return NewSMTP(config.EmailConfig{
+		HostAddress: host,
+		HostPort:    port,
+		Username:    "noreply@example.com",
+		Timeout:     timeout,
+	})
+}
+
+func TestSMTPSend(t *testing.T) {
+	ln := listen(t)
+	got := make(chan string, 1)
+	go fakeSMTP(t, ln, got)
+
+	s := newTestSMTP(ln, time.Second)
+	err := s.Send(context.Background(), Message{To: "User <user@example.com>", Subject: "Hi", Text: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := <-got
+	for _, want := range []string{"MAIL FROM:<noreply@example.com>", "RCPT TO:<user@example.com>", "Subject: Hi", "hello"} {
+		if !strings.Contains(session, want) {
+			t.Errorf("session missing %q:\n%s", want, session)
+		}
+	}
+}
+
+func TestSMTPSendTimeout(t *testing.T) {
+	// 服务器接受连接但不发送问候
+	ln := listen(t)
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(5 * time.Second)
+		}
+	}()
+
+	s := newTestSMTP(ln, 100*time.Millisecond)
+	start := time.Now()
+	err := s.Send(context.Background(), Message{To: "user@example.com", Subject: "Hi", Text: "hello"})
+	if err == nil {
+		t.Fatal("expected a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("send blocked for %v", elapsed)
+	}
+}
+
+func TestSMTPSendContextCanceled(t *testing.T) {
+	ln := listen(t)
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(5 * time.Second)
+		}
+	}()
+
+	s := newTestSMTP(ln, time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	err := s.Send(ctx, Message{To: "user@example.com", Subject: "Hi", Text: "hello"})
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("got %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("send blocked for %v after cancel", elapsed)
+	}
+}
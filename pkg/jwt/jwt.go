@@ -1,49 +1,157 @@
 package jwt
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/jekyulll/url_shortener/config"
 )
 
+const (
+	// 未配置时 access token 的有效期，需配合 refresh token 续期
+	defaultDuration = 15 * time.Minute
+	// 非对称签名的默认配置
+	defaultKeyDir    = "keys"
+	defaultRotation  = 30 * 24 * time.Hour
+	defaultRetention = 24 * time.Hour
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
 type JWT struct {
-	secret   []byte
+	method   jwt.SigningMethod
+	secret   []byte // 仅 HS256
 	duration time.Duration
+	issuer   string
+
+	// 非对称签名（RS256、EdDSA）使用的密钥，按创建时间升序，最后一个用于签名
+	keyDir    string
+	rotation  time.Duration
+	retention time.Duration
+	mu        sync.RWMutex
+	keys      []*signingKey
 }
 
-func NewJWT(cfg config.JWTConfig) *JWT {
-	return &JWT{
-		secret:   []byte(cfg.Secret),
-		duration: cfg.Duration,
+// NewJWT 非对称算法下会从 key_dir 加载密钥，目录为空时生成第一个密钥
+func NewJWT(cfg config.JWTConfig) (*JWT, error) {
+	j := &JWT{
+		secret:    []byte(cfg.Secret),
+		duration:  cfg.Duration,
+		issuer:    cfg.Issuer,
+		keyDir:    cfg.KeyDir,
+		rotation:  cfg.RotationInterval,
+		retention: cfg.KeyRetention,
+	}
+	if j.duration <= 0 {
+		j.duration = defaultDuration
+	}
+	if j.keyDir == "" {
+		j.keyDir = defaultKeyDir
+	}
+	if j.rotation <= 0 {
+		j.rotation = defaultRotation
+	}
+	if j.retention <= 0 {
+		j.retention = defaultRetention
+	}
+	// 轮换后旧密钥至少要保留到它签发的 token 全部过期
+	if j.retention < j.duration {
+		j.retention = j.duration
+	}
+
+	switch strings.ToUpper(cfg.Algorithm) {
+	case "", "HS256":
+		j.method = jwt.SigningMethodHS256
+		return j, nil
+	case "RS256":
+		j.method = jwt.SigningMethodRS256
+	case "EDDSA":
+		j.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm: %s", cfg.Algorithm)
 	}
+	if err := j.Reload(); err != nil {
+		return nil, err
+	}
+	if j.current() == nil {
+		if err := j.addKey(); err != nil {
+			return nil, err
+		}
+	}
+	return j, nil
 }
 
 type UserClaims struct {
-	Email  string `json:"email"`
-	UserID int    `json:"user_id"`
+	Email   string `json:"email"`
+	UserID  int    `json:"user_id"`
+	Version int64  `json:"ver"` // 用户的 token 版本，版本号变更后旧 token 全部失效
+	Role    string `json:"role"`
 	jwt.RegisteredClaims
 }
 
-func (j *JWT) Generate(email string, userId int) (string, error) {
+// Generate 签发 access token，每个 token 带唯一的 jti 以便单独吊销
+func (j *JWT) Generate(email string, userId int, version int64, role string) (string, error) {
+	now := time.Now()
 	claims := UserClaims{
-		Email:  email,
-		UserID: userId,
+		Email:   email,
+		UserID:  userId,
+		Version: version,
+		Role:    role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.duration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        uuid.NewString(),
+			Issuer:    j.issuer,
+			ExpiresAt: jwt.NewNumericDate(now.Add(j.duration)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 	// 两行重点
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(j.secret)
+	token := jwt.NewWithClaims(j.method, claims)
+	if !j.Asymmetric() {
+		return token.SignedString(j.secret)
+	}
+	key := j.current()
+	if key == nil {
+		return "", ErrUnknownKey
+	}
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.private)
+}
+
+// Duration access token 的有效期
+func (j *JWT) Duration() time.Duration {
+	return j.duration
+}
+
+// Asymmetric 是否使用非对称签名，此时公钥可通过 JWKS 发布
+func (j *JWT) Asymmetric() bool {
+	return j.method != jwt.SigningMethodHS256
 }
 
 func (j *JWT) ParseToken(tokenString string) (*UserClaims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{j.method.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if j.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(j.issuer))
+	}
 	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(t *jwt.Token) (interface{}, error) {
-		return j.secret, nil
-	})
+		if !j.Asymmetric() {
+			return j.secret, nil
+		}
+		// 轮换下来的旧密钥在保留期内仍可验证
+		kid, _ := t.Header["kid"].(string)
+		key := j.lookup(kid)
+		if key == nil {
+			return nil, ErrUnknownKey
+		}
+		return key.public, nil
+	}, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,268 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const rsaKeyBits = 2048
+
+// signingKey 私钥以 PKCS#8 PEM 保存在 key_dir 下，文件名即 kid，
+// kid 形如 "<创建时间 unix 秒>-<随机串>"，多个实例共享同一目录即可共享密钥
+type signingKey struct {
+	kid       string
+	createdAt time.Time
+	private   crypto.Signer
+	public    crypto.PublicKey
+}
+
+// Reload 重新读取 key_dir 中的密钥，其他实例轮换出的新密钥由此生效
+func (j *JWT) Reload() error {
+	if !j.Asymmetric() {
+		return nil
+	}
+	if err := os.MkdirAll(j.keyDir, 0o700); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(j.keyDir)
+	if err != nil {
+		return err
+	}
+	var keys []*signingKey
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+		key, err := j.loadKey(filepath.Join(j.keyDir, entry.Name()))
+		if err != nil {
+			// 算法不匹配或文件损坏时跳过，不影响其他密钥
+			log.Printf("jwt: skip key %s: %v", entry.Name(), err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		return keys[a].createdAt.Before(keys[b].createdAt)
+	})
+
+	j.mu.Lock()
+	j.keys = keys
+	j.mu.Unlock()
+	return nil
+}
+
+// Rotate 当前密钥超过轮换周期时生成新密钥，并删除超过保留期的旧密钥。
+// 多实例部署时应在分布式锁内调用
+func (j *JWT) Rotate() (bool, error) {
+	if !j.Asymmetric() {
+		return false, nil
+	}
+	if err := j.Reload(); err != nil {
+		return false, err
+	}
+	rotated := false
+	if key := j.current(); key == nil || time.Since(key.createdAt) >= j.rotation {
+		if err := j.addKey(); err != nil {
+			return false, err
+		}
+		rotated = true
+	}
+	return rotated, j.purge()
+}
+
+// purge 删除已被替换且超过保留期的密钥，
+// 一个密钥被替换的时间即下一个密钥的创建时间
+func (j *JWT) purge() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	kept := j.keys[:0]
+	for i, key := range j.keys {
+		if i+1 < len(j.keys) && time.Since(j.keys[i+1].createdAt) > j.retention {
+			if err := os.Remove(filepath.Join(j.keyDir, key.kid+".pem")); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		kept = append(kept, key)
+	}
+	j.keys = kept
+	return nil
+}
+
+func (j *JWT) current() *signingKey {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if len(j.keys) == 0 {
+		return nil
+	}
+	return j.keys[len(j.keys)-1]
+}
+
+func (j *JWT) lookup(kid string) *signingKey {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	for _, key := range j.keys {
+		if key.kid == kid {
+			return key
+		}
+	}
+	return nil
+}
+
+// addKey 生成新密钥并写入 key_dir，先写临时文件再改名，避免其他实例读到半个文件
+func (j *JWT) addKey() error {
+	var private crypto.Signer
+	var err error
+	switch j.method {
+	case jwt.SigningMethodRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case jwt.SigningMethodEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return fmt.Errorf("unsupported jwt algorithm: %s", j.method.Alg())
+	}
+	if err != nil {
+		return err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return err
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	now := time.Now()
+	kid := strconv.FormatInt(now.Unix(), 10) + "-" + hex.EncodeToString(suffix)
+
+	tmp, err := os.CreateTemp(j.keyDir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := pem.Encode(tmp, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(j.keyDir, kid+".pem")); err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	j.keys = append(j.keys, &signingKey{
+		kid:       kid,
+		createdAt: now,
+		private:   private,
+		public:    private.Public(),
+	})
+	j.mu.Unlock()
+	log.Printf("jwt: new signing key %s", kid)
+	return nil
+}
+
+func (j *JWT) loadKey(path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	var private crypto.Signer
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		if j.method == jwt.SigningMethodRS256 {
+			private = k
+		}
+	case ed25519.PrivateKey:
+		if j.method == jwt.SigningMethodEdDSA {
+			private = k
+		}
+	}
+	if private == nil {
+		return nil, fmt.Errorf("key type %T does not match %s", parsed, j.method.Alg())
+	}
+
+	kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+	unix, err := strconv.ParseInt(strings.SplitN(kid, "-", 2)[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kid %q", kid)
+	}
+	return &signingKey{
+		kid:       kid,
+		createdAt: time.Unix(unix, 0),
+		private:   private,
+		public:    private.Public(),
+	}, nil
+}
+
+// JWK RFC 7517 公钥
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS 返回所有仍可用于验证的公钥，HS256 下为空
+func (j *JWT) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	for _, key := range j.keys {
+		jwk := JWK{
+			Kid: key.kid,
+			Use: "sig",
+			Alg: j.method.Alg(),
+		}
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
@@ -0,0 +1,196 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"github.com/jekyulll/url_shortener/config"
+	goqrcode "github.com/skip2/go-qrcode"
+)
+
+var ErrInvalidOption = errors.New("invalid qrcode option")
+
+type Options struct {
+	Size       int    // 图片边长（像素）
+	Level      string // 纠错等级：L、M、Q、H
+	Margin     int    // 静区宽度（模块数）
+	Foreground string // 前景色，十六进制，如 000000
+	Background string // 背景色，十六进制，如 ffffff
+}
+
+type Generator struct {
+	defaults Options
+}
+
+func NewGenerator(cfg config.QRCodeConfig) (*Generator, error) {
+	g := &Generator{
+		defaults: Options{
+			Size:       cfg.Size,
+			Level:      cfg.Level,
+			Foreground: cfg.Foreground,
+			Background: cfg.Background,
+		},
+	}
+	// 配置里没写的项使用默认值
+	if g.defaults.Size <= 0 {
+		g.defaults.Size = 256
+	}
+	if g.defaults.Level == "" {
+		g.defaults.Level = "M"
+	}
+	// 0 表示不留静区，只有没写时才用默认值
+	g.defaults.Margin = 4
+	if cfg.Margin != nil {
+		g.defaults.Margin = *cfg.Margin
+	}
+	if g.defaults.Foreground == "" {
+		g.defaults.Foreground = "000000"
+	}
+	if g.defaults.Background == "" {
+		g.defaults.Background = "ffffff"
+	}
+	// 启动时就检查配置是否合法
+	if _, _, _, err := g.prepare("check", g.defaults); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Defaults 返回配置中的默认选项，调用方可在其基础上覆盖
+func (g *Generator) Defaults() Options {
+	return g.defaults
+}
+
+func (g *Generator) PNG(content string, opt Options) ([]byte, error) {
+	bitmap, fg, bg, err := g.prepare(content, opt)
+	if err != nil {
+		return nil, err
+	}
+	n := len(bitmap)
+	// 每个模块占 scale 像素，剩余部分居中留白
+	scale := opt.Size / n
+	if scale < 1 {
+		scale = 1
+	}
+	size := opt.Size
+	if size < n*scale {
+		size = n * scale
+	}
+	offset := (size - n*scale) / 2
+
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{bg, fg})
+	for y, row := range bitmap {
+		for x, set := range row {
+			if !set {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex(offset+x*scale+dx, offset+y*scale+dy, 1)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (g *Generator) SVG(content string, opt Options) ([]byte, error) {
+	bitmap, _, _, err := g.prepare(content, opt)
+	if err != nil {
+		return nil, err
+	}
+	n := len(bitmap)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opt.Size, opt.Size, n, n)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#%s"/>`, n, n, normalizeHex(opt.Background))
+	fmt.Fprintf(&buf, `<path fill="#%s" d="`, normalizeHex(opt.Foreground))
+	for y, row := range bitmap {
+		for x, set := range row {
+			if set {
+				fmt.Fprintf(&buf, "M%d,%dh1v1h-1z", x, y)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes(), nil
+}
+
+// prepare 校验选项并生成带静区的位图
+func (g *Generator) prepare(content string, opt Options) ([][]bool, color.Color, color.Color, error) {
+	level, err := parseLevel(opt.Level)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	fg, err := parseColor(opt.Foreground)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	bg, err := parseColor(opt.Background)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if opt.Size <= 0 || opt.Margin < 0 {
+		return nil, nil, nil, fmt.Errorf("%w: size %d, margin %d", ErrInvalidOption, opt.Size, opt.Margin)
+	}
+
+	q, err := goqrcode.New(content, level)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// 静区由自己绘制，以支持自定义宽度
+	q.DisableBorder = true
+	symbol := q.Bitmap()
+
+	n := len(symbol) + 2*opt.Margin
+	bitmap := make([][]bool, n)
+	for y := range bitmap {
+		bitmap[y] = make([]bool, n)
+	}
+	for y, row := range symbol {
+		copy(bitmap[y+opt.Margin][opt.Margin:], row)
+	}
+	return bitmap, fg, bg, nil
+}
+
+func parseLevel(level string) (goqrcode.RecoveryLevel, error) {
+	switch strings.ToUpper(level) {
+	case "L":
+		return goqrcode.Low, nil
+	case "M":
+		return goqrcode.Medium, nil
+	case "Q":
+		return goqrcode.High, nil
+	case "H":
+		return goqrcode.Highest, nil
+	}
+	return 0, fmt.Errorf("%w: level %q", ErrInvalidOption, level)
+}
+
+func normalizeHex(hex string) string {
+	return strings.ToLower(strings.TrimPrefix(hex, "#"))
+}
+
+func parseColor(hex string) (color.Color, error) {
+	hex = normalizeHex(hex)
+	if len(hex) != 6 {
+		return nil, fmt.Errorf("%w: color %q", ErrInvalidOption, hex)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("%w: color %q", ErrInvalidOption, hex)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
@@ -0,0 +1,30 @@
+package qrcode
+
+import (
+	"testing"
+
+	"github.com/jekyulll/url_shortener/config"
+)
+
+func TestNewGeneratorMargin(t *testing.T) {
+	zero, negative := 0, -1
+	tests := []struct {
+		margin *int
+		want   int
+	}{
+		{nil, 4},
+		{&zero, 0},
+	}
+	for _, tt := range tests {
+		g, err := NewGenerator(config.QRCodeConfig{Margin: tt.margin})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := g.Defaults().Margin; got != tt.want {
+			t.Errorf("margin = %d, want %d", got, tt.want)
+		}
+	}
+	if _, err := NewGenerator(config.QRCodeConfig{Margin: &negative}); err == nil {
+		t.Error("expected an error for a negative margin")
+	}
+}
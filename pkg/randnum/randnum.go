@@ -1,28 +1,28 @@
 package randnum
 
 import (
-	"math/rand"
-
 	"github.com/jekyulll/url_shortener/config"
+	"github.com/jekyulll/url_shortener/pkg/randstr"
 )
 
-const nums = "0123456789"
+// 未配置时验证码的位数
+const defaultLength = 6
 
 type RandNum struct {
 	length int
 }
 
 func NewRandNum(cfg config.RandNumConfig) *RandNum {
+	length := cfg.Length
+	if length <= 0 {
+		length = defaultLength
+	}
 	return &RandNum{
-		length: cfg.Length,
+		length: length,
 	}
 }
 
-func (r *RandNum) Generate() string {
-	result := make([]byte, r.length)
-	length := len(nums)
-	for i := range result {
-		result[i] = nums[rand.Intn(length)]
-	}
-	return string(result)
+// Generate 生成数字验证码，使用 crypto/rand，不可预测
+func (r *RandNum) Generate() (string, error) {
+	return randstr.Generate(randstr.Digits, r.length)
 }
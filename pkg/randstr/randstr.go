@@ -0,0 +1,61 @@
+package randstr
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+const (
+	Digits = "0123456789"
+	Base62 = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	// Unambiguous 去掉容易看混的 0/O/o、1/l/I
+	Unambiguous = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// Validate 字母表至少两个字符、不能重复，且只能是单字节字符
+func Validate(alphabet string) error {
+	if len(alphabet) < 2 || len(alphabet) > 256 {
+		return errors.New("alphabet must have between 2 and 256 characters")
+	}
+	var seen [256]bool
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if c >= 0x80 {
+			return fmt.Errorf("alphabet must be ASCII, got %q", alphabet)
+		}
+		if seen[c] {
+			return fmt.Errorf("duplicate character %q in alphabet", c)
+		}
+		seen[c] = true
+	}
+	return nil
+}
+
+// Generate 用 crypto/rand 从 alphabet 中随机取 length 个字符。
+// 直接对随机字节取模时，字母表长度不能整除 256 会让前面的字符概率偏高，
+// 所以丢弃落在最后不完整的一轮中的字节（拒绝采样）
+func Generate(alphabet string, length int) (string, error) {
+	n := len(alphabet)
+	if n < 2 || n > 256 {
+		return "", errors.New("alphabet must have between 2 and 256 characters")
+	}
+	limit := 256 - 256%n // 小于 limit 的字节取模后是均匀的
+	result := make([]byte, 0, length)
+	buf := make([]byte, length+length/2+8)
+	for len(result) < length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("read random bytes: %w", err)
+		}
+		for _, b := range buf {
+			if int(b) >= limit {
+				continue
+			}
+			result = append(result, alphabet[int(b)%n])
+			if len(result) == length {
+				break
+			}
+		}
+	}
+	return string(result), nil
+}
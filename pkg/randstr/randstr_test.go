@@ -0,0 +1,73 @@
+package randstr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	for _, alphabet := range []string{Digits, Base62, Unambiguous} {
+		s, err := Generate(alphabet, 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(s) != 32 {
+			t.Fatalf("got length %d, want 32", len(s))
+		}
+		for _, c := range s {
+			if !strings.ContainsRune(alphabet, c) {
+				t.Fatalf("%q not in alphabet %q", c, alphabet)
+			}
+		}
+	}
+	if _, err := Generate("a", 8); err == nil {
+		t.Error("expected an error for a one-character alphabet")
+	}
+}
+
+// 100 个字符时 256%100 = 56，直接取模会让前 56 个字符的概率是其余字符的 1.5 倍
+func TestGenerateUniform(t *testing.T) {
+	alphabet := make([]byte, 100)
+	for i := range alphabet {
+		alphabet[i] = byte(28 + i)
+	}
+	s, err := Generate(string(alphabet), 200000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var counts [100]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]-28]++
+	}
+	var low, high int
+	for i, n := range counts {
+		if i < 56 {
+			low += n
+		} else {
+			high += n
+		}
+	}
+	// 均匀分布时两组每个字符的平均次数都约为 2000
+	ratio := (float64(low) / 56) / (float64(high) / 44)
+	if ratio < 0.95 || ratio > 1.05 {
+		t.Errorf("biased output: per-character ratio %.3f, want about 1", ratio)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		alphabet string
+		ok       bool
+	}{
+		{Base62, true},
+		{"ab", true},
+		{"a", false},
+		{"abca", false},
+		{"abcé", false},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.alphabet); (err == nil) != tt.ok {
+			t.Errorf("Validate(%q) = %v, want ok %v", tt.alphabet, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 每隔多久清理一次不再活跃的 key
+const sweepInterval = time.Minute
+
+// Memory 单实例内存限流，多实例部署时各实例分别计数
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	windows   map[string]*window
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens   float64
+	last     time.Time
+	expireAt time.Time // 此后令牌已经补满，可以丢弃
+}
+
+type window struct {
+	index      int64 // 当前固定窗口的序号
+	prev, curr int
+	expireAt   time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		buckets:   make(map[string]*bucket),
+		windows:   make(map[string]*window),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow implements Limiter.
+func (m *Memory) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep(now)
+
+	if rule.Algorithm == SlidingWindow {
+		return m.slidingWindow(now, key, rule), nil
+	}
+	return m.tokenBucket(now, key, rule), nil
+}
+
+func (m *Memory) tokenBucket(now time.Time, key string, rule Rule) Result {
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rule.Limit), last: now}
+		m.buckets[key] = b
+	}
+	tokens, res := takeToken(b.tokens, now.Sub(b.last), rule)
+	b.tokens, b.last = tokens, now
+	b.expireAt = now.Add(res.Reset)
+	return res
+}
+
+func (m *Memory) slidingWindow(now time.Time, key string, rule Rule) Result {
+	index := now.UnixNano() / int64(rule.Window)
+	w, ok := m.windows[key]
+	switch {
+	case !ok:
+		w = &window{index: index}
+		m.windows[key] = w
+	case w.index == index-1:
+		w.index, w.prev, w.curr = index, w.curr, 0
+	case w.index != index:
+		w.index, w.prev, w.curr = index, 0, 0
+	}
+	elapsed := time.Duration(now.UnixNano() - index*int64(rule.Window))
+	curr, res := countWindow(w.prev, w.curr, elapsed, rule)
+	w.curr = curr
+	w.expireAt = now.Add(2 * rule.Window)
+	return res
+}
+
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for k, b := range m.buckets {
+		if now.After(b.expireAt) {
+			delete(m.buckets, k)
+		}
+	}
+	for k, w := range m.windows {
+		if now.After(w.expireAt) {
+			delete(m.windows, k)
+		}
+	}
+}
+
+var _ Limiter = (*Memory)(nil)
@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	TokenBucket   = "token_bucket"   // 容量为 Limit，每 Window/Limit 补充一个令牌，允许短时突发
+	SlidingWindow = "sliding_window" // 按上一个窗口加权估算最近 Window 内的请求数，更平滑严格
+)
+
+// Rule 在 Window 内最多允许 Limit 个请求
+type Rule struct {
+	Algorithm string
+	Limit     int
+	Window    time.Duration
+	Cost      int // 本次请求占用的额度，如批量创建的行数，0 按 1 计；超过 Limit 时永远不会放行
+}
+
+func (r Rule) cost() int {
+	return max(r.Cost, 1)
+}
+
+func (r Rule) Validate() error {
+	switch r.Algorithm {
+	case TokenBucket, SlidingWindow:
+	default:
+		return fmt.Errorf("unknown rate limit algorithm %q", r.Algorithm)
+	}
+	if r.Limit <= 0 || r.Window < time.Millisecond {
+		return fmt.Errorf("rate limit needs a positive limit and window")
+	}
+	return nil
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // 额度完全恢复（令牌桶）或当前窗口结束（滑动窗口）的时间
+	RetryAfter time.Duration // 被拒绝时至少需要等待的时间
+}
+
+type Limiter interface {
+	Allow(ctx context.Context, key string, rule Rule) (Result, error)
+}
+
+// takeToken 令牌桶：先按经过的时间补充令牌，再尝试取出 Cost 个，返回剩余令牌数
+func takeToken(tokens float64, elapsed time.Duration, rule Rule) (float64, Result) {
+	limit := float64(rule.Limit)
+	cost := float64(rule.cost())
+	rate := limit / float64(rule.Window) // 每纳秒补充的令牌数
+	tokens = math.Min(limit, tokens+float64(elapsed)*rate)
+
+	res := Result{Limit: rule.Limit}
+	if tokens >= cost {
+		tokens -= cost
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration(math.Ceil((cost - tokens) / rate))
+	}
+	res.Remaining = int(tokens)
+	res.Reset = time.Duration(math.Ceil((limit - tokens) / rate))
+	return tokens, res
+}
+
+// countWindow 滑动窗口：prev、curr 为上一个和当前固定窗口的请求数，elapsed 为当前窗口已经过的时间，
+// 放行时返回的 curr 已加上 Cost
+func countWindow(prev, curr int, elapsed time.Duration, rule Rule) (int, Result) {
+	window := float64(rule.Window)
+	limit := float64(rule.Limit)
+	cost := float64(rule.cost())
+	estimate := float64(prev)*(1-float64(elapsed)/window) + float64(curr)
+
+	res := Result{Limit: rule.Limit, Reset: rule.Window - elapsed}
+	if estimate+cost <= limit {
+		curr += rule.cost()
+		estimate += cost
+		res.Allowed = true
+	} else if curr+rule.cost() <= rule.Limit && prev > 0 {
+		// 等上一个窗口的权重下降到能再容纳这次请求
+		wait := window*(1-(limit-cost-float64(curr))/float64(prev)) - float64(elapsed)
+		res.RetryAfter = time.Duration(math.Ceil(wait))
+	} else {
+		// 当前窗口已满：等它成为上一个窗口，且权重下降到能再容纳这次请求
+		wait := window - float64(elapsed) + window*(1-(limit-cost)/float64(max(curr, 1)))
+		res.RetryAfter = time.Duration(math.Ceil(wait))
+	}
+	res.Remaining = int(math.Max(0, math.Floor(limit-estimate)))
+	return curr, res
+}
+
+// Fallback 主限流器（Redis）出错时改用备用限流器（内存），错误日志每分钟最多打印一次
+type Fallback struct {
+	primary   Limiter
+	secondary Limiter
+
+	mu       sync.Mutex
+	loggedAt time.Time
+}
+
+func NewFallback(primary, secondary Limiter) *Fallback {
+	return &Fallback{
+		primary:   primary,
+		secondary: secondary,
+	}
+}
+
+// Allow implements Limiter.
+func (f *Fallback) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	res, err := f.primary.Allow(ctx, key, rule)
+	if err == nil {
+		return res, nil
+	}
+	f.mu.Lock()
+	if time.Since(f.loggedAt) > time.Minute {
+		f.loggedAt = time.Now()
+		log.Printf("ratelimit: primary limiter failed, using fallback: %v", err)
+	}
+	f.mu.Unlock()
+	return f.secondary.Allow(ctx, key, rule)
+}
+
+var _ Limiter = (*Fallback)(nil)
@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryCost(t *testing.T) {
+	ctx := context.Background()
+	for _, algorithm := range []string{TokenBucket, SlidingWindow} {
+		m := NewMemory()
+		rule := Rule{Algorithm: algorithm, Limit: 100, Window: time.Hour, Cost: 60}
+
+		res, _ := m.Allow(ctx, "k", rule)
+		if !res.Allowed || res.Remaining != 40 {
+			t.Fatalf("%s: first take: %+v", algorithm, res)
+		}
+		res, _ = m.Allow(ctx, "k", rule)
+		if res.Allowed || res.RetryAfter <= 0 {
+			t.Fatalf("%s: over quota allowed: %+v", algorithm, res)
+		}
+		// 剩余额度仍可用于较小的请求
+		rule.Cost = 40
+		if res, _ = m.Allow(ctx, "k", rule); !res.Allowed {
+			t.Fatalf("%s: remaining quota rejected: %+v", algorithm, res)
+		}
+		// 不设置 Cost 时按 1 计
+		rule.Cost = 0
+		if res, _ = m.Allow(ctx, "other", rule); !res.Allowed || res.Remaining != 99 {
+			t.Fatalf("%s: default cost: %+v", algorithm, res)
+		}
+	}
+}
+
+func TestTakeTokenRetryAfterCoversCost(t *testing.T) {
+	rule := Rule{Algorithm: TokenBucket, Limit: 10, Window: 10 * time.Second, Cost: 5}
+	_, res := takeToken(2, 0, rule)
+	if res.Allowed {
+		t.Fatal("allowed without enough tokens")
+	}
+	// 每秒补充一个令牌，还差 3 个
+	if res.RetryAfter != 3*time.Second {
+		t.Fatalf("RetryAfter = %v, want 3s", res.RetryAfter)
+	}
+}
+
+func TestCountWindowCostAboveLimit(t *testing.T) {
+	rule := Rule{Algorithm: SlidingWindow, Limit: 10, Window: time.Minute, Cost: 11}
+	curr, res := countWindow(0, 0, 0, rule)
+	if res.Allowed || curr != 0 {
+		t.Fatalf("cost above limit allowed: curr=%d %+v", curr, res)
+	}
+}
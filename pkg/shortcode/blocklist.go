@@ -0,0 +1,58 @@
+package shortcode
+
+import "strings"
+
+// 与站点路径或常见页面同名的短码，完全匹配（不区分大小写）时不可用
+var defaultReservedWords = []string{
+	"api", "admin", "ping", "login", "logout", "register", "signup", "signin",
+	"auth", "oauth", "oidc", "account", "settings", "dashboard", "static", "assets",
+	"health", "status", "metrics", "docs", "help", "about", "terms", "privacy",
+	"favicon", "robots", "sitemap", "www", "mail", "support", "security",
+}
+
+// 不雅或冒犯性的词，短码中包含（不区分大小写，并识别常见的数字替代写法）即不可用
+var defaultBlockedWords = []string{
+	"fuck", "shit", "cunt", "bitch", "dick", "cock", "pussy", "porn", "slut",
+	"whore", "nazi", "fag", "nigg", "retard", "penis", "vagina",
+}
+
+// 数字替代字母的常见写法，如 sh1t、p0rn
+var leetReplacer = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b", "9", "g")
+
+type Blocklist struct {
+	reserved map[string]struct{}
+	blocked  []string
+}
+
+// NewBlocklist 在内置词表的基础上追加 reserved（完全匹配）和 blocked（包含即屏蔽）
+func NewBlocklist(reserved, blocked []string) *Blocklist {
+	b := &Blocklist{reserved: make(map[string]struct{})}
+	for _, w := range append(defaultReservedWords, reserved...) {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			b.reserved[w] = struct{}{}
+		}
+	}
+	for _, w := range append(defaultBlockedWords, blocked...) {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			b.blocked = append(b.blocked, w)
+		}
+	}
+	return b
+}
+
+func (b *Blocklist) Blocked(code string) bool {
+	lower := strings.ToLower(code)
+	if _, ok := b.reserved[lower]; ok {
+		return true
+	}
+	// 1 也常用来代替 l
+	variants := []string{lower, leetReplacer.Replace(lower), strings.ReplaceAll(leetReplacer.Replace(lower), "i", "l")}
+	for _, w := range b.blocked {
+		for _, v := range variants {
+			if strings.Contains(v, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
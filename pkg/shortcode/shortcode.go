@@ -1,27 +1,70 @@
 package shortcode
 
-import "math/rand"
+import (
+	"errors"
+	"fmt"
 
-type RandomShortCodeGeneratorImpl struct {
-	length int
+	"github.com/jekyulll/url_shortener/config"
+	"github.com/jekyulll/url_shortener/pkg/randstr"
+)
+
+const (
+	// 未配置时的短码长度
+	defaultLength = 6
+	// 连续生成的短码都被屏蔽时放弃，正常的字母表和长度下几乎不会发生
+	maxAttempts = 10
+)
+
+// 配置中可以直接使用的字母表名称
+var alphabets = map[string]string{
+	"":            randstr.Base62,
+	"base62":      randstr.Base62,
+	"unambiguous": randstr.Unambiguous,
 }
 
-func NewShortCodeGeneratorImpl(length int) *RandomShortCodeGeneratorImpl {
-	return &RandomShortCodeGeneratorImpl{
-		length: length,
-	}
+// Generator 用 crypto/rand 生成短码，跳过保留词和屏蔽词
+type Generator struct {
+	alphabet  string
+	length    int
+	blocklist *Blocklist
 }
 
-const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+func NewGenerator(cfg config.ShortCodeConfig) (*Generator, error) {
+	alphabet, ok := alphabets[cfg.Alphabet]
+	if !ok {
+		alphabet = cfg.Alphabet
+	}
+	if err := randstr.Validate(alphabet); err != nil {
+		return nil, fmt.Errorf("shortcode: %w", err)
+	}
+	length := cfg.Length
+	if length <= 0 {
+		length = defaultLength
+	}
+	return &Generator{
+		alphabet:  alphabet,
+		length:    length,
+		blocklist: NewBlocklist(cfg.ReservedWords, cfg.BlockedWords),
+	}, nil
+}
 
-// 使用随机数生成的短码
-func (s *RandomShortCodeGeneratorImpl) GenerateShortCode() string {
-	length := len(chars)
-	result := make([]byte, s.length)
-	for i := 0; i < s.length; i++ {
-		result[i] = chars[rand.Intn(length)]
+// GenerateShortCode 生成随机短码
+func (g *Generator) GenerateShortCode() (string, error) {
+	for i := 0; i < maxAttempts; i++ {
+		code, err := randstr.Generate(g.alphabet, g.length)
+		if err != nil {
+			return "", err
+		}
+		if !g.blocklist.Blocked(code) {
+			return code, nil
+		}
 	}
-	return string(result)
+	return "", errors.New("shortcode: too many blocked codes generated")
+}
+
+// Blocked 短码是保留词或包含屏蔽词，自定义短码同样适用
+func (g *Generator) Blocked(code string) bool {
+	return g.blocklist.Blocked(code)
 }
 
 // 解决重复长地址转换攻击
@@ -0,0 +1,160 @@
+// Package xlsx 流式写入只有一个工作表的 xlsx 文件，用于导出大量数据：
+// 每行写入后即压缩输出，不在内存中保留整个表格
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 工作表名的长度上限
+const maxSheetName = 31
+
+// xlsx 中除工作表外的固定部分
+var staticParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// Writer 调用 WriteRow 逐行写入，最后必须调用 Close
+type Writer struct {
+	zw   *zip.Writer
+	buf  *bufio.Writer
+	rows int
+}
+
+// NewWriter sheet 为工作表名，超过 31 个字符时截断
+func NewWriter(w io.Writer, sheet string) (*Writer, error) {
+	zw := zip.NewWriter(w)
+	for _, part := range staticParts {
+		if err := writePart(zw, part.name, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if sheet == "" {
+		sheet = "Sheet1"
+	}
+	if r := []rune(sheet); len(r) > maxSheetName {
+		sheet = string(r[:maxSheetName])
+	}
+	workbook := xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + escape(sheet) + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	if err := writePart(zw, "xl/workbook.xml", workbook); err != nil {
+		return nil, err
+	}
+	// 工作表最后写入，之后 zip 中不再创建其他文件，可以一直写到 Close
+	sw, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	buf := bufio.NewWriter(sw)
+	buf.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return &Writer{zw: zw, buf: buf}, nil
+}
+
+func writePart(zw *zip.Writer, name, content string) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, content)
+	return err
+}
+
+// WriteRow 支持字符串、整数、浮点数、布尔值和 time.Time，
+// 时间按 RFC 3339 写成文本，nil 为空单元格
+func (w *Writer) WriteRow(cells ...any) error {
+	w.rows++
+	row := strconv.Itoa(w.rows)
+	w.buf.WriteString(`<row r="` + row + `">`)
+	for i, cell := range cells {
+		if cell == nil {
+			continue
+		}
+		ref := columnName(i) + row
+		switch v := cell.(type) {
+		case string:
+			writeString(w.buf, ref, v)
+		case int:
+			writeNumber(w.buf, ref, strconv.Itoa(v))
+		case int32:
+			writeNumber(w.buf, ref, strconv.FormatInt(int64(v), 10))
+		case int64:
+			writeNumber(w.buf, ref, strconv.FormatInt(v, 10))
+		case uint:
+			writeNumber(w.buf, ref, strconv.FormatUint(uint64(v), 10))
+		case uint64:
+			writeNumber(w.buf, ref, strconv.FormatUint(v, 10))
+		case float64:
+			writeNumber(w.buf, ref, strconv.FormatFloat(v, 'g', -1, 64))
+		case bool:
+			b := "0"
+			if v {
+				b = "1"
+			}
+			w.buf.WriteString(`<c r="` + ref + `" t="b"><v>` + b + `</v></c>`)
+		case time.Time:
+			if !v.IsZero() {
+				writeString(w.buf, ref, v.Format(time.RFC3339))
+			}
+		default:
+			return fmt.Errorf("xlsx: unsupported cell type %T", cell)
+		}
+	}
+	// bufio 写出失败后会一直返回同一个错误
+	_, err := w.buf.WriteString(`</row>`)
+	return err
+}
+
+// Close 写入工作表结尾和 zip 目录，不会关闭底层的 io.Writer
+func (w *Writer) Close() error {
+	w.buf.WriteString(`</sheetData></worksheet>`)
+	return errors.Join(w.buf.Flush(), w.zw.Close())
+}
+
+func writeString(buf *bufio.Writer, ref, s string) {
+	buf.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+	buf.WriteString(escape(s))
+	buf.WriteString(`</t></is></c>`)
+}
+
+func writeNumber(buf *bufio.Writer, ref, n string) {
+	buf.WriteString(`<c r="` + ref + `"><v>` + n + `</v></c>`)
+}
+
+// escape 转义 XML 特殊字符，XML 中不允许的控制字符替换为 U+FFFD
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// columnName 0 -> A，25 -> Z，26 -> AA
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
@@ -0,0 +1,147 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sheetXML 解析工作表，返回每个单元格的引用和值
+type sheetXML struct {
+	Rows []struct {
+		R     string `xml:"r,attr"`
+		Cells []struct {
+			R      string `xml:"r,attr"`
+			T      string `xml:"t,attr"`
+			V      string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readParts(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = string(b)
+	}
+	return parts
+}
+
+func TestWriter(t *testing.T) {
+	var out bytes.Buffer
+	w, err := NewWriter(&out, "links & <more>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := time.Date(2025, 3, 1, 8, 30, 0, 0, time.UTC)
+	if err := w.WriteRow("short_url", "views", "disabled", "created_at"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow("https://sho.rt/a?x=1&y=<2>", int32(42), true, created); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow(nil, uint64(7), false, time.Time{}, 1.5, "bad\x00char"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	parts := readParts(t, out.Bytes())
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("missing part %s", name)
+		}
+	}
+	if !strings.Contains(parts["xl/workbook.xml"], `name="links &amp; &lt;more&gt;"`) {
+		t.Errorf("sheet name not escaped: %s", parts["xl/workbook.xml"])
+	}
+
+	var sheet sheetXML
+	if err := xml.Unmarshal([]byte(parts["xl/worksheets/sheet1.xml"]), &sheet); err != nil {
+		t.Fatalf("worksheet is not valid XML: %v", err)
+	}
+	if len(sheet.Rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(sheet.Rows))
+	}
+	row := sheet.Rows[1]
+	if row.R != "2" || len(row.Cells) != 4 {
+		t.Fatalf("unexpected row %+v", row)
+	}
+	if c := row.Cells[0]; c.R != "A2" || c.T != "inlineStr" || c.Inline != "https://sho.rt/a?x=1&y=<2>" {
+		t.Errorf("string cell %+v", c)
+	}
+	if c := row.Cells[1]; c.R != "B2" || c.T != "" || c.V != "42" {
+		t.Errorf("number cell %+v", c)
+	}
+	if c := row.Cells[2]; c.T != "b" || c.V != "1" {
+		t.Errorf("bool cell %+v", c)
+	}
+	if c := row.Cells[3]; c.Inline != "2025-03-01T08:30:00Z" {
+		t.Errorf("time cell %+v", c)
+	}
+
+	// nil 和零值时间不写单元格，后面的单元格引用不变
+	row = sheet.Rows[2]
+	refs := make([]string, len(row.Cells))
+	for i, c := range row.Cells {
+		refs[i] = c.R
+	}
+	if got := strings.Join(refs, ","); got != "B3,C3,E3,F3" {
+		t.Errorf("got cells %s, want B3,C3,E3,F3", got)
+	}
+	if c := row.Cells[3]; c.Inline != "bad�char" {
+		t.Errorf("control character not replaced: %q", c.Inline)
+	}
+}
+
+func TestWriterLongSheetName(t *testing.T) {
+	var out bytes.Buffer
+	w, err := NewWriter(&out, strings.Repeat("链", 40))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	parts := readParts(t, out.Bytes())
+	if !strings.Contains(parts["xl/workbook.xml"], `name="`+strings.Repeat("链", 31)+`"`) {
+		t.Errorf("sheet name not truncated to 31 characters: %s", parts["xl/workbook.xml"])
+	}
+}
+
+func TestWriteRowUnsupportedType(t *testing.T) {
+	w, err := NewWriter(io.Discard, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow(struct{}{}); err == nil {
+		t.Error("expected an error for an unsupported cell type")
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %s, want %s", i, got, want)
+		}
+	}
+}